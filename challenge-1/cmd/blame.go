@@ -0,0 +1,136 @@
+/*
+ * fools2024-solutions: source code for Kagamiin's solutions for TheZZAZZGlitch April Fools Event 2024's Security Testing Program.
+ * Copyright (C) 2024 Kagamiin~
+ * 
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License,
+ * or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program. If not, see <https://www.gnu.org/licenses/>.
+ */
+
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/Kagamiin/fools2024-solutions/challenge-1/cmd/decomp"
+)
+
+// parseAddrRange parses either a single hex address or an inclusive range
+// such as a7d0-a7ff.
+func parseAddrRange(s string) (start, end uint16, err error) {
+	parts := strings.SplitN(s, "-", 2)
+	parseAddr := func(p string) (uint16, error) {
+		p = strings.TrimPrefix(strings.ToLower(strings.TrimSpace(p)), "0x")
+		addr, err := strconv.ParseUint(p, 16, 16)
+		return uint16(addr), err
+	}
+	start, err = parseAddr(parts[0])
+	if err != nil {
+		return
+	}
+	end = start
+	if len(parts) == 2 {
+		end, err = parseAddr(parts[1])
+		if err != nil {
+			return
+		}
+	}
+	if end < start {
+		err = fmt.Errorf("invalid address range %s", s)
+	}
+	return
+}
+
+func blameJournal() {
+	flags := flag.NewFlagSet("blame", flag.ExitOnError)
+	depth := flags.Int("depth", 8, "maximum number of operations to follow back in each causal chain")
+	onlyBit := flags.Int("bit", -1, "only show the given bit (0 is the least significant bit)")
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), `usage: 
+	%v blame [options] journal addr[-addr]
+
+journal: decompression journal, as written by the record subcommand
+addr: address or inclusive address range to query, in hex (e.g. a7d0-a7ff)
+
+Prints, for every bit in the range, the journal operations that wrote to it,
+along with the chain of operations that caused each write.
+
+options:
+`, os.Args[0])
+		flags.PrintDefaults()
+	}
+	handle(flags.Parse(os.Args[2:]))
+	
+	if flags.NArg() != 2 {
+		flags.Usage()
+		os.Exit(1)
+	}
+	
+	recording, err := readJournalFile(flags.Arg(0))
+	handle(err)
+	
+	start, end, err := parseAddrRange(flags.Arg(1))
+	handle(err)
+	
+	blamer := recording.NewBlamer()
+	for addr := int(start); addr <= int(end); addr++ {
+		for bit := 7; bit >= 0; bit-- {
+			if *onlyBit >= 0 && bit != *onlyBit {
+				continue
+			}
+			writes := blamer.Blame(uint16(addr), uint8(bit), *depth)
+			if len(writes) == 0 {
+				continue
+			}
+			fmt.Printf("0x%04x bit %d:\n", addr, bit)
+			printed := make(map[*decomp.BitWrite]bool)
+			for _, write := range writes {
+				printBitWrite(write, 1, printed)
+			}
+		}
+	}
+}
+
+func printBitWrite(write *decomp.BitWrite, indent int, printed map[*decomp.BitWrite]bool) {
+	operation := write.Operation
+	fmt.Printf("%s#%d %-8v %-9v 0x%04x bit %d", strings.Repeat("  ", indent), write.Index,
+		operation.T, operation.Phase, write.Addr, write.Bit)
+	switch operation.T {
+	case decomp.DCopy, decomp.DXor:
+		fmt.Printf(" <- 0x%04x", operation.SourceAddr)
+	case decomp.Scale:
+		fmt.Printf(" <- 0x%04x bits %d-%d", operation.SourceAddr, operation.Value, operation.Value + 3)
+	case decomp.DeltaDec:
+		if operation.Value != 0 {
+			fmt.Printf(" (continues row from 0x%04x)", operation.SourceAddr)
+		} else {
+			fmt.Print(" (row start)")
+		}
+	}
+	if operation.Phase == decomp.PhaseImprint {
+		fmt.Printf(" from input bit %d (0x%04x.%d)", operation.StreamByte * 8 + int(operation.StreamBit),
+			operation.StreamByte, operation.StreamBit)
+	}
+	if printed[write] && len(write.Causes) > 0 {
+		fmt.Println(" (see above)")
+		return
+	}
+	fmt.Println()
+	printed[write] = true
+	for _, cause := range write.Causes {
+		printBitWrite(cause, indent + 1, printed)
+	}
+}
@@ -0,0 +1,107 @@
+/*
+ * fools2024-solutions: source code for Kagamiin's solutions for TheZZAZZGlitch April Fools Event 2024's Security Testing Program.
+ * Copyright (C) 2024 Kagamiin~
+ * 
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License,
+ * or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program. If not, see <https://www.gnu.org/licenses/>.
+ */
+
+package main
+
+import (
+	"encoding/hex"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"regexp"
+	"strconv"
+
+	"github.com/Kagamiin/fools2024-solutions/challenge-1/cmd/candidates"
+)
+
+func listCandidates() {
+	flags := flag.NewFlagSet("candidates", flag.ExitOnError)
+	maxBits := flags.Int("max-bits", 24, fmt.Sprintf("maximum number of unknown bits to enumerate, up to %d", candidates.MaxBits))
+	count := flags.Int("n", 20, "number of candidates to print, or 0 for all of them")
+	code := flags.Bool("sm83", false, "only keep candidates that disassemble as SM83 code without illegal opcodes")
+	codeOffset := flags.String("sm83-offset", "0", "offset (hex) of the code in the range")
+	codeStop := flags.Bool("sm83-stop", false, "stop checking the code at the first unconditional jump or return")
+	text := flags.Bool("text", false, "only keep candidates holding text terminated by 0x50, ranking the ones with more letters first")
+	textOffset := flags.String("text-offset", "0", "offset (hex) of the text in the range")
+	pattern := flags.String("regex", "", "only keep candidates matching a regular expression, against their raw bytes")
+	patternText := flags.Bool("regex-text", false, "match -regex against the candidates decoded as text instead")
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), `usage: 
+	%v candidates [options] result.bin unknownbits.bin addr[-addr]
+
+result.bin: recovered memory image, as written by undo
+unknownbits.bin: bit map of the unknown bits of the memory image
+addr: address or inclusive address range to enumerate, in hex (e.g. a7d0-a7ff)
+
+Lists every assignment of the unknown bits in the range that passes the
+filters, best ranked first.
+
+options:
+`, os.Args[0])
+		flags.PrintDefaults()
+	}
+	handle(flags.Parse(os.Args[2:]))
+	
+	if flags.NArg() != 3 {
+		flags.Usage()
+		os.Exit(1)
+	}
+	if *maxBits > candidates.MaxBits {
+		handle(fmt.Errorf("-max-bits can't be more than %d, got %d", candidates.MaxBits, *maxBits))
+	}
+	
+	memSpace, err := loadMemImage(flags.Arg(0))
+	handle(err)
+	unknownBitMap, err := os.ReadFile(flags.Arg(1))
+	handle(err)
+	if len(unknownBitMap) != 65536 {
+		handle(fmt.Errorf("%s: expected a 65536 byte bit map, got %d bytes", flags.Arg(1), len(unknownBitMap)))
+	}
+	start, end, err := parseAddrRange(flags.Arg(2))
+	handle(err)
+	
+	predicates := make([]candidates.Predicate, 0)
+	if *code {
+		offset, err := strconv.ParseUint(*codeOffset, 16, 16)
+		handle(err)
+		predicates = append(predicates, candidates.SM83Code{Offset: int(offset), StopAtEnd: *codeStop})
+	}
+	if *text {
+		offset, err := strconv.ParseUint(*textOffset, 16, 16)
+		handle(err)
+		predicates = append(predicates, candidates.Gen1Text{Offset: int(offset)})
+	}
+	if *pattern != "" {
+		re, err := regexp.Compile(*pattern)
+		handle(err)
+		predicates = append(predicates, candidates.Regex{Pattern: re, Text: *patternText})
+	}
+	
+	data := memSpace[start:int(end) + 1]
+	unknown := unknownBitMap[start:int(end) + 1]
+	survivors, accepted, err := candidates.Enumerate(data, unknown, *maxBits, *count, predicates)
+	handle(err)
+	log.Printf("%d candidates passed the filters\n", accepted)
+	
+	for i, candidate := range survivors {
+		fmt.Printf("#%d score %d assignment %b\n", i + 1, candidate.Score, candidate.Assignment)
+		fmt.Print(hex.Dump(candidate.Data))
+		fmt.Printf("text: %q\n\n", candidates.DecodeAllText(candidate.Data))
+	}
+}
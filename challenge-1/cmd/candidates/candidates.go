@@ -0,0 +1,137 @@
+/*
+ * fools2024-solutions: source code for Kagamiin's solutions for TheZZAZZGlitch April Fools Event 2024's Security Testing Program.
+ * Copyright (C) 2024 Kagamiin~
+ * 
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License,
+ * or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program. If not, see <https://www.gnu.org/licenses/>.
+ */
+
+package candidates
+
+import (
+	"container/heap"
+	"fmt"
+	"sort"
+)
+
+// A Predicate filters candidates, and scores the ones it accepts so that they
+// can be ranked, higher scores first.
+type Predicate interface {
+	Check(data []byte) (ok bool, score int)
+}
+
+type Candidate struct {
+	// Assignment holds the values of the unknown bits, in the order they
+	// appear in the region from the lowest bit of the first byte.
+	Assignment uint64
+	Data []byte
+	Score int
+}
+
+// better ranks candidates: higher scores first, then lower assignments, which
+// were found first.
+func better(a, b *Candidate) bool {
+	if a.Score != b.Score {
+		return a.Score > b.Score
+	}
+	return a.Assignment < b.Assignment
+}
+
+// ranking is a heap of the best candidates found so far, with the worst one on
+// top to be dropped first.
+type ranking []Candidate
+
+func (r ranking) Len() int { return len(r) }
+func (r ranking) Less(i, j int) bool { return better(&r[j], &r[i]) }
+func (r ranking) Swap(i, j int) { r[i], r[j] = r[j], r[i] }
+func (r *ranking) Push(x any) { *r = append(*r, x.(Candidate)) }
+func (r *ranking) Pop() any {
+	old := *r
+	last := old[len(old) - 1]
+	*r = old[:len(old) - 1]
+	return last
+}
+
+// MaxBits is the most unknown bits an assignment can hold.
+const MaxBits = 63
+
+// Enumerate tries every assignment of the bits set in unknown over data, and
+// returns the best keep candidates accepted by all predicates, best scores
+// first, along with how many were accepted in all. A keep of 0 returns all of
+// them. maxBits can't be more than MaxBits.
+func Enumerate(data, unknown []byte, maxBits int, keep int, predicates []Predicate) ([]Candidate, int, error) {
+	if maxBits > MaxBits {
+		return nil, 0, fmt.Errorf("can't enumerate more than %d unknown bits, got a limit of %d", MaxBits, maxBits)
+	}
+	if len(data) != len(unknown) {
+		return nil, 0, fmt.Errorf("expected as many bytes of unknown bits as of data, got %d and %d", len(unknown), len(data))
+	}
+	type bitPos struct {
+		offset int
+		mask uint8
+	}
+	bits := make([]bitPos, 0)
+	for offset, b := range unknown {
+		for bit := 0; bit < 8; bit++ {
+			if b & (1 << bit) != 0 {
+				bits = append(bits, bitPos{offset, 1 << bit})
+			}
+		}
+	}
+	if len(bits) > maxBits {
+		return nil, 0, fmt.Errorf("%d unknown bits, more than the %d allowed", len(bits), maxBits)
+	}
+
+	survivors := make(ranking, 0)
+	accepted := 0
+	candidate := make([]byte, len(data))
+	for assignment := uint64(0); assignment < 1 << len(bits); assignment++ {
+		copy(candidate, data)
+		for i, bit := range bits {
+			if assignment & (1 << i) != 0 {
+				candidate[bit.offset] |= bit.mask
+			} else {
+				candidate[bit.offset] &= ^bit.mask
+			}
+		}
+
+		total := 0
+		ok := true
+		for _, predicate := range predicates {
+			var score int
+			ok, score = predicate.Check(candidate)
+			if !ok {
+				break
+			}
+			total += score
+		}
+		if !ok {
+			continue
+		}
+		accepted++
+		found := Candidate{Assignment: assignment, Score: total}
+		if keep > 0 && len(survivors) == keep {
+			if !better(&found, &survivors[0]) {
+				continue
+			}
+			heap.Pop(&survivors)
+		}
+		found.Data = append([]byte(nil), candidate...)
+		heap.Push(&survivors, found)
+	}
+
+	sort.Slice(survivors, func(i, j int) bool {
+		return better(&survivors[i], &survivors[j])
+	})
+	return survivors, accepted, nil
+}
@@ -0,0 +1,119 @@
+/*
+ * fools2024-solutions: source code for Kagamiin's solutions for TheZZAZZGlitch April Fools Event 2024's Security Testing Program.
+ * Copyright (C) 2024 Kagamiin~
+ * 
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License,
+ * or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program. If not, see <https://www.gnu.org/licenses/>.
+ */
+
+package candidates_test
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/Kagamiin/fools2024-solutions/challenge-1/cmd/candidates"
+)
+
+func Test_EnumerateText(t *testing.T) {
+	// "HI!" with the low bit of the "I" and the terminator unknown
+	data := []byte{0x87, 0x88, 0xe7, 0x50}
+	unknown := []byte{0x00, 0x01, 0x00, 0x01}
+	survivors, _, err := candidates.Enumerate(data, unknown, 8, 0, []candidates.Predicate{candidates.Gen1Text{}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(survivors) != 2 {
+		t.Fatalf("expected 2 candidates, got %d", len(survivors))
+	}
+	for _, candidate := range survivors {
+		if candidate.Data[3] != 0x50 || candidate.Score != 2 {
+			t.Errorf("unexpected candidate % x with score %d", candidate.Data, candidate.Score)
+		}
+	}
+
+	re := regexp.MustCompile("^HI!@$")
+	survivors, _, err = candidates.Enumerate(data, unknown, 8, 0, []candidates.Predicate{candidates.Regex{Pattern: re, Text: true}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(survivors) != 1 || survivors[0].Assignment != 0 {
+		t.Errorf("expected only the original text to match, got %v", survivors)
+	}
+}
+
+func Test_EnumerateCode(t *testing.T) {
+	// ld a, $d3 / ret, then an illegal opcode unless bit 4 is cleared
+	data := []byte{0x3e, 0xd3, 0xc9, 0xd3}
+	unknown := []byte{0x00, 0x00, 0x00, 0x10}
+	survivors, _, err := candidates.Enumerate(data, unknown, 8, 0, []candidates.Predicate{candidates.SM83Code{}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(survivors) != 1 || survivors[0].Data[3] != 0xc3 {
+		t.Errorf("expected only jp to pass, got %v", survivors)
+	}
+
+	survivors, _, err = candidates.Enumerate(data, unknown, 8, 0, []candidates.Predicate{candidates.SM83Code{StopAtEnd: true}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(survivors) != 2 {
+		t.Errorf("expected both candidates to pass when stopping at ret, got %d", len(survivors))
+	}
+
+	if _, _, err = candidates.Enumerate(data, []byte{0xff, 0xff, 0, 0}, 8, 0, nil); err == nil {
+		t.Error("expected an error for too many unknown bits")
+	}
+	if _, _, err = candidates.Enumerate(data, unknown, candidates.MaxBits + 1, 0, nil); err == nil {
+		t.Error("expected an error for a limit past what an assignment can hold")
+	}
+}
+
+// halfScore accepts everything, scoring half the first byte so that pairs of
+// candidates tie.
+type halfScore struct{}
+
+func (halfScore) Check(data []byte) (bool, int) {
+	return true, int(data[0] >> 1)
+}
+
+func Test_EnumerateKeepsBest(t *testing.T) {
+	data := []byte{0x00}
+	unknown := []byte{0x0f}
+	all, accepted, err := candidates.Enumerate(data, unknown, 8, 0, []candidates.Predicate{halfScore{}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(all) != 16 || accepted != 16 {
+		t.Fatalf("expected all 16 candidates back, got %d of %d", len(all), accepted)
+	}
+
+	best, total, err := candidates.Enumerate(data, unknown, 8, 3, []candidates.Predicate{halfScore{}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if total != 16 {
+		t.Errorf("expected 16 candidates counted, got %d", total)
+	}
+	// ties go to the lower assignment
+	expected := []uint64{14, 15, 12}
+	if len(best) != len(expected) {
+		t.Fatalf("expected %d candidates kept, got %d", len(expected), len(best))
+	}
+	for i := range best {
+		if best[i].Assignment != expected[i] || best[i].Assignment != all[i].Assignment {
+			t.Errorf("candidate #%d: expected assignment %d, got %d", i + 1, expected[i], best[i].Assignment)
+		}
+	}
+}
@@ -0,0 +1,100 @@
+/*
+ * fools2024-solutions: source code for Kagamiin's solutions for TheZZAZZGlitch April Fools Event 2024's Security Testing Program.
+ * Copyright (C) 2024 Kagamiin~
+ * 
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License,
+ * or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program. If not, see <https://www.gnu.org/licenses/>.
+ */
+
+package candidates
+
+import (
+	"regexp"
+
+	"github.com/Kagamiin/fools2024-solutions/challenge-1/cmd/gen1sav"
+	"github.com/Kagamiin/fools2024-solutions/challenge-1/cmd/sm83"
+)
+
+// SM83Code accepts candidates that disassemble as SM83 code without illegal
+// opcodes, from Offset until the end, or until the first unconditional jump or
+// return if StopAtEnd is set. An instruction cut off by the end of the region
+// is accepted.
+type SM83Code struct {
+	Offset int
+	StopAtEnd bool
+}
+
+func (p SM83Code) Check(data []byte) (bool, int) {
+	for pc := p.Offset; pc < len(data); pc += sm83.Length(data[pc]) {
+		if sm83.Illegal(data[pc]) {
+			return false, 0
+		}
+		if p.StopAtEnd && sm83.EndsFlow(data[pc]) {
+			break
+		}
+	}
+	return true, 0
+}
+
+// Gen1Text accepts candidates holding text terminated by 0x50 at Offset, and
+// scores them by the number of letters and digits in it.
+type Gen1Text struct {
+	Offset int
+}
+
+func (p Gen1Text) Check(data []byte) (bool, int) {
+	if p.Offset >= len(data) {
+		return false, 0
+	}
+	_, end, ok := gen1sav.DecodeText(data[p.Offset:])
+	if !ok {
+		return false, 0
+	}
+	score := 0
+	for _, b := range data[p.Offset:p.Offset + end] {
+		if gen1sav.IsLetter(b) {
+			score++
+		}
+	}
+	return true, score
+}
+
+// Regex accepts candidates matching a regular expression, either against
+// their raw bytes, or against their decoding as text if Text is set. Text
+// decoding goes on past terminators, which show up as @.
+type Regex struct {
+	Pattern *regexp.Regexp
+	Text bool
+}
+
+func (p Regex) Check(data []byte) (bool, int) {
+	if !p.Text {
+		return p.Pattern.Match(data), 0
+	}
+	return p.Pattern.MatchString(DecodeAllText(data)), 0
+}
+
+// DecodeAllText decodes the whole of data as text, terminators included.
+func DecodeAllText(data []byte) string {
+	var text string
+	for len(data) > 0 {
+		part, end, _ := gen1sav.DecodeText(data)
+		text += part
+		if end < len(data) {
+			text += "@"
+			end++
+		}
+		data = data[end:]
+	}
+	return text
+}
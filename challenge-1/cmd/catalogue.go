@@ -0,0 +1,230 @@
+/*
+ * fools2024-solutions: source code for Kagamiin's solutions for TheZZAZZGlitch April Fools Event 2024's Security Testing Program.
+ * Copyright (C) 2024 Kagamiin~
+ * 
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License,
+ * or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program. If not, see <https://www.gnu.org/licenses/>.
+ */
+
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/Kagamiin/fools2024-solutions/challenge-1/cmd/decomp"
+	"github.com/Kagamiin/fools2024-solutions/challenge-1/cmd/romdata"
+)
+
+const sramEnd = 0xbfff
+
+type catalogueEntry struct {
+	Species string `json:"species"`
+	DexNumber uint8 `json:"dex"`
+	Bank string `json:"bank"`
+	Pointer string `json:"pointer"`
+	BaseWidth int `json:"base_width"`
+	BaseHeight int `json:"base_height"`
+	Width int `json:"width"`
+	Height int `json:"height"`
+	FirstBuffer int `json:"first_buffer"`
+	DecodeMode uint8 `json:"decode_mode"`
+	OpCounts map[string]int `json:"ops"`
+	HighestWrite string `json:"highest_write"`
+	PastBuffers bool `json:"sram_past_buffers"`
+	RanOffInput bool `json:"ran_off_input"`
+	Error string `json:"error,omitempty"`
+}
+
+func catalogueSprites() {
+	flags := flag.NewFlagSet("catalogue", flag.ExitOnError)
+	outPath := flags.String("o", "catalogue.csv", "path of the table to write")
+	formatName := flags.String("format", "", "table format: csv or json (default: guessed from the output path)")
+	workers := flags.Int("workers", runtime.NumCPU(), "number of sprites to decompress in parallel")
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), `usage: 
+	%v catalogue [options] pokeblue.sav
+
+pokeblue.sav: save file containing the source data where the sprites will be decompressed.
+
+Decompresses the front sprite of every species index from 0x00 to 0xff in
+journaling mode, resolving its bank, pointer and base dimensions the same way
+the game does, and writes a table summarizing each decompression.
+
+options:
+`, os.Args[0])
+		flags.PrintDefaults()
+	}
+	handle(flags.Parse(os.Args[2:]))
+
+	if flags.NArg() != 1 || *workers < 1 {
+		flags.Usage()
+		os.Exit(1)
+	}
+
+	format := *formatName
+	if format == "" {
+		format = "csv"
+		if filepath.Ext(*outPath) == ".json" {
+			format = "json"
+		}
+	}
+	if format != "csv" && format != "json" {
+		handle(fmt.Errorf("unknown table format %q", format))
+	}
+
+	savData, err := readSavFile(flags.Arg(0))
+	handle(err)
+
+	memSpace := make([]byte, 65536)
+	prepareMemSpace(memSpace, savData)
+	profile := loadProfile()
+
+	log.Printf("Decompressing 256 sprites with %d workers...\n", *workers)
+	log.SetOutput(io.Discard)
+
+	entries := make([]catalogueEntry, 256)
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for i := 0; i < *workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for species := range jobs {
+				entries[species] = catalogueSpecies(profile, memSpace, savData, uint8(species))
+			}
+		}()
+	}
+	for species := 0; species < 256; species++ {
+		jobs <- species
+	}
+	close(jobs)
+	wg.Wait()
+
+	log.SetOutput(os.Stderr)
+
+	f, err := os.Create(*outPath)
+	handle(err)
+	defer func() {
+		ec := f.Close()
+		handle(ec)
+	}()
+
+	if format == "json" {
+		encoder := json.NewEncoder(f)
+		encoder.SetIndent("", "  ")
+		handle(encoder.Encode(entries))
+	} else {
+		handle(writeCatalogueCsv(f, entries))
+	}
+
+	log.Println("Done.")
+}
+
+// catalogueSpecies decompresses the sprite of a single species, leaving
+// memSpace untouched.
+func catalogueSpecies(profile *romdata.Profile, memSpace, savData []byte, species uint8) catalogueEntry {
+	entry := catalogueEntry{
+		Species: fmt.Sprintf("0x%02x", species),
+		OpCounts: make(map[string]int),
+	}
+
+	sprite, recording, err := recordSpeciesSprite(profile, memSpace, savData, species)
+	if sprite == nil {
+		entry.Error = err.Error()
+		return entry
+	}
+	entry.DexNumber = sprite.DexNumber
+	entry.Bank = fmt.Sprintf("0x%02x", sprite.Bank)
+	entry.Pointer = fmt.Sprintf("0x%04x", spritePic(sprite))
+	entry.BaseWidth = sprite.BaseWidth()
+	entry.BaseHeight = sprite.BaseHeight()
+	if err != nil {
+		entry.Error = err.Error()
+		entry.RanOffInput = errors.Is(err, decomp.ErrPrematureEnd)
+	}
+	entry.Width = recording.WidthTiles
+	entry.Height = recording.HeightTiles
+	entry.FirstBuffer = recording.FirstBuffer
+	entry.DecodeMode = recording.DecodeMode
+
+	// anything written past the third sprite buffer up to the end of SRAM is
+	// collateral damage
+	spriteBuffersEnd := profile.SpriteBuffers[2] + romdata.SpriteBufferSize - 1
+	var highestWrite uint16
+	for _, operation := range recording.Operations {
+		entry.OpCounts[operation.T.String()]++
+		highestWrite = max(highestWrite, operation.DestAddr)
+		if operation.DestAddr > spriteBuffersEnd && operation.DestAddr <= sramEnd {
+			entry.PastBuffers = true
+		}
+	}
+	if len(recording.Operations) > 0 {
+		entry.HighestWrite = fmt.Sprintf("0x%04x", highestWrite)
+	}
+
+	return entry
+}
+
+func writeCatalogueCsv(w io.Writer, entries []catalogueEntry) error {
+	opNames := decomp.OpTypeNames()
+
+	header := []string{"species", "dex", "bank", "pointer", "base_width", "base_height",
+	                   "width", "height", "first_buffer", "decode_mode"}
+	for _, name := range opNames {
+		header = append(header, strings.ToLower(name) + "_ops")
+	}
+	header = append(header, "highest_write", "sram_past_buffers", "ran_off_input", "error")
+
+	csvWriter := csv.NewWriter(w)
+	err := csvWriter.Write(header)
+	if err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		record := []string{
+			entry.Species,
+			strconv.Itoa(int(entry.DexNumber)),
+			entry.Bank,
+			entry.Pointer,
+			strconv.Itoa(entry.BaseWidth),
+			strconv.Itoa(entry.BaseHeight),
+			strconv.Itoa(entry.Width),
+			strconv.Itoa(entry.Height),
+			strconv.Itoa(entry.FirstBuffer),
+			strconv.Itoa(int(entry.DecodeMode)),
+		}
+		for _, name := range opNames {
+			record = append(record, strconv.Itoa(entry.OpCounts[name]))
+		}
+		record = append(record, entry.HighestWrite, strconv.FormatBool(entry.PastBuffers),
+		                strconv.FormatBool(entry.RanOffInput), entry.Error)
+		err = csvWriter.Write(record)
+		if err != nil {
+			return err
+		}
+	}
+	csvWriter.Flush()
+	return csvWriter.Error()
+}
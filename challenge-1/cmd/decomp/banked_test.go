@@ -0,0 +1,134 @@
+/*
+ * fools2024-solutions: source code for Kagamiin's solutions for TheZZAZZGlitch April Fools Event 2024's Security Testing Program.
+ * Copyright (C) 2024 Kagamiin~
+ * 
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License,
+ * or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program. If not, see <https://www.gnu.org/licenses/>.
+ */
+
+package decomp_test
+
+import (
+	"bytes"
+	"io"
+	"log"
+	"math/rand"
+	"os"
+	"reflect"
+	"testing"
+
+	"github.com/Kagamiin/fools2024-solutions/challenge-1/cmd/decomp"
+	"github.com/Kagamiin/fools2024-solutions/challenge-1/cmd/gbmem"
+	"github.com/Kagamiin/fools2024-solutions/challenge-1/cmd/romdata"
+)
+
+func Test_RecordOnFlatMemory(t *testing.T) {
+	log.SetOutput(io.Discard)
+	defer log.SetOutput(os.Stderr)
+
+	rng := rand.New(rand.NewSource(1))
+	plane1 := randomPlane(rng, 5 * 6 * 8)
+	plane2 := randomPlane(rng, 5 * 6 * 8)
+	stream, err := decomp.CompressSprite(plane1, plane2, 5, 6)
+	if err != nil {
+		t.Fatal(err)
+	}
+	memSpace := make([]byte, 65536)
+	copy(memSpace[streamAddr:], stream)
+
+	expected := decomp.RecordDecompressSprite(memSpace, streamAddr, -1, -1)
+	live := make([]byte, 65536)
+	copy(live, memSpace)
+	recording, err := decomp.TryRecordDecompressSpriteOn(romdata.DefaultProfile, gbmem.Flat(live), streamAddr, -1, -1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(recording.Operations, expected.Operations) {
+		t.Error("recording through memory differs from recording from a slice")
+	}
+
+	expected.ApplyRecording(&memSpace)
+	if !bytes.Equal(live, memSpace) {
+		t.Error("live memory differs from the replayed recording")
+	}
+}
+
+func Test_RecordPastBankEnd(t *testing.T) {
+	log.SetOutput(io.Discard)
+	defer log.SetOutput(os.Stderr)
+
+	rng := rand.New(rand.NewSource(2))
+	plane1 := randomPlane(rng, 7 * 7 * 8)
+	plane2 := randomPlane(rng, 7 * 7 * 8)
+	stream, err := decomp.CompressSprite(plane1, plane2, 7, 7)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the stream starts at the end of bank 3, and continues in VRAM
+	const spriteAddr = 0x7ff0
+	rom := make([]byte, 4 * gbmem.ROMBankSize)
+	copy(rom[3 * gbmem.ROMBankSize + spriteAddr - 0x4000:], stream[:0x10])
+	image := make([]byte, 65536)
+	copy(image[0x8000:], stream[0x10:])
+
+	space := gbmem.NewAddressSpace(rom, make([]byte, gbmem.SRAMBankSize))
+	space.LoadImage(image)
+	space.Write(0x0000, 0x0a)
+	space.Write(0x2000, 0x03)
+	_, err = decomp.TryRecordDecompressSpriteOn(romdata.DefaultProfile, space, spriteAddr, -1, -1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !bytes.Equal(space.Image()[0xa188:0xa498], alignAndInterlace(plane1, plane2, 7, 7)) {
+		t.Error("sprite read across the end of the ROM bank doesn't match")
+	}
+}
+
+func Test_RecordIntoClearedBuffer(t *testing.T) {
+	log.SetOutput(io.Discard)
+	defer log.SetOutput(os.Stderr)
+
+	// a 1x1 sprite starting with BP2, whose first plane is all literal 3s,
+	// then mode 0, and a second plane with two runs of 1 before literal 3s
+	stream := []byte{
+		0x11, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xc0,
+		0x3f, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff,
+	}
+	// the stream starts in sprite buffer 0, and its second plane runs into
+	// sprite buffer 1, which gets cleared before the stream is read
+	const spriteAddr = 0xa188 - 10
+	original := make([]byte, 65536)
+	copy(original[spriteAddr:], stream)
+	live := bytes.Clone(original)
+	_, err := decomp.TryRecordDecompressSpriteOn(romdata.DefaultProfile, gbmem.Flat(live), spriteAddr, -1, -1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// so the second plane is read as zeroes, which write nothing into BP1
+	memSpace := make([]byte, 65536)
+	copy(memSpace[streamAddr:], stream[:10])
+	expected := decomp.RecordDecompressSprite(memSpace, streamAddr, -1, -1)
+	expected.ApplyRecording(&memSpace)
+	if !bytes.Equal(live[0xa188:0xa498], memSpace[0xa188:0xa498]) {
+		t.Error("sprite read from a cleared buffer doesn't match")
+	}
+
+	stale := decomp.RecordDecompressSprite(original, spriteAddr, -1, -1)
+	stale.ApplyRecording(&original)
+	if bytes.Equal(live[0xa188:0xa498], original[0xa188:0xa498]) {
+		t.Error("reading the stream as it was before clearing gives the same sprite")
+	}
+}
@@ -0,0 +1,156 @@
+/*
+ * fools2024-solutions: source code for Kagamiin's solutions for TheZZAZZGlitch April Fools Event 2024's Security Testing Program.
+ * Copyright (C) 2024 Kagamiin~
+ * 
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License,
+ * or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program. If not, see <https://www.gnu.org/licenses/>.
+ */
+
+package decomp
+
+import (
+	"sort"
+)
+
+// A BitWrite is a journal operation that wrote to a given bit, along with the
+// writes that determined the bits it read to do so.
+type BitWrite struct {
+	Index int
+	Operation Operation
+	Addr uint16
+	Bit uint8
+	Causes []*BitWrite
+}
+
+// A Blamer answers provenance queries over a journal.
+type Blamer struct {
+	recording *RecordedDecompression
+	writes map[uint16][]int
+}
+
+// blameKey includes the depth left, so that a chain cut short when reached
+// deep down isn't reused where it can be followed further.
+type blameKey struct {
+	index int
+	bit uint8
+	depth int
+}
+
+func (r *RecordedDecompression) NewBlamer() *Blamer {
+	b := Blamer{
+		recording: r,
+		writes: make(map[uint16][]int),
+	}
+	for i, operation := range r.Operations {
+		b.writes[operation.DestAddr] = append(b.writes[operation.DestAddr], i)
+	}
+	return &b
+}
+
+// WrittenBits returns the mask of the destination bits an operation overwrites.
+func (o Operation) WrittenBits() uint8 {
+	switch o.T {
+	case Or, DXor:
+		return o.Mask
+	}
+	return 0xff
+}
+
+// Writes returns the indices of all operations writing to the given bit, in
+// journal order. Bit 0 is the least significant bit.
+func (b *Blamer) Writes(addr uint16, bit uint8) []int {
+	result := make([]int, 0)
+	for _, index := range b.writes[addr] {
+		if b.recording.Operations[index].WrittenBits() & (1 << bit) != 0 {
+			result = append(result, index)
+		}
+	}
+	return result
+}
+
+func (b *Blamer) lastWriteBefore(addr uint16, bit uint8, before int) int {
+	indices := b.writes[addr]
+	pos := sort.SearchInts(indices, before)
+	for i := pos - 1; i >= 0; i-- {
+		if b.recording.Operations[indices[i]].WrittenBits() & (1 << bit) != 0 {
+			return indices[i]
+		}
+	}
+	return -1
+}
+
+// Blame returns every write to the given bit, each with the chain of writes
+// that caused it, followed back through at most maxDepth operations.
+func (b *Blamer) Blame(addr uint16, bit uint8, maxDepth int) []*BitWrite {
+	memo := make(map[blameKey]*BitWrite)
+	result := make([]*BitWrite, 0)
+	for _, index := range b.Writes(addr, bit) {
+		result = append(result, b.blameWrite(index, addr, bit, maxDepth, memo))
+	}
+	return result
+}
+
+func (b *Blamer) blameWrite(index int, addr uint16, bit uint8, depth int, memo map[blameKey]*BitWrite) *BitWrite {
+	key := blameKey{index, bit, depth}
+	if write, ok := memo[key]; ok {
+		return write
+	}
+	write := &BitWrite{
+		Index: index,
+		Operation: b.recording.Operations[index],
+		Addr: addr,
+		Bit: bit,
+		Causes: make([]*BitWrite, 0),
+	}
+	memo[key] = write
+	if depth <= 0 {
+		return write
+	}
+
+	addCause := func(causeAddr uint16, causeBit uint8) {
+		causeIndex := b.lastWriteBefore(causeAddr, causeBit, index)
+		if causeIndex < 0 {
+			return
+		}
+		for _, cause := range write.Causes {
+			if cause.Index == causeIndex && cause.Bit == causeBit {
+				return
+			}
+		}
+		write.Causes = append(write.Causes, b.blameWrite(causeIndex, causeAddr, causeBit, depth - 1, memo))
+	}
+
+	operation := write.Operation
+	switch operation.T {
+	case Or:
+		// the previous value of the bit is merged with the imprinted one
+		addCause(addr, bit)
+	case DCopy:
+		addCause(operation.SourceAddr, bit)
+	case DXor:
+		addCause(operation.SourceAddr, bit)
+		addCause(addr, bit)
+	case Scale:
+		// every bit of the nybble is doubled
+		addCause(operation.SourceAddr, operation.Value + bit / 2)
+	case DeltaDec:
+		// every bit is the parity of all bits before it in the row
+		for higherBit := bit; higherBit < 8; higherBit++ {
+			addCause(addr, higherBit)
+		}
+		if operation.Value != 0 {
+			addCause(operation.SourceAddr, 0)
+		}
+	}
+	return write
+}
@@ -0,0 +1,126 @@
+/*
+ * fools2024-solutions: source code for Kagamiin's solutions for TheZZAZZGlitch April Fools Event 2024's Security Testing Program.
+ * Copyright (C) 2024 Kagamiin~
+ * 
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License,
+ * or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program. If not, see <https://www.gnu.org/licenses/>.
+ */
+
+package decomp_test
+
+import (
+	"io"
+	"log"
+	"math/rand"
+	"os"
+	"testing"
+
+	"github.com/Kagamiin/fools2024-solutions/challenge-1/cmd/decomp"
+)
+
+func Test_ImprintStreamPositions(t *testing.T) {
+	log.SetOutput(io.Discard)
+	defer log.SetOutput(os.Stderr)
+
+	rng := rand.New(rand.NewSource(2))
+	stream, err := decomp.CompressSprite(randomPlane(rng, 4 * 4 * 8), randomPlane(rng, 4 * 4 * 8), 4, 4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	memSpace := make([]byte, 65536)
+	copy(memSpace[streamAddr:], stream)
+
+	recording := decomp.RecordDecompressSprite(memSpace, streamAddr, -1, -1)
+	imprints := 0
+	for i, operation := range recording.Operations {
+		if operation.Phase != decomp.PhaseImprint {
+			continue
+		}
+		imprints++
+		bitOffset := operation.StreamByte * 8 + int(operation.StreamBit)
+		var pair uint8
+		for j := 0; j < 2; j++ {
+			offset := bitOffset + j
+			pair = pair << 1 | (memSpace[offset / 8] >> (7 - offset % 8)) & 1
+		}
+		shift := 0
+		for ; (operation.Mask >> shift) & 3 != 3; shift += 2 {
+		}
+		if pair != operation.Value >> shift {
+			t.Fatalf("operation %d: stream position 0x%04x.%d holds %02b, expected %02b",
+				i, operation.StreamByte, operation.StreamBit, pair, operation.Value >> shift)
+		}
+	}
+	if imprints == 0 {
+		t.Fatal("no imprinting operations were recorded")
+	}
+}
+
+func Test_BlameFollowsCopies(t *testing.T) {
+	recording := decomp.RecordedDecompression{
+		Operations: []decomp.Operation{
+			{T: decomp.Fill, DestAddr: 0x10, Mask: 0xff, Phase: decomp.PhaseClear},
+			{T: decomp.Or, DestAddr: 0x10, Mask: 0x03, Value: 0x01, Phase: decomp.PhaseImprint, StreamByte: 0x20, StreamBit: 6},
+			{T: decomp.DCopy, DestAddr: 0x11, SourceAddr: 0x10, Mask: 0xff, Phase: decomp.PhaseCopyAlign},
+			{T: decomp.DCopy, DestAddr: 0x12, SourceAddr: 0x11, Mask: 0xff, Phase: decomp.PhaseInterlace},
+		},
+	}
+
+	writes := recording.NewBlamer().Blame(0x12, 0, 10)
+	if len(writes) != 1 || writes[0].Index != 3 {
+		t.Fatalf("expected a single write by operation 3, got %v", writes)
+	}
+	chain := []int{}
+	for write := writes[0]; ; {
+		chain = append(chain, write.Index)
+		if len(write.Causes) == 0 {
+			break
+		}
+		write = write.Causes[0]
+	}
+	expected := []int{3, 2, 1, 0}
+	if len(chain) != len(expected) {
+		t.Fatalf("expected chain %v, got %v", expected, chain)
+	}
+	for i := range expected {
+		if chain[i] != expected[i] {
+			t.Fatalf("expected chain %v, got %v", expected, chain)
+		}
+	}
+
+	if writes := recording.NewBlamer().Blame(0x12, 0, 1); len(writes[0].Causes) != 1 || len(writes[0].Causes[0].Causes) != 0 {
+		t.Error("expected the chain to stop at the maximum depth")
+	}
+}
+
+func Test_BlameDepthDoesntDependOnOrder(t *testing.T) {
+	recording := decomp.RecordedDecompression{
+		Operations: []decomp.Operation{
+			{T: decomp.Fill, DestAddr: 0x10, Mask: 0xff, Phase: decomp.PhaseClear},
+			{T: decomp.Or, DestAddr: 0x10, Mask: 0x03, Value: 0x01, Phase: decomp.PhaseImprint},
+			{T: decomp.DCopy, DestAddr: 0x11, SourceAddr: 0x10, Mask: 0xff, Phase: decomp.PhaseCopyAlign},
+			{T: decomp.DCopy, DestAddr: 0x12, SourceAddr: 0x11, Mask: 0xff, Phase: decomp.PhaseInterlace},
+			{T: decomp.DCopy, DestAddr: 0x12, SourceAddr: 0x10, Mask: 0xff, Phase: decomp.PhaseInterlace},
+		},
+	}
+
+	// operation 1 is first reached 2 operations deep through operation 3, and
+	// then only 1 operation deep through operation 4
+	writes := recording.NewBlamer().Blame(0x12, 0, 2)
+	if len(writes) != 2 || writes[1].Index != 4 || len(writes[1].Causes) != 1 {
+		t.Fatalf("expected operation 4 to be caused by operation 1, got %v", writes)
+	}
+	if causes := writes[1].Causes[0].Causes; len(causes) != 1 || causes[0].Index != 0 {
+		t.Errorf("expected operation 1 to be caused by operation 0 within the maximum depth, got %v", causes)
+	}
+}
@@ -0,0 +1,207 @@
+/*
+ * fools2024-solutions: source code for Kagamiin's solutions for TheZZAZZGlitch April Fools Event 2024's Security Testing Program.
+ * Copyright (C) 2024 Kagamiin~
+ * 
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License,
+ * or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program. If not, see <https://www.gnu.org/licenses/>.
+ */
+
+package decomp
+
+import (
+	"fmt"
+)
+
+type BitstreamWriter struct {
+	bitstream []byte
+	currentByte uint8
+	bitPosition int
+}
+
+func (b *BitstreamWriter) writeBit(bit uint8) {
+	b.currentByte <<= 1
+	b.currentByte |= bit & 1
+	b.bitPosition++
+	if b.bitPosition == 8 {
+		b.bitstream = append(b.bitstream, b.currentByte)
+		b.currentByte = 0
+		b.bitPosition = 0
+	}
+}
+
+func (b *BitstreamWriter) writeBits(value, numBits int) {
+	for i := numBits - 1; i >= 0; i-- {
+		b.writeBit(uint8((value >> i) & 1))
+	}
+}
+
+// Bytes returns the written bitstream, padding the last byte with zero bits.
+func (b *BitstreamWriter) Bytes() []byte {
+	result := make([]byte, len(b.bitstream), len(b.bitstream) + 1)
+	copy(result, b.bitstream)
+	if b.bitPosition != 0 {
+		result = append(result, b.currentByte << (8 - b.bitPosition))
+	}
+	return result
+}
+
+func writeExpGolombNumber(writer *BitstreamWriter, number int) error {
+	if number < 1 {
+		return fmt.Errorf("cannot encode %d as an Exp-Golomb number", number)
+	}
+	// A prefix of n one bits followed by a zero selects an offset of 2^(n+1) - 1,
+	// and is followed by n+1 bits to be added to that offset.
+	numOnes := 0
+	for ; number >= (2 << (numOnes + 1)) - 1; {
+		numOnes++
+	}
+	for i := 0; i < numOnes; i++ {
+		writer.writeBit(1)
+	}
+	writer.writeBit(0)
+	writer.writeBits(number - ((2 << numOnes) - 1), numOnes + 1)
+	return nil
+}
+
+// CompressSprite encodes two bitplanes into a Gen 1 compressed sprite
+// bitstream. The planes are expected in the layout the decompressor leaves
+// them in BP1 and BP2 right before copying/aligning, i.e. column-major with
+// widthTiles columns of heightTiles * 8 bytes each.
+//
+// All decode modes (0, 2 and 3) are tried with both buffer orders, and the
+// smallest resulting stream is returned.
+func CompressSprite(plane1, plane2 []byte, widthTiles, heightTiles int) ([]byte, error) {
+	if widthTiles < 1 || widthTiles > 15 || heightTiles < 1 || heightTiles > 15 {
+		return nil, fmt.Errorf("invalid sprite size %dx%d", widthTiles, heightTiles)
+	}
+	planeSize := widthTiles * heightTiles * 8
+	if len(plane1) != planeSize || len(plane2) != planeSize {
+		return nil, fmt.Errorf("expected planes of %d bytes, got %d and %d", planeSize, len(plane1), len(plane2))
+	}
+
+	var best []byte
+	for _, decodeMode := range []uint8{0, 2, 3} {
+		for firstBuffer := 1; firstBuffer <= 2; firstBuffer++ {
+			stream := compressWithMode(plane1, plane2, widthTiles, heightTiles, firstBuffer, decodeMode)
+			if best == nil || len(stream) < len(best) {
+				best = stream
+			}
+		}
+	}
+
+	return best, nil
+}
+
+func compressWithMode(plane1, plane2 []byte, widthTiles, heightTiles, firstBuffer int, decodeMode uint8) []byte {
+	planes := [][]byte{plane1, plane2}
+	first := planes[firstBuffer - 1]
+	second := planes[2 - firstBuffer]
+
+	var firstData, secondData []byte
+	switch decodeMode {
+	case 0:
+		firstData = deltaEncodePlane(first, widthTiles, heightTiles)
+		secondData = deltaEncodePlane(second, widthTiles, heightTiles)
+	case 2:
+		firstData = deltaEncodePlane(first, widthTiles, heightTiles)
+		secondData = xorPlanes(second, first)
+	case 3:
+		firstData = deltaEncodePlane(first, widthTiles, heightTiles)
+		secondData = deltaEncodePlane(xorPlanes(second, first), widthTiles, heightTiles)
+	}
+
+	writer := BitstreamWriter{}
+	writer.writeBits(widthTiles, 4)
+	writer.writeBits(heightTiles, 4)
+	writer.writeBit(uint8(firstBuffer - 1))
+	compressPlane(&writer, firstData, widthTiles, heightTiles)
+	if decodeMode == 0 {
+		writer.writeBit(0)
+	} else {
+		writer.writeBits(int(decodeMode), 2)
+	}
+	compressPlane(&writer, secondData, widthTiles, heightTiles)
+
+	return writer.Bytes()
+}
+
+func compressPlane(writer *BitstreamWriter, plane []byte, widthTiles, heightTiles int) {
+	rowCount := heightTiles * 8
+	totalPairs := rowCount * widthTiles * 4
+	pixelPair := func(offset int) int {
+		column := offset / rowCount
+		row := offset % rowCount
+		return int(plane[(column / 4) * rowCount + row] >> (6 - (column % 4) * 2)) & 3
+	}
+
+	offset := 0
+	if pixelPair(0) == 0 {
+		writer.writeBit(0)
+	} else {
+		writer.writeBit(1)
+	}
+	for ; offset < totalPairs; {
+		// RLE packet - only ever reached at a zero pair
+		if pixelPair(offset) == 0 {
+			runLength := 0
+			for ; offset < totalPairs && pixelPair(offset) == 0; offset++ {
+				runLength++
+			}
+			// cannot fail, as the run length is always at least 1
+			handle(writeExpGolombNumber(writer, runLength))
+			if offset >= totalPairs {
+				break
+			}
+		}
+		// literal packet
+		for ; offset < totalPairs && pixelPair(offset) != 0; offset++ {
+			writer.writeBits(pixelPair(offset), 2)
+		}
+		if offset < totalPairs {
+			writer.writeBits(0, 2)
+		}
+	}
+}
+
+func deltaEncodePlane(plane []byte, widthTiles, heightTiles int) []byte {
+	rowCount := heightTiles * 8
+	result := make([]byte, len(plane))
+	for row := 0; row < rowCount; row++ {
+		var state uint8
+		for column := 0; column < widthTiles; column++ {
+			addr := column * rowCount + row
+			result[addr], state = deltaEncodeByte(plane[addr], state)
+		}
+	}
+	return result
+}
+
+func deltaEncodeByte(value, state uint8) (uint8, uint8) {
+	var result uint8
+	for bit := 0; bit < 8; bit++ {
+		current := (value >> (7 - bit)) & 1
+		if current != state {
+			result |= 0x80 >> bit
+		}
+		state = current
+	}
+	return result, state
+}
+
+func xorPlanes(a, b []byte) []byte {
+	result := make([]byte, len(a))
+	for i := range a {
+		result[i] = a[i] ^ b[i]
+	}
+	return result
+}
@@ -0,0 +1,130 @@
+/*
+ * fools2024-solutions: source code for Kagamiin's solutions for TheZZAZZGlitch April Fools Event 2024's Security Testing Program.
+ * Copyright (C) 2024 Kagamiin~
+ * 
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License,
+ * or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program. If not, see <https://www.gnu.org/licenses/>.
+ */
+
+package decomp_test
+
+import (
+	"bytes"
+	"io"
+	"log"
+	"math/rand"
+	"os"
+	"reflect"
+	"testing"
+	"testing/quick"
+
+	"github.com/Kagamiin/fools2024-solutions/challenge-1/cmd/decomp"
+)
+
+const streamAddr = 0x4000
+
+// randomPlane generates a plane with runs of zero pixel pairs, so that both
+// literal and RLE packets get exercised.
+func randomPlane(rng *rand.Rand, size int) []byte {
+	plane := make([]byte, size)
+	density := rng.Intn(4)
+	for i := range plane {
+		switch density {
+		case 0:
+			plane[i] = 0
+		case 1:
+			if rng.Intn(8) == 0 {
+				plane[i] = uint8(rng.Intn(256))
+			}
+		case 2:
+			if rng.Intn(2) == 0 {
+				plane[i] = uint8(rng.Intn(256))
+			}
+		default:
+			plane[i] = uint8(rng.Intn(256))
+		}
+	}
+	return plane
+}
+
+// alignAndInterlace builds the contents of sprite buffers 1 and 2 that the
+// game leaves after copying/aligning and interlacing sprites of up to 7x7 tiles.
+func alignAndInterlace(plane1, plane2 []byte, widthTiles, heightTiles int) []byte {
+	buffer0 := make([]byte, 0x188)
+	buffer1 := make([]byte, 0x188)
+	startOffset := 8 * (7 * ((8 - widthTiles) / 2) + 7 - heightTiles)
+	rowCount := heightTiles * 8
+	for column := 0; column < widthTiles; column++ {
+		for row := 0; row < rowCount; row++ {
+			buffer0[startOffset + column * 56 + row] = plane1[column * rowCount + row]
+			buffer1[startOffset + column * 56 + row] = plane2[column * rowCount + row]
+		}
+	}
+
+	result := make([]byte, 0x310)
+	for i := 0; i < 0x188; i++ {
+		result[i * 2] = buffer0[i]
+		result[i * 2 + 1] = buffer1[i]
+	}
+	return result
+}
+
+func compressRoundTripCheck(plane1, plane2 []byte, widthTiles, heightTiles int) bool {
+	stream, err := decomp.CompressSprite(plane1, plane2, widthTiles, heightTiles)
+	if err != nil {
+		log.Println(err)
+		return false
+	}
+
+	memSpace := make([]byte, 65536)
+	copy(memSpace[streamAddr:], stream)
+
+	recording := decomp.RecordDecompressSprite(memSpace, streamAddr, -1, -1)
+	recording.ApplyRecording(&memSpace)
+
+	expected := alignAndInterlace(plane1, plane2, widthTiles, heightTiles)
+	if !bytes.Equal(memSpace[0xa188:0xa498], expected) {
+		log.SetOutput(os.Stderr)
+		log.Printf("size %dx%d, stream % x", widthTiles, heightTiles, stream)
+		log.Println(expected)
+		log.Println(memSpace[0xa188:0xa498])
+		return false
+	}
+	return true
+}
+
+func Test_CompressRoundTrip(t *testing.T) {
+	log.SetOutput(io.Discard)
+	defer log.SetOutput(os.Stderr)
+
+	conf := quick.Config{MaxCount: 500, MaxCountScale: 1.0, Values: func(res []reflect.Value, rng *rand.Rand) {
+		widthTiles := rng.Intn(7) + 1
+		heightTiles := rng.Intn(7) + 1
+		res[0] = reflect.ValueOf(randomPlane(rng, widthTiles * heightTiles * 8))
+		res[1] = reflect.ValueOf(randomPlane(rng, widthTiles * heightTiles * 8))
+		res[2] = reflect.ValueOf(widthTiles)
+		res[3] = reflect.ValueOf(heightTiles)
+	}}
+	if err := quick.Check(compressRoundTripCheck, &conf); err != nil {
+		t.Error(err)
+	}
+}
+
+func Test_CompressInvalidSize(t *testing.T) {
+	if _, err := decomp.CompressSprite(make([]byte, 8), make([]byte, 8), 0, 1); err == nil {
+		t.Error("expected an error for a sprite with zero width")
+	}
+	if _, err := decomp.CompressSprite(make([]byte, 8), make([]byte, 16), 1, 1); err == nil {
+		t.Error("expected an error for mismatched plane sizes")
+	}
+}
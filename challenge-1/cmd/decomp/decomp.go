@@ -20,50 +20,109 @@ package decomp
 
 import (
 	"log"
+
+	"github.com/Kagamiin/fools2024-solutions/challenge-1/cmd/gbmem"
 )
 
 func (r *RecordedDecompression) ApplyRecording(destMemory *[]byte) {
+	r.ReplayRecording(gbmem.Flat(*destMemory))
+}
+
+// ReplayRecording applies the journal through a memory model, such as a
+// banked address space where writes can land on MBC registers.
+func (r *RecordedDecompression) ReplayRecording(mem gbmem.Memory) {
 	
 	log.Println("Applying recorded decompression journal over saved data...")
 	
 	var integrator uint8
 	for _, operation := range r.Operations {
-		switch (operation.T) {
-		case Fill:
-			operation.DoFill(destMemory)
-		case Or:
-			operation.DoOr(destMemory)
-		case DCopy:
-			operation.DoDataCopy(destMemory)
-		case DXor:
-			operation.DoDataXor(destMemory)
-		case DeltaDec:
-			integrator = operation.DoDeltaDecode(destMemory, integrator)
-		}
+		integrator = operation.Replay(mem, integrator)
 	}
 }
 
+// Do applies a single operation, taking and returning the delta decoding
+// integrator state threaded through DeltaDec operations.
+func (o Operation) Do(destMemory *[]byte, integrator uint8) uint8 {
+	return o.Replay(gbmem.Flat(*destMemory), integrator)
+}
+
+// Replay is Do for any memory model.
+func (o Operation) Replay(mem gbmem.Memory, integrator uint8) uint8 {
+	switch (o.T) {
+	case Fill:
+		o.ReplayFill(mem)
+	case Or:
+		o.ReplayOr(mem)
+	case DCopy:
+		o.ReplayDataCopy(mem)
+	case DXor:
+		o.ReplayDataXor(mem)
+	case Scale:
+		o.ReplayScale(mem)
+	case DeltaDec:
+		integrator = o.ReplayDeltaDecode(mem, integrator)
+	}
+	return integrator
+}
+
 func (o Operation) DoFill(destMemory *[]byte) {
-	(*destMemory)[o.DestAddr] = o.Value & o.Mask
+	o.ReplayFill(gbmem.Flat(*destMemory))
 }
 
 func (o Operation) DoOr(destMemory *[]byte) {
-	(*destMemory)[o.DestAddr] |= o.Value & o.Mask
+	o.ReplayOr(gbmem.Flat(*destMemory))
 }
 
 func (o Operation) DoDataCopy(destMemory *[]byte) {
-	(*destMemory)[o.DestAddr] = (*destMemory)[o.SourceAddr] & o.Mask
+	o.ReplayDataCopy(gbmem.Flat(*destMemory))
 }
 
 func (o Operation) DoDataXor(destMemory *[]byte) {
-	(*destMemory)[o.DestAddr] = (((*destMemory)[o.SourceAddr] ^ (*destMemory)[o.DestAddr]) & o.Mask) | ((*destMemory)[o.DestAddr] & ^o.Mask)
+	o.ReplayDataXor(gbmem.Flat(*destMemory))
 }
 
 func (o Operation) DoDeltaDecode(destMemory *[]byte, state uint8) uint8 {
-	originalVal := (*destMemory)[o.DestAddr]
-	(*destMemory)[o.DestAddr] = 0
+	return o.ReplayDeltaDecode(gbmem.Flat(*destMemory), state)
+}
+
+func (o Operation) ReplayFill(mem gbmem.Memory) {
+	mem.Write(o.DestAddr, o.Value & o.Mask)
+}
+
+func (o Operation) ReplayOr(mem gbmem.Memory) {
+	mem.Write(o.DestAddr, mem.Read(o.DestAddr) | o.Value & o.Mask)
+}
+
+func (o Operation) ReplayDataCopy(mem gbmem.Memory) {
+	mem.Write(o.DestAddr, mem.Read(o.SourceAddr) & o.Mask)
+}
+
+func (o Operation) ReplayDataXor(mem gbmem.Memory) {
+	dest := mem.Read(o.DestAddr)
+	mem.Write(o.DestAddr, ((mem.Read(o.SourceAddr) ^ dest) & o.Mask) | (dest & ^o.Mask))
+}
+
+func (o Operation) ReplayScale(mem gbmem.Memory) {
+	mem.Write(o.DestAddr, duplicateBits((mem.Read(o.SourceAddr) >> o.Value) & 0x0f) & o.Mask)
+}
+
+// ReplayDeltaDecode decodes a byte given the integrator left by the previous
+// DeltaDec operation. A Value of 0 marks the start of a row, where the game
+// resets the integrator.
+func (o Operation) ReplayDeltaDecode(mem gbmem.Memory, state uint8) uint8 {
+	if o.Value == 0 {
+		state = 0
+	}
+	var result uint8
+	result, state = deltaDecodeByte(mem.Read(o.DestAddr), state)
+	mem.Write(o.DestAddr, result)
+	return state
+}
+
+func deltaDecodeByte(value, state uint8) (uint8, uint8) {
+	var result uint8
 	for bit := 0; bit < 8; bit++ {
-		if (originalVal & (0x80 >> bit)) != 0 {
+		if (value & (0x80 >> bit)) != 0 {
 			if state != 0 {
 				state = 0
 			} else {
@@ -71,10 +130,10 @@ func (o Operation) DoDeltaDecode(destMemory *[]byte, state uint8) uint8 {
 			}
 		}
 		if state != 0 {
-			(*destMemory)[o.DestAddr] |= 0x80 >> bit
+			result |= 0x80 >> bit
 		}
 	}
 	
-	return state
+	return result, state
 }
 
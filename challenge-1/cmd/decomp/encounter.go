@@ -0,0 +1,111 @@
+/*
+ * fools2024-solutions: source code for Kagamiin's solutions for TheZZAZZGlitch April Fools Event 2024's Security Testing Program.
+ * Copyright (C) 2024 Kagamiin~
+ * 
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License,
+ * or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program. If not, see <https://www.gnu.org/licenses/>.
+ */
+
+package decomp
+
+import (
+	"github.com/Kagamiin/fools2024-solutions/challenge-1/cmd/gbmem"
+	"github.com/Kagamiin/fools2024-solutions/challenge-1/cmd/romdata"
+)
+
+// RecordEncounter journals some of the writes a wild encounter with the
+// species makes on top of decompressing its sprite, the way InitWildBattle goes
+// about it: LoadEnemyMonData loads the base data into wMonHeader with
+// GetMonHeader and sets the species' seen flag, then the sprite gets
+// decompressed. Only the writes to wMonHeader, wd11e and the seen flag are
+// covered; those LoadEnemyMonData makes to the wEnemyMon* variables (DVs, HP,
+// stats, moves and the rest of the enemy's battle data) aren't journaled.
+//
+// GetMonHeader copies the base data from past the end of the table for glitch
+// species, and the seen flag of Pokédex number 0 is bit 7 of the flags' 32nd
+// byte, past the end of the flags, which is the quantity of the 6th bag item.
+//
+// The operations are replayed on mem as they're recorded, and base data the
+// game would read from outside of ROM is read through it, so that the sprite
+// can then be recorded over mem, seeing the writes, and added with WithSprite.
+func RecordEncounter(profile *romdata.Profile, rom []byte, mem gbmem.Memory, species uint8) (*RecordedDecompression, error) {
+	monHeader, err := profile.Symbol("wMonHeader")
+	if err != nil {
+		return nil, err
+	}
+	dexNumberAddr, err := profile.Symbol("wd11e")
+	if err != nil {
+		return nil, err
+	}
+	seenFlags, err := profile.Symbol("wPokedexSeen")
+	if err != nil {
+		return nil, err
+	}
+	spriteInfo, err := profile.LookupSpeciesSprite(rom, species)
+	if err != nil {
+		return nil, err
+	}
+
+	encounter := newRecording(profile, false)
+	encounter.live = mem
+	fill := func(addr uint16, value uint8) {
+		encounter.appendOp(Operation{T: Fill, DestAddr: addr, Mask: 0xff, Value: value})
+	}
+
+	// GetMonHeader
+	encounter.phase = PhaseMonHeader
+	fill(dexNumberAddr, species)
+	switch {
+	case spriteInfo.HeaderAddr == 0:
+		// the fossils and the ghost only get their sprite fields written
+		fill(monHeader + 10, spriteInfo.Dimensions)
+		fill(monHeader + 11, uint8(spriteInfo.FrontPic))
+		fill(monHeader + 12, uint8(spriteInfo.FrontPic >> 8))
+	default:
+		if species != romdata.SpeciesMew || profile.MewBaseStats.Addr == 0 {
+			// IndexToPokedex
+			fill(dexNumberAddr, spriteInfo.DexNumber)
+		}
+		for i := uint16(0); i < romdata.BaseDataSize; i++ {
+			addr := spriteInfo.HeaderAddr + i
+			value := romdata.ReadBanked(rom, spriteInfo.HeaderBank, addr)
+			if addr >= 0x8000 {
+				value = mem.Read(addr)
+			}
+			fill(monHeader + i, value)
+		}
+	}
+	// wMonHIndex
+	fill(monHeader, species)
+
+	// the seen flag, set with FlagActionPredef on the Pokédex number minus one
+	encounter.phase = PhaseDexFlag
+	dexNumber := profile.IndexToPokedex(rom, species)
+	fill(dexNumberAddr, species)
+	fill(dexNumberAddr, dexNumber)
+	flag := dexNumber - 1
+	encounter.appendOp(Operation{T: Or, DestAddr: seenFlags + uint16(flag >> 3), Mask: 0xff, Value: 1 << (flag & 7)})
+
+	return encounter, nil
+}
+
+// WithSprite returns the recording of a sprite made over the memory an
+// encounter was recorded on, with the encounter's operations put first.
+func (r *RecordedDecompression) WithSprite(sprite *RecordedDecompression) *RecordedDecompression {
+	combined := *sprite
+	combined.Operations = make([]Operation, 0, len(r.Operations) + len(sprite.Operations))
+	combined.Operations = append(combined.Operations, r.Operations...)
+	combined.Operations = append(combined.Operations, sprite.Operations...)
+	combined.live = nil
+	return &combined
+}
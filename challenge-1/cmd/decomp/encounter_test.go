@@ -0,0 +1,149 @@
+/*
+ * fools2024-solutions: source code for Kagamiin's solutions for TheZZAZZGlitch April Fools Event 2024's Security Testing Program.
+ * Copyright (C) 2024 Kagamiin~
+ * 
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License,
+ * or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program. If not, see <https://www.gnu.org/licenses/>.
+ */
+
+package decomp_test
+
+import (
+	"io"
+	"log"
+	"math/rand"
+	"os"
+	"testing"
+
+	"github.com/Kagamiin/fools2024-solutions/challenge-1/cmd/decomp"
+	"github.com/Kagamiin/fools2024-solutions/challenge-1/cmd/gbmem"
+	"github.com/Kagamiin/fools2024-solutions/challenge-1/cmd/romdata"
+)
+
+const missingno = 0x1f
+
+// missingnoROM builds a ROM where MissingNo. is Pokédex number 0, whose base
+// data is 255 entries into the table, and returns it with that base data.
+func missingnoROM(profile *romdata.Profile) (rom, header []byte) {
+	rom = make([]byte, 0x80000)
+	rom[int(profile.PokedexOrder.Bank) * 0x4000 + int(profile.PokedexOrder.Addr) - 0x4000 + missingno - 1] = 0
+	headerOffset := int(profile.BaseStats.Bank) * 0x4000 + int(profile.BaseStats.Addr + 0xff * romdata.BaseDataSize) - 0x4000
+	return rom, rom[headerOffset:headerOffset + romdata.BaseDataSize]
+}
+
+func Test_RecordEncounter(t *testing.T) {
+	log.SetOutput(io.Discard)
+	defer log.SetOutput(os.Stderr)
+
+	profile := romdata.DefaultProfile
+	rng := rand.New(rand.NewSource(5))
+	rom, header := missingnoROM(profile)
+	rng.Read(header)
+
+	plane1 := randomPlane(rng, 4 * 4 * 8)
+	plane2 := randomPlane(rng, 4 * 4 * 8)
+	stream, err := decomp.CompressSprite(plane1, plane2, 4, 4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	original := make([]byte, 65536)
+	rng.Read(original)
+	copy(original[streamAddr:], stream)
+
+	encounterMem := make([]byte, 65536)
+	copy(encounterMem, original)
+	encounter, err := decomp.RecordEncounter(profile, rom, gbmem.Flat(encounterMem), missingno)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sprite := decomp.RecordDecompressSprite(encounterMem, streamAddr, 4, 4)
+	recording := encounter.WithSprite(sprite)
+	if len(recording.Operations) != len(encounter.Operations) + len(sprite.Operations) ||
+	   recording.Operations[0].Phase != decomp.PhaseMonHeader || recording.WidthTiles != 4 {
+		t.Fatalf("expected the encounter to come before the sprite")
+	}
+
+	memSpace := make([]byte, 65536)
+	copy(memSpace, original)
+	recording.ApplyRecording(&memSpace)
+	if memSpace[0xd0b8] != missingno || memSpace[0xd0b9] != header[1] || memSpace[0xd0d3] != header[romdata.BaseDataSize - 1] {
+		t.Errorf("base data wasn't loaded into wMonHeader")
+	}
+	// the 6th bag item's quantity
+	if memSpace[0xd329] != original[0xd329] | 0x80 {
+		t.Errorf("expected the seen flag to set bit 7 of 0xd329, got 0x%02x from 0x%02x", memSpace[0xd329], original[0xd329])
+	}
+
+	unknownBitMap, conflicts := recording.UndoRecordingWithConflicts(&memSpace)
+	if len(conflicts) > 0 {
+		t.Fatalf("unexpected conflicts, first one: %v", conflicts[0])
+	}
+	for addr := range memSpace {
+		if wrong := (memSpace[addr] ^ original[addr]) & ^(*unknownBitMap)[addr]; wrong != 0 {
+			t.Fatalf("0x%04x: bits %08b are known but wrong", addr, wrong)
+		}
+	}
+	if (*unknownBitMap)[0xd0c0] != 0xff || (*unknownBitMap)[0xd11e] != 0xff || (*unknownBitMap)[0xd329] != 0x80 {
+		t.Errorf("expected wMonHeader, wd11e and the seen flag to be unknown after undoing")
+	}
+}
+
+func Test_RecordEncounterSpriteInMonHeader(t *testing.T) {
+	log.SetOutput(io.Discard)
+	defer log.SetOutput(os.Stderr)
+
+	// the sprite is read from wMonHeader, right past wMonHIndex, so it's only
+	// there once GetMonHeader has copied the base data over the garbage; it's
+	// small enough to end before wd11e
+	const monHeader = 0xd0b8
+	profile := romdata.DefaultProfile
+	rng := rand.New(rand.NewSource(6))
+	rom, header := missingnoROM(profile)
+
+	plane1 := randomPlane(rng, 8)
+	plane2 := randomPlane(rng, 8)
+	stream, err := decomp.CompressSprite(plane1, plane2, 1, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if monHeader + 1 + len(stream) > 0xd11e {
+		t.Fatalf("a %d byte stream runs into wd11e", len(stream))
+	}
+	inHeader := min(len(stream), romdata.BaseDataSize - 1)
+	copy(header[1:], stream[:inHeader])
+	original := make([]byte, 65536)
+	rng.Read(original)
+	copy(original[monHeader + romdata.BaseDataSize:], stream[inHeader:])
+
+	// what the sprite decompresses to when read from where it's intact
+	expected := make([]byte, 65536)
+	copy(expected[streamAddr:], stream)
+	decomp.RecordDecompressSprite(expected, streamAddr, 1, 1).ApplyRecording(&expected)
+
+	encounterMem := make([]byte, 65536)
+	copy(encounterMem, original)
+	encounter, err := decomp.RecordEncounter(profile, rom, gbmem.Flat(encounterMem), missingno)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sprite, err := decomp.TryRecordDecompressSpriteOn(profile, gbmem.Flat(encounterMem), monHeader + 1, 1, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	memSpace := make([]byte, 65536)
+	copy(memSpace, original)
+	encounter.WithSprite(sprite).ApplyRecording(&memSpace)
+	if i := firstDifference(memSpace[0xa000:0xa498], expected[0xa000:0xa498]); i >= 0 {
+		t.Errorf("sprite buffers differ at 0x%04x", 0xa000 + i)
+	}
+}
@@ -0,0 +1,183 @@
+/*
+ * fools2024-solutions: source code for Kagamiin's solutions for TheZZAZZGlitch April Fools Event 2024's Security Testing Program.
+ * Copyright (C) 2024 Kagamiin~
+ * 
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License,
+ * or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program. If not, see <https://www.gnu.org/licenses/>.
+ */
+
+package decomp
+
+import (
+	"errors"
+	"fmt"
+)
+
+// The fast decoder works on a scratch copy of SRAM from the first sprite
+// buffer up to the end of the address space, which is enough to hold every
+// buffer access made while decoding sprites of any size.
+const scratchBaseAddr = 0xa000
+const scratchSize = 0x10000 - scratchBaseAddr
+
+var ErrOutOfScratch = errors.New("sprite buffer access outside of SRAM scratch area")
+
+type DecodeError struct {
+	SpritePtr int
+	BytePosition int
+	Err error
+}
+
+func (e *DecodeError) Error() string {
+	return fmt.Sprintf("decoding sprite at 0x%04x: %v at byte 0x%04x", e.SpritePtr, e.Err, e.BytePosition)
+}
+
+func (e *DecodeError) Unwrap() error {
+	return e.Err
+}
+
+type DecodedSprite struct {
+	WidthTiles int
+	HeightTiles int
+	DecodeMode uint8
+	FirstBuffer int
+	// Tiles holds the sprite at its native size as interlaced 2bpp tile data,
+	// with tiles ordered column by column like the game does.
+	Tiles []byte
+	// scratch holds the sprite buffers right before copying/aligning.
+	scratch []byte
+}
+
+// DecodeSprite decompresses the sprite at spritePtr directly, without
+// journaling any of the operations involved.
+func DecodeSprite(rom []byte, spritePtr int) (*DecodedSprite, error) {
+	spriteReader := BitstreamReader{
+		bitstream: rom,
+		bytePosition: spritePtr,
+	}
+	wrapErr := func(err error) error {
+		return &DecodeError{
+			SpritePtr: spritePtr,
+			BytePosition: spriteReader.bytePosition,
+			Err: err,
+		}
+	}
+
+	sprite := DecodedSprite{
+		scratch: make([]byte, scratchSize),
+	}
+	var err error
+
+	sprite.WidthTiles, sprite.HeightTiles, err = readSpriteSize(&spriteReader)
+	if err != nil {
+		return nil, wrapErr(err)
+	}
+	firstBuffer, secondBuffer, err := readBufferOrder(&spriteReader)
+	if err != nil {
+		return nil, wrapErr(err)
+	}
+	sprite.FirstBuffer = firstBuffer
+
+	imprint := func(addr uint16, mask, value uint8, streamOffset int) {
+		sprite.scratch[addr - scratchBaseAddr] |= value & mask
+	}
+	bufferOrder := []int{firstBuffer, secondBuffer}
+	for i := 0; i < 2; i++ {
+		if i == 1 {
+			sprite.DecodeMode, err = readDecodeMode(&spriteReader)
+			if err != nil {
+				return nil, wrapErr(err)
+			}
+		}
+		err = walkPlane(defaultSpriteBuffers, &spriteReader, sprite.HeightTiles, sprite.WidthTiles, bufferOrder[i], imprint)
+		if err != nil {
+			return nil, wrapErr(err)
+		}
+	}
+
+	for _, step := range unpackSteps(sprite.DecodeMode, firstBuffer, secondBuffer) {
+		if step.xor {
+			forEachXorPair(defaultSpriteBuffers, sprite.HeightTiles, sprite.WidthTiles, step.sourceBuffer, step.buffer, func(destAddr, sourceAddr uint16) {
+				sprite.scratch[destAddr - scratchBaseAddr] ^= sprite.scratch[sourceAddr - scratchBaseAddr]
+			})
+		} else {
+			var state uint8
+			forEachDeltaDecodeByte(defaultSpriteBuffers, sprite.HeightTiles, sprite.WidthTiles, step.buffer, func(addr, prevAddr uint16, rowStart bool) {
+				if rowStart {
+					state = 0
+				}
+				sprite.scratch[addr - scratchBaseAddr], state = deltaDecodeByte(sprite.scratch[addr - scratchBaseAddr], state)
+			})
+		}
+	}
+
+	sprite.Tiles = sprite.nativeTiles()
+	return &sprite, nil
+}
+
+func (s *DecodedSprite) nativeTiles() []byte {
+	widthTiles := s.WidthTiles
+	if widthTiles == 0 {
+		widthTiles = 32
+	}
+	heightTiles := s.HeightTiles
+	if heightTiles == 0 {
+		heightTiles = 32
+	}
+	rowCount := heightTiles * 8
+
+	tiles := make([]byte, widthTiles * rowCount * 2)
+	for offset := 0; offset < widthTiles * rowCount; offset++ {
+		tiles[offset * 2] = s.scratch[int(defaultSpriteBuffers.baseAddr(1)) - scratchBaseAddr + offset]
+		tiles[offset * 2 + 1] = s.scratch[int(defaultSpriteBuffers.baseAddr(2)) - scratchBaseAddr + offset]
+	}
+	return tiles
+}
+
+// AlignedTiles copies/aligns and interlaces the decoded sprite the same way
+// the game does, using the given base data dimensions (or the sprite's own
+// dimensions, if negative). It returns the 7x7 tile contents of sprite
+// buffers 1 and 2 as left by the game.
+func (s *DecodedSprite) AlignedTiles(baseDataWidth, baseDataHeight int) ([]byte, error) {
+	if baseDataWidth < 0 {
+		baseDataWidth = s.WidthTiles
+	}
+	if baseDataHeight < 0 {
+		baseDataHeight = s.HeightTiles
+	}
+
+	scratch := make([]byte, len(s.scratch))
+	copy(scratch, s.scratch)
+	var err error
+	copyByte := func(destAddr, srcAddr uint16) {
+		if destAddr < scratchBaseAddr || srcAddr < scratchBaseAddr {
+			err = ErrOutOfScratch
+			return
+		}
+		scratch[destAddr - scratchBaseAddr] = scratch[srcAddr - scratchBaseAddr]
+	}
+
+	for _, buffers := range [][2]int{{1, 0}, {2, 1}} {
+		base := int(defaultSpriteBuffers.baseAddr(buffers[1])) - scratchBaseAddr
+		clear(scratch[base:base + 0x188])
+		forEachAlignCopy(defaultSpriteBuffers, baseDataHeight, baseDataWidth, buffers[0], buffers[1], copyByte)
+	}
+	forEachInterlaceCopy(defaultSpriteBuffers, copyByte)
+	if err != nil {
+		return nil, err
+	}
+
+	base := int(defaultSpriteBuffers.baseAddr(1)) - scratchBaseAddr
+	result := make([]byte, 0x310)
+	copy(result, scratch[base:base + 0x310])
+	return result, nil
+}
@@ -0,0 +1,109 @@
+/*
+ * fools2024-solutions: source code for Kagamiin's solutions for TheZZAZZGlitch April Fools Event 2024's Security Testing Program.
+ * Copyright (C) 2024 Kagamiin~
+ * 
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License,
+ * or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program. If not, see <https://www.gnu.org/licenses/>.
+ */
+
+package decomp_test
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"log"
+	"math/rand"
+	"os"
+	"reflect"
+	"testing"
+	"testing/quick"
+
+	"github.com/Kagamiin/fools2024-solutions/challenge-1/cmd/decomp"
+)
+
+func fastDecodeCheck(plane1, plane2 []byte, widthTiles, heightTiles int) bool {
+	stream, err := decomp.CompressSprite(plane1, plane2, widthTiles, heightTiles)
+	if err != nil {
+		log.Println(err)
+		return false
+	}
+
+	memSpace := make([]byte, 65536)
+	copy(memSpace[streamAddr:], stream)
+
+	sprite, err := decomp.DecodeSprite(memSpace, streamAddr)
+	if err != nil {
+		log.Println(err)
+		return false
+	}
+	if sprite.WidthTiles != widthTiles || sprite.HeightTiles != heightTiles {
+		log.Printf("expected size %dx%d, got %dx%d", widthTiles, heightTiles, sprite.WidthTiles, sprite.HeightTiles)
+		return false
+	}
+	for i := range plane1 {
+		if sprite.Tiles[i * 2] != plane1[i] || sprite.Tiles[i * 2 + 1] != plane2[i] {
+			log.Printf("native tile data mismatch at offset %d", i)
+			return false
+		}
+	}
+
+	aligned, err := sprite.AlignedTiles(-1, -1)
+	if err != nil {
+		log.Println(err)
+		return false
+	}
+
+	recording := decomp.RecordDecompressSprite(memSpace, streamAddr, -1, -1)
+	recording.ApplyRecording(&memSpace)
+
+	if !bytes.Equal(memSpace[0xa188:0xa498], aligned) {
+		log.Println(memSpace[0xa188:0xa498])
+		log.Println(aligned)
+		return false
+	}
+	return true
+}
+
+func Test_FastDecodeMatchesJournal(t *testing.T) {
+	log.SetOutput(io.Discard)
+	defer log.SetOutput(os.Stderr)
+
+	conf := quick.Config{MaxCount: 500, MaxCountScale: 1.0, Values: func(res []reflect.Value, rng *rand.Rand) {
+		widthTiles := rng.Intn(7) + 1
+		heightTiles := rng.Intn(7) + 1
+		res[0] = reflect.ValueOf(randomPlane(rng, widthTiles * heightTiles * 8))
+		res[1] = reflect.ValueOf(randomPlane(rng, widthTiles * heightTiles * 8))
+		res[2] = reflect.ValueOf(widthTiles)
+		res[3] = reflect.ValueOf(heightTiles)
+	}}
+	if err := quick.Check(fastDecodeCheck, &conf); err != nil {
+		t.Error(err)
+	}
+}
+
+func Test_FastDecodePrematureEnd(t *testing.T) {
+	stream, err := decomp.CompressSprite(bytes.Repeat([]byte{0x5a}, 8), bytes.Repeat([]byte{0xa5}, 8), 1, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = decomp.DecodeSprite(stream[:len(stream) - 1], 0)
+	var decodeErr *decomp.DecodeError
+	if !errors.As(err, &decodeErr) {
+		t.Fatalf("expected a DecodeError, got %v", err)
+	}
+	if !errors.Is(err, decomp.ErrPrematureEnd) {
+		t.Errorf("expected ErrPrematureEnd, got %v", err)
+	}
+}
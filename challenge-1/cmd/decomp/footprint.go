@@ -0,0 +1,192 @@
+/*
+ * fools2024-solutions: source code for Kagamiin's solutions for TheZZAZZGlitch April Fools Event 2024's Security Testing Program.
+ * Copyright (C) 2024 Kagamiin~
+ * 
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License,
+ * or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program. If not, see <https://www.gnu.org/licenses/>.
+ */
+
+package decomp
+
+import (
+	"fmt"
+	"strings"
+)
+
+type Region struct {
+	Name string
+	Start uint16
+	End uint16
+}
+
+// MemoryRegions splits the address space as seen by the decompressor, which
+// runs with SRAM bank 0 mapped.
+var MemoryRegions = []Region{
+	{"MBC1 RAM enable register", 0x0000, 0x1fff},
+	{"MBC1 ROM bank register", 0x2000, 0x3fff},
+	{"MBC1 RAM bank register", 0x4000, 0x5fff},
+	{"MBC1 banking mode register", 0x6000, 0x7fff},
+	{"VRAM", 0x8000, 0x9fff},
+	{"SRAM bank 0 (sprite buffers)", 0xa000, 0xa497},
+	{"SRAM bank 0", 0xa498, 0xbfff},
+	{"WRAM bank 0", 0xc000, 0xcfff},
+	{"WRAM bank 1", 0xd000, 0xdfff},
+	{"echo RAM", 0xe000, 0xfdff},
+	{"OAM", 0xfe00, 0xfe9f},
+	{"unusable area", 0xfea0, 0xfeff},
+	{"I/O registers", 0xff00, 0xff7f},
+	{"HRAM", 0xff80, 0xfffe},
+	{"interrupt enable register", 0xffff, 0xffff},
+}
+
+type Damage int
+
+const (
+	Untouched Damage = iota
+	// Recoverable bytes were only written to by DCopy, DXor and DeltaDec operations.
+	Recoverable
+	// Imprinted bytes had bits OR'd into them, so any bit set by the imprint is lost.
+	Imprinted
+	// Lost bytes were overwritten by a Fill or Scale operation.
+	Lost
+)
+
+func (d Damage) String() string {
+	switch d {
+	case Untouched:
+		return "untouched"
+	case Recoverable:
+		return "recoverable"
+	case Imprinted:
+		return "imprinted"
+	case Lost:
+		return "lost"
+	}
+	return fmt.Sprintf("Damage(%d)", int(d))
+}
+
+func (o Operation) damage() Damage {
+	switch o.T {
+	case Fill, Scale:
+		return Lost
+	case Or:
+		return Imprinted
+	}
+	return Recoverable
+}
+
+type AddrRange struct {
+	Start uint16
+	End uint16
+}
+
+func (a AddrRange) String() string {
+	if a.Start == a.End {
+		return fmt.Sprintf("0x%04x", a.Start)
+	}
+	return fmt.Sprintf("0x%04x-0x%04x", a.Start, a.End)
+}
+
+type OpCounts map[opType]int
+
+func (c OpCounts) String() string {
+	var sb strings.Builder
+	for t := range opTypeNames {
+		if c[opType(t)] == 0 {
+			continue
+		}
+		if sb.Len() > 0 {
+			sb.WriteString(", ")
+		}
+		fmt.Fprintf(&sb, "%v %d", opType(t), c[opType(t)])
+	}
+	return sb.String()
+}
+
+type RegionFootprint struct {
+	Region Region
+	OpCounts OpCounts
+	Ranges map[Damage][]AddrRange
+}
+
+type Footprint struct {
+	OpCounts OpCounts
+	// Regions lists only the regions the journal writes to.
+	Regions []RegionFootprint
+	// ByteDamage holds the worst damage done to each byte of the address space.
+	ByteDamage []Damage
+}
+
+func (r *RecordedDecompression) Footprint() *Footprint {
+	footprint := Footprint{
+		OpCounts: make(OpCounts),
+		Regions: make([]RegionFootprint, 0),
+		ByteDamage: make([]Damage, 65536),
+	}
+	regionOpCounts := make([]OpCounts, len(MemoryRegions))
+
+	for _, operation := range r.Operations {
+		footprint.OpCounts[operation.T]++
+		if damage := operation.damage(); damage > footprint.ByteDamage[operation.DestAddr] {
+			footprint.ByteDamage[operation.DestAddr] = damage
+		}
+		regionIdx := findRegion(operation.DestAddr)
+		if regionOpCounts[regionIdx] == nil {
+			regionOpCounts[regionIdx] = make(OpCounts)
+		}
+		regionOpCounts[regionIdx][operation.T]++
+	}
+
+	for regionIdx, region := range MemoryRegions {
+		if regionOpCounts[regionIdx] == nil {
+			continue
+		}
+		regionFootprint := RegionFootprint{
+			Region: region,
+			OpCounts: regionOpCounts[regionIdx],
+			Ranges: make(map[Damage][]AddrRange),
+		}
+		for _, addrRange := range footprint.DamagedRanges(region.Start, region.End) {
+			damage := footprint.ByteDamage[addrRange.Start]
+			regionFootprint.Ranges[damage] = append(regionFootprint.Ranges[damage], addrRange)
+		}
+		footprint.Regions = append(footprint.Regions, regionFootprint)
+	}
+
+	return &footprint
+}
+
+// DamagedRanges splits the damaged bytes between start and end (inclusive)
+// into ranges of contiguous bytes with the same damage.
+func (f *Footprint) DamagedRanges(start, end uint16) []AddrRange {
+	result := make([]AddrRange, 0)
+	for addr := int(start); addr <= int(end); {
+		damage := f.ByteDamage[addr]
+		rangeStart := addr
+		for ; addr <= int(end) && f.ByteDamage[addr] == damage; addr++ {
+		}
+		if damage != Untouched {
+			result = append(result, AddrRange{uint16(rangeStart), uint16(addr - 1)})
+		}
+	}
+	return result
+}
+
+func findRegion(addr uint16) int {
+	for i, region := range MemoryRegions {
+		if addr >= region.Start && addr <= region.End {
+			return i
+		}
+	}
+	panic(fmt.Sprintf("address 0x%04x is not in any region", addr))
+}
@@ -0,0 +1,78 @@
+/*
+ * fools2024-solutions: source code for Kagamiin's solutions for TheZZAZZGlitch April Fools Event 2024's Security Testing Program.
+ * Copyright (C) 2024 Kagamiin~
+ * 
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License,
+ * or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program. If not, see <https://www.gnu.org/licenses/>.
+ */
+
+package decomp_test
+
+import (
+	"testing"
+
+	"github.com/Kagamiin/fools2024-solutions/challenge-1/cmd/decomp"
+)
+
+func Test_FootprintClassification(t *testing.T) {
+	recording := decomp.RecordedDecompression{
+		Operations: []decomp.Operation{
+			{T: decomp.Fill, DestAddr: 0xa000, Mask: 0xff},
+			{T: decomp.Fill, DestAddr: 0xa001, Mask: 0xff},
+			{T: decomp.Or, DestAddr: 0xa001, Mask: 0xc0, Value: 0x40},
+			{T: decomp.Or, DestAddr: 0xc100, Mask: 0x03, Value: 0x03},
+			{T: decomp.DXor, DestAddr: 0xc101, SourceAddr: 0xa000, Mask: 0xff},
+			{T: decomp.DCopy, DestAddr: 0xc102, SourceAddr: 0xa001, Mask: 0xff},
+			{T: decomp.DCopy, DestAddr: 0x2000, SourceAddr: 0xa001, Mask: 0xff},
+		},
+	}
+
+	footprint := recording.Footprint()
+	if footprint.OpCounts[decomp.Fill] != 2 || footprint.OpCounts[decomp.Or] != 2 || footprint.OpCounts[decomp.DCopy] != 2 {
+		t.Errorf("unexpected operation counts %v", footprint.OpCounts)
+	}
+
+	expected := map[uint16]decomp.Damage{
+		0xa000: decomp.Lost,
+		0xa001: decomp.Lost,
+		0xa002: decomp.Untouched,
+		0xc100: decomp.Imprinted,
+		0xc101: decomp.Recoverable,
+		0xc102: decomp.Recoverable,
+		0x2000: decomp.Recoverable,
+	}
+	for addr, damage := range expected {
+		if footprint.ByteDamage[addr] != damage {
+			t.Errorf("0x%04x: expected %v, got %v", addr, damage, footprint.ByteDamage[addr])
+		}
+	}
+
+	regionNames := make([]string, 0)
+	for _, region := range footprint.Regions {
+		regionNames = append(regionNames, region.Region.Name)
+	}
+	expectedNames := []string{"MBC1 ROM bank register", "SRAM bank 0 (sprite buffers)", "WRAM bank 0"}
+	if len(regionNames) != len(expectedNames) {
+		t.Fatalf("expected regions %v, got %v", expectedNames, regionNames)
+	}
+	for i := range expectedNames {
+		if regionNames[i] != expectedNames[i] {
+			t.Fatalf("expected regions %v, got %v", expectedNames, regionNames)
+		}
+	}
+
+	ranges := footprint.Regions[2].Ranges[decomp.Recoverable]
+	if len(ranges) != 1 || ranges[0] != (decomp.AddrRange{Start: 0xc101, End: 0xc102}) {
+		t.Errorf("expected a single recoverable range 0xc101-0xc102, got %v", ranges)
+	}
+}
@@ -0,0 +1,137 @@
+/*
+ * fools2024-solutions: source code for Kagamiin's solutions for TheZZAZZGlitch April Fools Event 2024's Security Testing Program.
+ * Copyright (C) 2024 Kagamiin~
+ * 
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License,
+ * or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program. If not, see <https://www.gnu.org/licenses/>.
+ */
+
+package decomp
+
+import (
+	"fmt"
+	"log"
+)
+
+// A Snapshot is a memory image along with the bits of it that are unknown,
+// such as the result of undoing a journal over it. A nil Unknown means every
+// bit is known, as in a clean baseline.
+type Snapshot struct {
+	Name string
+	Memory []byte
+	Unknown []byte
+}
+
+// UndoSnapshot undoes the journal over a copy of the memory image, and returns
+// the recovered image as a snapshot.
+func (r *RecordedDecompression) UndoSnapshot(name string, memory []byte) Snapshot {
+	memSpace := make([]byte, len(memory))
+	copy(memSpace, memory)
+	unknownBitMap := r.UndoRecording(&memSpace)
+	return Snapshot{Name: name, Memory: memSpace, Unknown: *unknownBitMap}
+}
+
+func (s *Snapshot) known(addr int) uint8 {
+	if s.Unknown == nil {
+		return 0xff
+	}
+	return ^s.Unknown[addr]
+}
+
+// A Disagreement is a byte where snapshots know different values for the
+// same bits. Values and Known hold each snapshot's byte and its known bits.
+type Disagreement struct {
+	Addr uint16
+	Bits uint8
+	Values []uint8
+	Known []uint8
+}
+
+// Format describes the disagreement with the given snapshot names, showing
+// the disagreeing bits each snapshot knows, and ? for those it doesn't.
+func (d Disagreement) Format(names []string) string {
+	text := fmt.Sprintf("0x%04x bits %08b:", d.Addr, d.Bits)
+	for i, name := range names {
+		if d.Known[i] & d.Bits == 0 {
+			continue
+		}
+		bits := make([]byte, 0, 8)
+		for bit := 7; bit >= 0; bit-- {
+			mask := uint8(1) << bit
+			switch {
+			case d.Bits & mask == 0:
+				bits = append(bits, '.')
+			case d.Known[i] & mask == 0:
+				bits = append(bits, '?')
+			case d.Values[i] & mask != 0:
+				bits = append(bits, '1')
+			default:
+				bits = append(bits, '0')
+			}
+		}
+		text += fmt.Sprintf(" %s=%s", name, bits)
+	}
+	return text
+}
+
+// FuseSnapshots merges snapshots of the same memory, taking every bit from
+// the first snapshot that knows it. Bits known by several snapshots with
+// different values are reported as disagreements, and become unknown if
+// strict is set.
+func FuseSnapshots(snapshots []Snapshot, strict bool) (memory, unknown []byte, disagreements []Disagreement, err error) {
+	if len(snapshots) == 0 {
+		return nil, nil, nil, fmt.Errorf("no snapshots to fuse")
+	}
+	size := len(snapshots[0].Memory)
+	for _, snapshot := range snapshots {
+		if len(snapshot.Memory) != size || (snapshot.Unknown != nil && len(snapshot.Unknown) != size) {
+			return nil, nil, nil, fmt.Errorf("snapshot %s: expected %d bytes of memory and unknown bits", snapshot.Name, size)
+		}
+	}
+	
+	memory = make([]byte, size)
+	unknown = make([]byte, size)
+	disagreements = make([]Disagreement, 0)
+	for addr := 0; addr < size; addr++ {
+		var value, known, disagreeing uint8
+		for i := range snapshots {
+			snapshotKnown := snapshots[i].known(addr)
+			snapshotValue := snapshots[i].Memory[addr]
+			disagreeing |= (value ^ snapshotValue) & known & snapshotKnown
+			value |= snapshotValue & snapshotKnown & ^known
+			known |= snapshotKnown
+		}
+		if disagreeing != 0 {
+			d := Disagreement{
+				Addr: uint16(addr),
+				Bits: disagreeing,
+				Values: make([]uint8, len(snapshots)),
+				Known: make([]uint8, len(snapshots)),
+			}
+			for i := range snapshots {
+				d.Values[i] = snapshots[i].Memory[addr]
+				d.Known[i] = snapshots[i].known(addr)
+			}
+			disagreements = append(disagreements, d)
+			if strict {
+				known &= ^disagreeing
+			}
+		}
+		// unknown bits are left as in the first snapshot
+		memory[addr] = value | snapshots[0].Memory[addr] & ^known
+		unknown[addr] = ^known
+	}
+	
+	log.Printf("Fused %d snapshots, %d bytes with disagreeing bits\n", len(snapshots), len(disagreements))
+	return memory, unknown, disagreements, nil
+}
@@ -0,0 +1,74 @@
+/*
+ * fools2024-solutions: source code for Kagamiin's solutions for TheZZAZZGlitch April Fools Event 2024's Security Testing Program.
+ * Copyright (C) 2024 Kagamiin~
+ * 
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License,
+ * or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program. If not, see <https://www.gnu.org/licenses/>.
+ */
+
+package decomp_test
+
+import (
+	"io"
+	"log"
+	"os"
+	"testing"
+
+	"github.com/Kagamiin/fools2024-solutions/challenge-1/cmd/decomp"
+)
+
+func Test_FuseSnapshots(t *testing.T) {
+	log.SetOutput(io.Discard)
+	defer log.SetOutput(os.Stderr)
+
+	snapshots := []decomp.Snapshot{
+		{Name: "a", Memory: []byte{0x0f, 0x00, 0x11}, Unknown: []byte{0xf0, 0xff, 0x00}},
+		{Name: "b", Memory: []byte{0xa0, 0x5a, 0x13}, Unknown: []byte{0x0f, 0x00, 0x00}},
+		{Name: "c", Memory: []byte{0x00, 0x00, 0x00}, Unknown: []byte{0xff, 0xff, 0xff}},
+	}
+	memory, unknown, disagreements, err := decomp.FuseSnapshots(snapshots, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectedMemory := []byte{0xaf, 0x5a, 0x11}
+	expectedUnknown := []byte{0x00, 0x00, 0x00}
+	for addr := range memory {
+		if memory[addr] != expectedMemory[addr] || unknown[addr] != expectedUnknown[addr] {
+			t.Errorf("0x%04x: expected 0x%02x with unknown bits %08b, got 0x%02x with %08b",
+			         addr, expectedMemory[addr], expectedUnknown[addr], memory[addr], unknown[addr])
+		}
+	}
+	if len(disagreements) != 1 || disagreements[0].Addr != 2 || disagreements[0].Bits != 0x02 {
+		t.Fatalf("expected a disagreement on bit 1 of 0x0002, got %v", disagreements)
+	}
+	if text := disagreements[0].Format([]string{"a", "b", "c"}); text != "0x0002 bits 00000010: a=......0. b=......1." {
+		t.Errorf("unexpected disagreement report %q", text)
+	}
+
+	_, unknown, _, err = decomp.FuseSnapshots(snapshots, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if unknown[2] != 0x02 {
+		t.Errorf("expected strict fusion to make bit 1 of 0x0002 unknown, got %08b", unknown[2])
+	}
+
+	clean := decomp.Snapshot{Name: "clean", Memory: []byte{0x01, 0x02, 0x03}}
+	memory, unknown, _, err = decomp.FuseSnapshots([]decomp.Snapshot{snapshots[2], clean}, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if memory[2] != 0x03 || unknown[2] != 0 {
+		t.Errorf("expected a clean snapshot to fill in everything, got 0x%02x with unknown bits %08b", memory[2], unknown[2])
+	}
+}
@@ -0,0 +1,335 @@
+/*
+ * fools2024-solutions: source code for Kagamiin's solutions for TheZZAZZGlitch April Fools Event 2024's Security Testing Program.
+ * Copyright (C) 2024 Kagamiin~
+ * 
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License,
+ * or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program. If not, see <https://www.gnu.org/licenses/>.
+ */
+
+package decomp
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+type JournalFormat int
+
+const (
+	JournalBinary JournalFormat = iota
+	JournalJSON
+	JournalJSONL
+)
+
+// Binary journals start with this magic, followed by a version byte, the
+// number of operations as a little-endian uint32 and the operations themselves.
+// Version 1 journals lack the phase and input stream position of each operation.
+var journalMagic = []byte("G1SJ")
+
+const journalVersion = 2
+
+var opTypeNames = []string{
+	Fill: "Fill",
+	Or: "Or",
+	DeltaDec: "DeltaDec",
+	DCopy: "DCopy",
+	DXor: "DXor",
+	Scale: "Scale",
+}
+
+func (t opType) String() string {
+	if int(t) >= 0 && int(t) < len(opTypeNames) {
+		return opTypeNames[t]
+	}
+	return fmt.Sprintf("opType(%d)", int(t))
+}
+
+// OpTypeNames returns the names of all operation types, in order.
+func OpTypeNames() []string {
+	return append([]string(nil), opTypeNames...)
+}
+
+var phaseNames = []string{
+	PhaseUnknown: "Unknown",
+	PhaseClear: "Clear",
+	PhaseImprint: "Imprint",
+	PhaseDelta: "Delta",
+	PhaseXor: "Xor",
+	PhaseCopyAlign: "CopyAlign",
+	PhaseInterlace: "Interlace",
+	PhaseScale: "Scale",
+	PhaseMonHeader: "MonHeader",
+	PhaseDexFlag: "DexFlag",
+}
+
+func (p Phase) String() string {
+	if int(p) >= 0 && int(p) < len(phaseNames) {
+		return phaseNames[p]
+	}
+	return fmt.Sprintf("Phase(%d)", int(p))
+}
+
+func parsePhase(name string) (Phase, error) {
+	if name == "" {
+		return PhaseUnknown, nil
+	}
+	for i, phaseName := range phaseNames {
+		if phaseName == name {
+			return Phase(i), nil
+		}
+	}
+	return 0, fmt.Errorf("unknown phase %q", name)
+}
+
+func parseOpType(name string) (opType, error) {
+	for i, opName := range opTypeNames {
+		if opName == name {
+			return opType(i), nil
+		}
+	}
+	return 0, fmt.Errorf("unknown operation type %q", name)
+}
+
+type jsonOperation struct {
+	Op string `json:"op"`
+	Dest string `json:"dest"`
+	Source string `json:"source"`
+	Mask string `json:"mask"`
+	Value string `json:"value"`
+	Phase string `json:"phase,omitempty"`
+	StreamByte string `json:"stream_byte,omitempty"`
+	StreamBit *uint8 `json:"stream_bit,omitempty"`
+}
+
+func (o Operation) MarshalJSON() ([]byte, error) {
+	j := jsonOperation{
+		Op: o.T.String(),
+		Dest: fmt.Sprintf("0x%04x", o.DestAddr),
+		Source: fmt.Sprintf("0x%04x", o.SourceAddr),
+		Mask: fmt.Sprintf("0x%02x", o.Mask),
+		Value: fmt.Sprintf("0x%02x", o.Value),
+	}
+	if o.Phase != PhaseUnknown {
+		j.Phase = o.Phase.String()
+	}
+	if o.Phase == PhaseImprint {
+		j.StreamByte = fmt.Sprintf("0x%04x", o.StreamByte)
+		j.StreamBit = &o.StreamBit
+	}
+	return json.Marshal(j)
+}
+
+func (o *Operation) UnmarshalJSON(data []byte) error {
+	var j jsonOperation
+	err := json.Unmarshal(data, &j)
+	if err != nil {
+		return err
+	}
+
+	o.T, err = parseOpType(j.Op)
+	if err != nil {
+		return err
+	}
+	parseHex := func(s string, bitSize int) (uint64, error) {
+		return strconv.ParseUint(strings.TrimPrefix(s, "0x"), 16, bitSize)
+	}
+	dest, err := parseHex(j.Dest, 16)
+	if err != nil {
+		return fmt.Errorf("invalid dest: %w", err)
+	}
+	source, err := parseHex(j.Source, 16)
+	if err != nil {
+		return fmt.Errorf("invalid source: %w", err)
+	}
+	mask, err := parseHex(j.Mask, 8)
+	if err != nil {
+		return fmt.Errorf("invalid mask: %w", err)
+	}
+	value, err := parseHex(j.Value, 8)
+	if err != nil {
+		return fmt.Errorf("invalid value: %w", err)
+	}
+	o.DestAddr = uint16(dest)
+	o.SourceAddr = uint16(source)
+	o.Mask = uint8(mask)
+	o.Value = uint8(value)
+
+	o.Phase, err = parsePhase(j.Phase)
+	if err != nil {
+		return err
+	}
+	o.StreamByte = 0
+	o.StreamBit = 0
+	if j.StreamByte != "" {
+		streamByte, err := parseHex(j.StreamByte, 32)
+		if err != nil {
+			return fmt.Errorf("invalid stream_byte: %w", err)
+		}
+		o.StreamByte = int(streamByte)
+	}
+	if j.StreamBit != nil {
+		o.StreamBit = *j.StreamBit
+	}
+	return nil
+}
+
+func (r *RecordedDecompression) WriteJournal(w io.Writer, format JournalFormat) error {
+	bufWriter := bufio.NewWriter(w)
+	var err error
+	switch format {
+	case JournalBinary:
+		err = r.writeBinary(bufWriter)
+	case JournalJSON:
+		encoder := json.NewEncoder(bufWriter)
+		encoder.SetIndent("", "\t")
+		err = encoder.Encode(r.Operations)
+	case JournalJSONL:
+		encoder := json.NewEncoder(bufWriter)
+		for _, operation := range r.Operations {
+			err = encoder.Encode(operation)
+			if err != nil {
+				break
+			}
+		}
+	default:
+		err = fmt.Errorf("unknown journal format %d", format)
+	}
+	if err != nil {
+		return err
+	}
+	return bufWriter.Flush()
+}
+
+func (r *RecordedDecompression) writeBinary(w io.Writer) error {
+	header := make([]byte, 0, 9)
+	header = append(header, journalMagic...)
+	header = append(header, journalVersion)
+	header = binary.LittleEndian.AppendUint32(header, uint32(len(r.Operations)))
+	_, err := w.Write(header)
+	if err != nil {
+		return err
+	}
+
+	record := make([]byte, 13)
+	for _, operation := range r.Operations {
+		record[0] = uint8(operation.T)
+		binary.LittleEndian.PutUint16(record[1:], operation.DestAddr)
+		binary.LittleEndian.PutUint16(record[3:], operation.SourceAddr)
+		record[5] = operation.Mask
+		record[6] = operation.Value
+		record[7] = uint8(operation.Phase)
+		binary.LittleEndian.PutUint32(record[8:], uint32(operation.StreamByte))
+		record[12] = operation.StreamBit
+		_, err = w.Write(record)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ReadJournal reads a journal in any of the supported formats, telling them
+// apart by their first bytes.
+func ReadJournal(r io.Reader) (*RecordedDecompression, error) {
+	bufReader := bufio.NewReader(r)
+	start, err := bufReader.Peek(len(journalMagic))
+	if err != nil && !errors.Is(err, io.EOF) {
+		return nil, err
+	}
+
+	if bytes.Equal(start, journalMagic) {
+		return readBinaryJournal(bufReader)
+	}
+	trimmed := bytes.TrimLeft(start, " \t\r\n")
+	if len(trimmed) > 0 && trimmed[0] == '[' {
+		recording := RecordedDecompression{}
+		err = json.NewDecoder(bufReader).Decode(&recording.Operations)
+		if err != nil {
+			return nil, err
+		}
+		return &recording, nil
+	}
+
+	recording := RecordedDecompression{
+		Operations: make([]Operation, 0),
+	}
+	decoder := json.NewDecoder(bufReader)
+	for {
+		var operation Operation
+		err = decoder.Decode(&operation)
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("operation %d: %w", len(recording.Operations), err)
+		}
+		recording.Operations = append(recording.Operations, operation)
+	}
+	return &recording, nil
+}
+
+func readBinaryJournal(r io.Reader) (*RecordedDecompression, error) {
+	header := make([]byte, 9)
+	_, err := io.ReadFull(r, header)
+	if err != nil {
+		return nil, err
+	}
+	var recordSize int
+	switch header[4] {
+	case 1:
+		recordSize = 7
+	case 2:
+		recordSize = 13
+	default:
+		return nil, fmt.Errorf("unsupported journal version %d", header[4])
+	}
+
+	count := binary.LittleEndian.Uint32(header[5:])
+	recording := RecordedDecompression{
+		Operations: make([]Operation, 0, min(count, 1 << 20)),
+	}
+	record := make([]byte, recordSize)
+	for i := uint32(0); i < count; i++ {
+		_, err = io.ReadFull(r, record)
+		if err != nil {
+			return nil, fmt.Errorf("operation %d: %w", i, err)
+		}
+		if int(record[0]) >= len(opTypeNames) {
+			return nil, fmt.Errorf("operation %d: unknown operation type %d", i, record[0])
+		}
+		operation := Operation{
+			T: opType(record[0]),
+			DestAddr: binary.LittleEndian.Uint16(record[1:]),
+			SourceAddr: binary.LittleEndian.Uint16(record[3:]),
+			Mask: record[5],
+			Value: record[6],
+		}
+		if recordSize > 7 {
+			if int(record[7]) >= len(phaseNames) {
+				return nil, fmt.Errorf("operation %d: unknown phase %d", i, record[7])
+			}
+			operation.Phase = Phase(record[7])
+			operation.StreamByte = int(binary.LittleEndian.Uint32(record[8:]))
+			operation.StreamBit = record[12]
+		}
+		recording.Operations = append(recording.Operations, operation)
+	}
+	return &recording, nil
+}
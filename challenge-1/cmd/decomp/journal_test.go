@@ -0,0 +1,91 @@
+/*
+ * fools2024-solutions: source code for Kagamiin's solutions for TheZZAZZGlitch April Fools Event 2024's Security Testing Program.
+ * Copyright (C) 2024 Kagamiin~
+ * 
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License,
+ * or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program. If not, see <https://www.gnu.org/licenses/>.
+ */
+
+package decomp_test
+
+import (
+	"bytes"
+	"math/rand"
+	"reflect"
+	"testing"
+
+	"github.com/Kagamiin/fools2024-solutions/challenge-1/cmd/decomp"
+)
+
+func Test_JournalRoundTrip(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	recording := decomp.RecordedDecompression{
+		Operations: make([]decomp.Operation, 1000),
+	}
+	opTypes := []decomp.Operation{
+		{T: decomp.Fill}, {T: decomp.Or}, {T: decomp.DeltaDec}, {T: decomp.DCopy}, {T: decomp.DXor},
+	}
+	for i := range recording.Operations {
+		recording.Operations[i] = decomp.Operation{
+			T: opTypes[rng.Intn(len(opTypes))].T,
+			DestAddr: uint16(rng.Intn(65536)),
+			SourceAddr: uint16(rng.Intn(65536)),
+			Mask: uint8(rng.Intn(256)),
+			Value: uint8(rng.Intn(256)),
+			Phase: decomp.Phase(rng.Intn(int(decomp.PhaseInterlace) + 1)),
+		}
+		if recording.Operations[i].Phase == decomp.PhaseImprint {
+			recording.Operations[i].StreamByte = rng.Intn(0x8000)
+			recording.Operations[i].StreamBit = uint8(rng.Intn(8))
+		}
+	}
+
+	formats := map[string]decomp.JournalFormat{
+		"binary": decomp.JournalBinary,
+		"json": decomp.JournalJSON,
+		"jsonl": decomp.JournalJSONL,
+	}
+	for name, format := range formats {
+		t.Run(name, func(t *testing.T) {
+			var buf bytes.Buffer
+			err := recording.WriteJournal(&buf, format)
+			if err != nil {
+				t.Fatal(err)
+			}
+			loaded, err := decomp.ReadJournal(&buf)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(recording.Operations, loaded.Operations) {
+				t.Error("journal changed after a round trip")
+			}
+		})
+	}
+}
+
+func Test_JournalJSONLFields(t *testing.T) {
+	recording := decomp.RecordedDecompression{
+		Operations: []decomp.Operation{
+			{T: decomp.DXor, DestAddr: 0xa310, SourceAddr: 0xa188, Mask: 0xff, Value: 0},
+		},
+	}
+	var buf bytes.Buffer
+	err := recording.WriteJournal(&buf, decomp.JournalJSONL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := `{"op":"DXor","dest":"0xa310","source":"0xa188","mask":"0xff","value":"0x00"}` + "\n"
+	if buf.String() != expected {
+		t.Errorf("expected %s, got %s", expected, buf.String())
+	}
+}
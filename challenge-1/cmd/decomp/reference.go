@@ -0,0 +1,389 @@
+/*
+ * fools2024-solutions: source code for Kagamiin's solutions for TheZZAZZGlitch April Fools Event 2024's Security Testing Program.
+ * Copyright (C) 2024 Kagamiin~
+ * 
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License,
+ * or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program. If not, see <https://www.gnu.org/licenses/>.
+ */
+
+package decomp
+
+import (
+	"errors"
+
+	"github.com/Kagamiin/fools2024-solutions/challenge-1/cmd/gbmem"
+	"github.com/Kagamiin/fools2024-solutions/challenge-1/cmd/romdata"
+)
+
+// The reference decoder is a line-by-line port of pokered's
+// UncompressSpriteData and LoadUncompressedSpriteData routines, keeping the
+// game's WRAM variables and 8-bit arithmetic instead of sharing any of the
+// walkers used to record journals. It exists to check the journal against,
+// and leaves out flipped sprites, which never reach SRAM recovery.
+
+// ErrOutsideOffsetList is returned when a run length has a prefix of more than
+// 15 one bits, for which the game reads its offset from whatever code follows
+// LengthEncodingOffsetList in ROM.
+var ErrOutsideOffsetList = errors.New("run length prefix past the end of LengthEncodingOffsetList")
+
+// lengthEncodingOffsetList mirrors LengthEncodingOffsetList: the nth item is
+// 2^(n+1) - 1.
+var lengthEncodingOffsetList = [16]uint16{
+	0x0001, 0x0003, 0x0007, 0x000f, 0x001f, 0x003f, 0x007f, 0x00ff,
+	0x01ff, 0x03ff, 0x07ff, 0x0fff, 0x1fff, 0x3fff, 0x7fff, 0xffff,
+}
+
+// decodeNybble0Table and decodeNybble1Table mirror the game's tables of
+// differentially decoded nybbles, starting from a bit value of 0 and 1.
+var decodeNybble0Table = [16]uint8{
+	0x0, 0x1, 0x3, 0x2, 0x7, 0x6, 0x4, 0x5, 0xf, 0xe, 0xc, 0xd, 0x8, 0x9, 0xb, 0xa,
+}
+var decodeNybble1Table = [16]uint8{
+	0xf, 0xe, 0xc, 0xd, 0x8, 0x9, 0xb, 0xa, 0x0, 0x1, 0x3, 0x2, 0x7, 0x6, 0x4, 0x5,
+}
+
+type referenceDecoder struct {
+	mem gbmem.Memory
+	buffers spriteBuffers
+	// WRAM variables of the same names, with the height and width in pixels
+	// as the game keeps them.
+	inputPtr uint16
+	inputBitCounter uint8
+	inputCurByte uint8
+	spriteHeight uint8
+	spriteWidth uint8
+	curPosX uint8
+	curPosY uint8
+	outputBitOffset uint8
+	outputPtr uint16
+	outputPtrCached uint16
+	loadFlags uint8
+	unpackMode uint8
+}
+
+// ReferenceDecompressSprite decompresses the sprite at spritePtr and
+// copies/aligns it like LoadMonFrontSprite, reading the sprite data through
+// mem with the pointer wrapping around at 0xffff. Negative base data
+// dimensions stand for the sprite's own.
+func ReferenceDecompressSprite(profile *romdata.Profile, mem gbmem.Memory, spritePtr, baseDataWidth, baseDataHeight int) error {
+	widthTiles, heightTiles, err := ReferenceUncompressSprite(profile, mem, spritePtr)
+	if err != nil {
+		return err
+	}
+	if baseDataWidth < 0 {
+		baseDataWidth = widthTiles
+	}
+	if baseDataHeight < 0 {
+		baseDataHeight = heightTiles
+	}
+	ReferenceLoadUncompressedSprite(profile, mem, baseDataWidth, baseDataHeight)
+	return nil
+}
+
+// ReferenceUncompressSprite runs _UncompressSpriteData and UnpackSprite,
+// leaving the sprite in sprite buffers 1 and 2. It returns the size of the
+// sprite in tiles as read from its header.
+func ReferenceUncompressSprite(profile *romdata.Profile, mem gbmem.Memory, spritePtr int) (widthTiles, heightTiles int, err error) {
+	d := referenceDecoder{
+		mem: mem,
+		buffers: spriteBuffers(profile.SpriteBuffers),
+		inputPtr: uint16(spritePtr),
+	}
+	for offset := uint16(0); offset < 2 * 0x188; offset++ {
+		d.mem.Write(d.buffers.baseAddr(1) + offset, 0)
+	}
+	d.inputBitCounter = 1
+	d.outputBitOffset = 3
+	size := d.readNextInputByte()
+	d.spriteHeight = (size & 0xf) * 8
+	d.spriteWidth = (size >> 4) * 8
+	d.loadFlags = d.readNextInputBit()
+
+	for {
+		done, err := d.uncompressChunk()
+		if err != nil {
+			return 0, 0, err
+		}
+		if done {
+			break
+		}
+	}
+	d.unpackSprite()
+	return int(size >> 4), int(size & 0xf), nil
+}
+
+// uncompressChunk is UncompressSpriteDataLoop for one chunk. It returns
+// whether it was the last one.
+func (d *referenceDecoder) uncompressChunk() (bool, error) {
+	if d.loadFlags & 1 == 0 {
+		d.storeSpriteOutputPointer(d.buffers.baseAddr(1))
+	} else {
+		d.storeSpriteOutputPointer(d.buffers.baseAddr(2))
+	}
+	if d.loadFlags & 2 != 0 {
+		d.unpackMode = d.readNextInputBit()
+		if d.unpackMode != 0 {
+			d.unpackMode = d.readNextInputBit() + 1
+		}
+	}
+
+	readRLEncodedZeros := d.readNextInputBit() == 0
+	for {
+		if !readRLEncodedZeros {
+			c := d.readNextInputBit()
+			a := d.readNextInputBit() | c << 1
+			if a != 0 {
+				d.writeSpriteBitsToBuffer(a)
+				if d.moveToNextBufferPosition() {
+					return d.nextChunk(), nil
+				}
+				continue
+			}
+		}
+		readRLEncodedZeros = false
+
+		c := 0
+		for d.readNextInputBit() != 0 {
+			c++
+		}
+		if c >= len(lengthEncodingOffsetList) {
+			return false, ErrOutsideOffsetList
+		}
+		offset := lengthEncodingOffsetList[c]
+		var de uint16
+		for i := 0; i <= c; i++ {
+			de = de << 1 | uint16(d.readNextInputBit())
+		}
+		de += offset
+		for {
+			if d.moveToNextBufferPosition() {
+				return d.nextChunk(), nil
+			}
+			de--
+			if de == 0 {
+				break
+			}
+		}
+	}
+}
+
+// nextChunk is the end of MoveToNextBufferPosition once all columns are done,
+// which goes on with the second chunk if the first one was just finished.
+func (d *referenceDecoder) nextChunk() bool {
+	d.curPosX = 0
+	if d.loadFlags & 2 != 0 {
+		return true
+	}
+	d.loadFlags = (d.loadFlags ^ 1) | 2
+	return false
+}
+
+func (d *referenceDecoder) storeSpriteOutputPointer(addr uint16) {
+	d.outputPtr = addr
+	d.outputPtrCached = addr
+}
+
+// moveToNextBufferPosition returns true where the game pops the return
+// address of its caller, as all columns of the chunk are done.
+func (d *referenceDecoder) moveToNextBufferPosition() bool {
+	if d.curPosY + 1 != d.spriteHeight {
+		d.curPosY++
+		d.outputPtr++
+		return false
+	}
+	d.curPosY = 0
+	if d.outputBitOffset != 0 {
+		d.outputBitOffset--
+		d.outputPtr = d.outputPtrCached
+		return false
+	}
+	d.outputBitOffset = 3
+	d.curPosX += 8
+	if d.curPosX == d.spriteWidth {
+		return true
+	}
+	d.storeSpriteOutputPointer(d.outputPtr + 1)
+	return false
+}
+
+func (d *referenceDecoder) writeSpriteBitsToBuffer(bits uint8) {
+	bits <<= d.outputBitOffset * 2
+	d.mem.Write(d.outputPtr, d.mem.Read(d.outputPtr) | bits)
+}
+
+func (d *referenceDecoder) readNextInputBit() uint8 {
+	d.inputBitCounter--
+	if d.inputBitCounter == 0 {
+		d.inputCurByte = d.readNextInputByte()
+		d.inputBitCounter = 8
+	}
+	d.inputCurByte = d.inputCurByte << 1 | d.inputCurByte >> 7
+	return d.inputCurByte & 1
+}
+
+func (d *referenceDecoder) readNextInputByte() uint8 {
+	value := d.mem.Read(d.inputPtr)
+	d.inputPtr++
+	return value
+}
+
+func (d *referenceDecoder) unpackSprite() {
+	switch d.unpackMode {
+	case 2:
+		d.unpackSpriteMode2()
+	case 1:
+		d.xorSpriteChunks()
+	default:
+		d.spriteDifferentialDecode(d.buffers.baseAddr(1))
+		d.spriteDifferentialDecode(d.buffers.baseAddr(2))
+	}
+}
+
+// resetSpriteBufferPointers points outputPtr to the buffer of the first chunk
+// and outputPtrCached to the one of the second chunk.
+func (d *referenceDecoder) resetSpriteBufferPointers() {
+	if d.loadFlags & 1 != 0 {
+		d.outputPtr = d.buffers.baseAddr(1)
+		d.outputPtrCached = d.buffers.baseAddr(2)
+	} else {
+		d.outputPtr = d.buffers.baseAddr(2)
+		d.outputPtrCached = d.buffers.baseAddr(1)
+	}
+}
+
+func (d *referenceDecoder) unpackSpriteMode2() {
+	d.resetSpriteBufferPointers()
+	d.spriteDifferentialDecode(d.outputPtrCached)
+	d.xorSpriteChunks()
+}
+
+func (d *referenceDecoder) xorSpriteChunks() {
+	d.curPosX = 0
+	d.curPosY = 0
+	d.resetSpriteBufferPointers()
+	d.spriteDifferentialDecode(d.outputPtr)
+	d.resetSpriteBufferPointers()
+	hl, de := d.outputPtr, d.outputPtrCached
+	for {
+		d.mem.Write(de, d.mem.Read(de) ^ d.mem.Read(hl))
+		hl++
+		de++
+		d.curPosY++
+		if d.curPosY != d.spriteHeight {
+			continue
+		}
+		d.curPosY = 0
+		d.curPosX += 8
+		if d.curPosX == d.spriteWidth {
+			break
+		}
+	}
+	d.curPosX = 0
+}
+
+func (d *referenceDecoder) spriteDifferentialDecode(addr uint16) {
+	d.curPosX = 0
+	d.curPosY = 0
+	d.storeSpriteOutputPointer(addr)
+	var e uint8
+	for {
+		b := d.mem.Read(d.outputPtr)
+		var high, low uint8
+		high, e = differentialDecodeNybble(b >> 4, e)
+		low, e = differentialDecodeNybble(b & 0xf, e)
+		d.mem.Write(d.outputPtr, high << 4 | low)
+		d.outputPtr += uint16(d.spriteHeight)
+		d.curPosX += 8
+		if d.curPosX != d.spriteWidth {
+			continue
+		}
+		e = 0
+		d.curPosX = 0
+		d.curPosY++
+		if d.curPosY == d.spriteHeight {
+			break
+		}
+		d.storeSpriteOutputPointer(d.outputPtrCached + 1)
+	}
+	d.curPosY = 0
+}
+
+// differentialDecodeNybble decodes a nybble given the last decoded one,
+// returning the decoded nybble, which is also the new last decoded one.
+func differentialDecodeNybble(nybble, last uint8) (uint8, uint8) {
+	var decoded uint8
+	if last & 1 != 0 {
+		decoded = decodeNybble1Table[nybble]
+	} else {
+		decoded = decodeNybble0Table[nybble]
+	}
+	return decoded, decoded
+}
+
+// ReferenceLoadUncompressedSprite runs the part of LoadUncompressedSpriteData
+// that copies/aligns sprite buffers 1 and 2 into buffers 0 and 1 according to
+// the base data dimensions, then InterlaceMergeSpriteBuffers.
+func ReferenceLoadUncompressedSprite(profile *romdata.Profile, mem gbmem.Memory, widthTiles, heightTiles int) {
+	buffers := spriteBuffers(profile.SpriteBuffers)
+	width := uint8(widthTiles) & 0xf
+	a := ((7 - width) + 1) >> 1
+	spriteOffset := a * 8 - a
+	height := uint8(heightTiles) & 0xf
+	spriteHeight := height * 8
+	spriteOffset = (spriteOffset + (7 - height)) * 8
+
+	for _, copyBuffers := range [][2]int{{1, 0}, {2, 1}} {
+		dest := buffers.baseAddr(copyBuffers[1])
+		for offset := uint16(0); offset < 0x188; offset++ {
+			mem.Write(dest + offset, 0)
+		}
+		alignSpriteDataCentered(mem, dest, buffers.baseAddr(copyBuffers[0]), spriteOffset, width, spriteHeight)
+	}
+
+	hl := buffers.baseAddr(2) + 0x187
+	de := buffers.baseAddr(1) + 0x187
+	bc := buffers.baseAddr(0) + 0x187
+	for counter := 0; counter < 0x188 / 2; counter++ {
+		for i := 0; i < 2; i++ {
+			mem.Write(hl, mem.Read(de))
+			de--
+			hl--
+			mem.Write(hl, mem.Read(bc))
+			bc--
+			hl--
+		}
+	}
+}
+
+func alignSpriteDataCentered(mem gbmem.Memory, hl, de uint16, spriteOffset, width, spriteHeight uint8) {
+	hl += uint16(spriteOffset)
+	columns := width
+	for {
+		column := hl
+		rows := spriteHeight
+		for {
+			mem.Write(column, mem.Read(de))
+			de++
+			column++
+			rows--
+			if rows == 0 {
+				break
+			}
+		}
+		hl += 7 * 8
+		columns--
+		if columns == 0 {
+			break
+		}
+	}
+}
@@ -0,0 +1,264 @@
+/*
+ * fools2024-solutions: source code for Kagamiin's solutions for TheZZAZZGlitch April Fools Event 2024's Security Testing Program.
+ * Copyright (C) 2024 Kagamiin~
+ * 
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License,
+ * or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program. If not, see <https://www.gnu.org/licenses/>.
+ */
+
+package decomp_test
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"math/rand"
+	"os"
+	"reflect"
+	"testing"
+	"testing/quick"
+
+	"github.com/Kagamiin/fools2024-solutions/challenge-1/cmd/decomp"
+	"github.com/Kagamiin/fools2024-solutions/challenge-1/cmd/gbmem"
+	"github.com/Kagamiin/fools2024-solutions/challenge-1/cmd/romdata"
+)
+
+// bitWriter builds raw sprite streams for the reference decoder to check the
+// journal against. It's a copy of its own rather than the package's
+// BitstreamWriter: that one's bit and Exp-Golomb writers aren't exported to
+// this package, and streams holding the runs and packets CompressSprite never
+// writes (runs wrapping around the game's 16-bit counter, or running past the
+// end of the chunk) are better built with an encoder that doesn't share the
+// package's bugs.
+type bitWriter struct {
+	data []byte
+	bits int
+}
+
+func (w *bitWriter) write(value, numBits int) {
+	for i := numBits - 1; i >= 0; i-- {
+		if w.bits % 8 == 0 {
+			w.data = append(w.data, 0)
+		}
+		w.data[len(w.data) - 1] |= uint8((value >> i) & 1) << (7 - w.bits % 8)
+		w.bits++
+	}
+}
+
+// writeRunLength writes a run length of 1 to 0x1fffe, returning the number of
+// pixel pairs the game skips for it.
+func (w *bitWriter) writeRunLength(number int) int {
+	numOnes := 0
+	for number >= (2 << (numOnes + 1)) - 1 {
+		numOnes++
+	}
+	for i := 0; i < numOnes; i++ {
+		w.write(1, 1)
+	}
+	w.write(0, 1)
+	w.write(number - ((2 << numOnes) - 1), numOnes + 1)
+	return (number - 1) & 0xffff + 1
+}
+
+// writeRandomPlane writes a random chunk of widthTiles x heightTiles, with
+// runs that may go past the end of the chunk or wrap around the 16-bit
+// counter of the game.
+func (w *bitWriter) writeRandomPlane(rng *rand.Rand, widthTiles, heightTiles int) {
+	rowCount := heightTiles * 8
+	if heightTiles == 0 {
+		rowCount = 256
+	}
+	columnCount := widthTiles * 4
+	if widthTiles == 0 {
+		columnCount = 128
+	}
+	totalPairs := rowCount * columnCount
+
+	offset := 0
+	literal := rng.Intn(2) == 1
+	if literal {
+		w.write(1, 1)
+	} else {
+		w.write(0, 1)
+	}
+	for offset < totalPairs {
+		if !literal {
+			switch rng.Intn(16) {
+			case 0:
+				offset += w.writeRunLength(rng.Intn(0x10000) + 0xffff)
+			case 1:
+				offset += w.writeRunLength(rng.Intn(totalPairs) + 1)
+			default:
+				offset += w.writeRunLength(rng.Intn(32) + 1)
+			}
+		}
+		literal = false
+		for count := rng.Intn(48); offset < totalPairs && count > 0; count-- {
+			w.write(rng.Intn(3) + 1, 2)
+			offset++
+		}
+		if offset < totalPairs {
+			w.write(0, 2)
+		}
+	}
+}
+
+func randomMemory(rng *rand.Rand, stream []byte) []byte {
+	memSpace := make([]byte, 65536)
+	rng.Read(memSpace)
+	copy(memSpace[streamAddr:], stream)
+	return memSpace
+}
+
+// referenceCheck decodes the sprite at streamAddr with the reference decoder,
+// by recording it live on the memory, and by replaying a journal recorded from
+// a copy of it, and compares the resulting memory.
+func referenceCheck(memSpace []byte, baseDataWidth, baseDataHeight int) error {
+	reference := bytes.Clone(memSpace)
+	err := decomp.ReferenceDecompressSprite(romdata.DefaultProfile, gbmem.Flat(reference), streamAddr, baseDataWidth, baseDataHeight)
+	if err != nil {
+		return err
+	}
+
+	live := bytes.Clone(memSpace)
+	_, err = decomp.TryRecordDecompressSpriteOn(romdata.DefaultProfile, gbmem.Flat(live), streamAddr, baseDataWidth, baseDataHeight)
+	if err != nil {
+		return err
+	}
+	if i := firstDifference(reference, live); i >= 0 {
+		return fmt.Errorf("live recording differs at 0x%04x: expected 0x%02x, got 0x%02x", i, reference[i], live[i])
+	}
+
+	replayed := bytes.Clone(memSpace)
+	recording, err := decomp.TryRecordDecompressSprite(romdata.DefaultProfile, memSpace, streamAddr, baseDataWidth, baseDataHeight)
+	if err != nil {
+		return err
+	}
+	recording.ApplyRecording(&replayed)
+	if i := firstDifference(reference, replayed); i >= 0 {
+		return fmt.Errorf("replayed journal differs at 0x%04x: expected 0x%02x, got 0x%02x", i, reference[i], replayed[i])
+	}
+	return nil
+}
+
+func firstDifference(a, b []byte) int {
+	for i := range a {
+		if a[i] != b[i] {
+			return i
+		}
+	}
+	return -1
+}
+
+func Test_ReferenceEveryMode(t *testing.T) {
+	log.SetOutput(io.Discard)
+	defer log.SetOutput(os.Stderr)
+
+	rng := rand.New(rand.NewSource(25))
+	for _, decodeMode := range []uint8{0, 2, 3} {
+		for firstBuffer := 1; firstBuffer <= 2; firstBuffer++ {
+			for widthTiles := 0; widthTiles < 16; widthTiles++ {
+				for heightTiles := 0; heightTiles < 16; heightTiles++ {
+					writer := bitWriter{}
+					writer.write(widthTiles, 4)
+					writer.write(heightTiles, 4)
+					writer.write(firstBuffer - 1, 1)
+					writer.writeRandomPlane(rng, widthTiles, heightTiles)
+					if decodeMode == 0 {
+						writer.write(0, 1)
+					} else {
+						writer.write(int(decodeMode), 2)
+					}
+					writer.writeRandomPlane(rng, widthTiles, heightTiles)
+
+					memSpace := randomMemory(rng, writer.data)
+					recording, err := decomp.TryRecordDecompressSprite(romdata.DefaultProfile, memSpace, streamAddr, -1, -1)
+					if err != nil {
+						t.Fatal(err)
+					}
+					if recording.DecodeMode != decodeMode || recording.FirstBuffer != firstBuffer ||
+					   recording.WidthTiles != widthTiles || recording.HeightTiles != heightTiles {
+						t.Fatalf("expected mode %d, BP%d first, %dx%d, got mode %d, BP%d first, %dx%d",
+						         decodeMode, firstBuffer, widthTiles, heightTiles,
+						         recording.DecodeMode, recording.FirstBuffer, recording.WidthTiles, recording.HeightTiles)
+					}
+
+					err = referenceCheck(memSpace, rng.Intn(16), rng.Intn(16))
+					if err != nil {
+						t.Errorf("mode %d, BP%d first, %dx%d: %v", decodeMode, firstBuffer, widthTiles, heightTiles, err)
+					}
+				}
+			}
+		}
+	}
+}
+
+func Test_ReferenceRandomStreams(t *testing.T) {
+	log.SetOutput(io.Discard)
+	defer log.SetOutput(os.Stderr)
+
+	check := func(memSpace []byte, baseDataWidth, baseDataHeight int) bool {
+		err := referenceCheck(memSpace, baseDataWidth, baseDataHeight)
+		if errors.Is(err, decomp.ErrOutsideOffsetList) {
+			return true
+		}
+		if err != nil {
+			t.Log(err)
+			return false
+		}
+		return true
+	}
+	conf := quick.Config{MaxCount: 200, MaxCountScale: 1.0, Values: func(res []reflect.Value, rng *rand.Rand) {
+		stream := make([]byte, 0x4000)
+		rng.Read(stream)
+		res[0] = reflect.ValueOf(randomMemory(rng, stream))
+		res[1] = reflect.ValueOf(rng.Intn(16))
+		res[2] = reflect.ValueOf(rng.Intn(16))
+	}}
+	if err := quick.Check(check, &conf); err != nil {
+		t.Error(err)
+	}
+}
+
+func Test_UndoDeltaDecodeZeroHeight(t *testing.T) {
+	log.SetOutput(io.Discard)
+	defer log.SetOutput(os.Stderr)
+
+	rng := rand.New(rand.NewSource(0))
+	for i := 0; i < 100; i++ {
+		writer := bitWriter{}
+		writer.write(rng.Intn(15) + 1, 4)
+		writer.write(0, 4)
+		writer.write(rng.Intn(2), 1)
+		writer.writeRandomPlane(rng, 1, 0)
+		writer.write(0, 1)
+		writer.writeRandomPlane(rng, 1, 0)
+
+		memSpace := randomMemory(rng, writer.data)
+		recording, err := decomp.TryRecordDecompressSprite(romdata.DefaultProfile, memSpace, streamAddr, -1, -1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var deltaOps []decomp.Operation
+		for _, op := range recording.Operations {
+			if op.T == decomp.DeltaDec {
+				deltaOps = append(deltaOps, op)
+			}
+		}
+		if !deltaDecIdentityCheck(memSpace, deltaOps) {
+			t.Fatalf("undoing the delta decoding of a %dx0 sprite doesn't restore its data", recording.WidthTiles)
+		}
+	}
+}
@@ -0,0 +1,100 @@
+/*
+ * fools2024-solutions: source code for Kagamiin's solutions for TheZZAZZGlitch April Fools Event 2024's Security Testing Program.
+ * Copyright (C) 2024 Kagamiin~
+ * 
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License,
+ * or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program. If not, see <https://www.gnu.org/licenses/>.
+ */
+
+package decomp
+
+import (
+	"log"
+)
+
+// scaleSpriteByTwo records ScaleSpriteByTwo, which scales the 4x4 tile back
+// sprites left in buffers 1 and 2 to 7x7 tiles in buffers 0 and 1. The
+// rightmost and bottommost 4 pixels of the input are dropped.
+func (r *RecordedDecompression) scaleSpriteByTwo() {
+	log.Println("Scaling sprite by two...")
+	r.phase = PhaseScale
+	for _, buffers := range [][2]int{{1, 0}, {2, 1}} {
+		forEachScaleWrite(r.buffers, buffers[0], buffers[1], func(destAddr, srcAddr uint16, shift uint8) {
+			r.appendOp(Operation{
+				T: Scale,
+				DestAddr: destAddr,
+				Mask: 0xff,
+				Value: shift,
+				SourceAddr: srcAddr,
+			})
+		})
+	}
+}
+
+// forEachScaleWrite walks backwards through the source and destination
+// buffers the same way ScaleSpriteByTwo does. Every nybble of the source is
+// written to two consecutive rows of the destination.
+func forEachScaleWrite(buffers spriteBuffers, srcBuffer, destBuffer int, visit func(destAddr, srcAddr uint16, shift uint8)) {
+	const inputRows = 4 * 8 - 4
+	const outputColumnSize = 7 * 8
+	srcAddr := int(buffers.baseAddr(srcBuffer)) + 4 * 4 * 8 - 5
+	destAddr := int(buffers.baseAddr(destBuffer)) + 7 * 7 * 8 - 1
+	scalePixels := func(shift uint8, offset int) {
+		visit(uint16(destAddr), uint16(srcAddr), shift)
+		visit(uint16(destAddr - 1), uint16(srcAddr), shift)
+		destAddr += offset - 1
+	}
+
+	// ScaleLastSpriteColumnByTwo: only the upper nybble is in the sprite
+	for row := 0; row < inputRows; row++ {
+		scalePixels(4, -1)
+		srcAddr--
+	}
+	srcAddr -= 4
+
+	// ScaleFirstThreeSpriteColumnsByTwo
+	for column := 0; column < 3; column++ {
+		for row := 0; row < inputRows; row++ {
+			// lower nybble into the current output column, upper nybble into
+			// the previous one
+			scalePixels(0, -outputColumnSize + 1)
+			scalePixels(4, outputColumnSize + 1 - 2)
+			srcAddr--
+		}
+		srcAddr -= 4
+		destAddr -= outputColumnSize
+	}
+}
+
+// duplicateBits repeats each of the 4 bits of a nybble twice, like the
+// DuplicateBitsTable used by ScalePixelsByTwo.
+func duplicateBits(nybble uint8) uint8 {
+	var result uint8
+	for bit := 0; bit < 4; bit++ {
+		if nybble & (1 << bit) != 0 {
+			result |= 3 << (bit * 2)
+		}
+	}
+	return result
+}
+
+// halveBits is the inverse of duplicateBits, keeping the upper bit of each pair.
+func halveBits(value uint8) uint8 {
+	var result uint8
+	for bit := 0; bit < 4; bit++ {
+		if value & (2 << (bit * 2)) != 0 {
+			result |= 1 << bit
+		}
+	}
+	return result
+}
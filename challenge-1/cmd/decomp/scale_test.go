@@ -0,0 +1,130 @@
+/*
+ * fools2024-solutions: source code for Kagamiin's solutions for TheZZAZZGlitch April Fools Event 2024's Security Testing Program.
+ * Copyright (C) 2024 Kagamiin~
+ * 
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License,
+ * or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program. If not, see <https://www.gnu.org/licenses/>.
+ */
+
+package decomp_test
+
+import (
+	"bytes"
+	"io"
+	"log"
+	"math/rand"
+	"os"
+	"testing"
+
+	"github.com/Kagamiin/fools2024-solutions/challenge-1/cmd/decomp"
+	"github.com/Kagamiin/fools2024-solutions/challenge-1/cmd/romdata"
+)
+
+// scaleByTwo builds the contents of a sprite buffer after ScaleSpriteByTwo,
+// from a 4x4 tile plane.
+func scaleByTwo(plane []byte) []byte {
+	buffer := make([]byte, 0x188)
+	for column := 0; column < 7; column++ {
+		for row := 0; row < 56; row++ {
+			input := plane[(column / 2) * 32 + row / 2]
+			if column % 2 == 0 {
+				input >>= 4
+			}
+			var output uint8
+			for bit := 0; bit < 4; bit++ {
+				if input & (1 << bit) != 0 {
+					output |= 3 << (bit * 2)
+				}
+			}
+			buffer[column * 56 + row] = output
+		}
+	}
+	return buffer
+}
+
+func Test_RecordBackSprite(t *testing.T) {
+	log.SetOutput(io.Discard)
+	defer log.SetOutput(os.Stderr)
+
+	rng := rand.New(rand.NewSource(3))
+	for i := 0; i < 50; i++ {
+		plane1 := randomPlane(rng, 4 * 4 * 8)
+		plane2 := randomPlane(rng, 4 * 4 * 8)
+		stream, err := decomp.CompressSprite(plane1, plane2, 4, 4)
+		if err != nil {
+			t.Fatal(err)
+		}
+		memSpace := make([]byte, 65536)
+		copy(memSpace[streamAddr:], stream)
+
+		recording, err := decomp.TryRecordDecompressBackSprite(romdata.DefaultProfile, memSpace, streamAddr)
+		if err != nil {
+			t.Fatal(err)
+		}
+		recording.ApplyRecording(&memSpace)
+
+		buffer0 := scaleByTwo(plane1)
+		buffer1 := scaleByTwo(plane2)
+		expected := make([]byte, 0x310)
+		for i := 0; i < 0x188; i++ {
+			expected[i * 2] = buffer0[i]
+			expected[i * 2 + 1] = buffer1[i]
+		}
+		if !bytes.Equal(memSpace[0xa188:0xa498], expected) {
+			t.Fatalf("scaled sprite doesn't match, stream % x", stream)
+		}
+	}
+}
+
+func Test_UndoScale(t *testing.T) {
+	memory := make([]byte, 65536)
+	memory[0xa000] = 0x5a
+	memory[0xa100] = 0xc3
+
+	operation := decomp.Operation{T: decomp.Scale, DestAddr: 0xa100, SourceAddr: 0xa000, Mask: 0xff, Value: 4}
+	operation.UndoScale(&memory)
+	if memory[0xa000] != 0x9a {
+		t.Errorf("expected source 0x9a, got 0x%02x", memory[0xa000])
+	}
+
+	operation.Do(&memory, 0)
+	if memory[0xa100] != 0xc3 {
+		t.Errorf("expected 0xc3 after scaling again, got 0x%02x", memory[0xa100])
+	}
+}
+
+func Test_UndoScaleUnknownBits(t *testing.T) {
+	log.SetOutput(io.Discard)
+	defer log.SetOutput(os.Stderr)
+
+	memory := make([]byte, 65536)
+	memory[0xa100] = 0xc3
+	// the source gets cleared after being scaled, so only the scaled nybble
+	// of it can be recovered
+	recording := decomp.RecordedDecompression{
+		Operations: []decomp.Operation{
+			{T: decomp.Scale, DestAddr: 0xa100, SourceAddr: 0xa000, Mask: 0xff, Value: 4},
+			{T: decomp.Fill, DestAddr: 0xa000, Mask: 0xff, Value: 0},
+		},
+	}
+	unknownBitMap, conflicts := recording.UndoRecordingWithConflicts(&memory)
+	if len(conflicts) > 0 {
+		t.Fatalf("unexpected conflicts, first one: %v", conflicts[0])
+	}
+	if memory[0xa000] & 0xf0 != 0x90 || (*unknownBitMap)[0xa000] != 0x0f {
+		t.Errorf("expected source 0x9- with unknown bits 0x0f, got 0x%02x and 0x%02x", memory[0xa000], (*unknownBitMap)[0xa000])
+	}
+	if (*unknownBitMap)[0xa100] != 0xff {
+		t.Errorf("expected the scaled byte to be unknown, got unknown bits 0x%02x", (*unknownBitMap)[0xa100])
+	}
+}
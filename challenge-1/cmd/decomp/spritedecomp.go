@@ -20,8 +20,11 @@ package decomp
 
 import (
 	"errors"
-	// "fmt"
+	"fmt"
 	"log"
+
+	"github.com/Kagamiin/fools2024-solutions/challenge-1/cmd/gbmem"
+	"github.com/Kagamiin/fools2024-solutions/challenge-1/cmd/romdata"
 )
 
 type opType int
@@ -32,6 +35,25 @@ const (
 	DeltaDec
 	DCopy
 	DXor
+	// Scale writes a nybble of the source with every bit doubled, as done by
+	// ScaleSpriteByTwo for back sprites. Value is the shift of the nybble.
+	Scale
+)
+
+type Phase int
+
+const (
+	PhaseUnknown Phase = iota
+	PhaseClear
+	PhaseImprint
+	PhaseDelta
+	PhaseXor
+	PhaseCopyAlign
+	PhaseInterlace
+	PhaseScale
+	// Phases of the encounter around the sprite decompression.
+	PhaseMonHeader
+	PhaseDexFlag
 )
 
 type Operation struct {
@@ -40,14 +62,52 @@ type Operation struct {
 	Mask uint8
 	Value uint8
 	SourceAddr uint16
+	Phase Phase
+	// Position in the compressed input of the first bit read to produce the
+	// operation. Only meaningful for operations of the imprinting phase.
+	StreamByte int
+	StreamBit uint8
 }
 
 type RecordedDecompression struct {
 	Operations []Operation
+	// Properties of the recorded sprite as read from its header. These are
+	// not stored in journals.
+	WidthTiles int
+	HeightTiles int
+	FirstBuffer int
+	DecodeMode uint8
+	// Back is set for back sprites, which are scaled by two instead of
+	// being copied/aligned.
+	Back bool
+	// InputEnd is the position of the byte holding the last bit read from
+	// the compressed input.
+	InputEnd int
+	phase Phase
+	buffers spriteBuffers
+	// live is the memory operations are replayed on as they're recorded, if
+	// any, along with the integrator threaded through them.
+	live gbmem.Memory
+	liveIntegrator uint8
 }
 
+func (r *RecordedDecompression) appendOp(operation Operation) {
+	operation.Phase = r.phase
+	r.Operations = append(r.Operations, operation)
+	if r.live != nil {
+		r.liveIntegrator = operation.Replay(r.live, r.liveIntegrator)
+	}
+}
+
+var ErrPrematureEnd = errors.New("premature end of stream")
+
+// A BitstreamReader reads either from a plain slice, failing at its end, or
+// through a memory model if memory is set. Reads through memory follow the
+// game's pointer, wrapping around at 0xffff, and see whatever is mapped at the
+// time of the read.
 type BitstreamReader struct {
 	bitstream []byte
+	memory gbmem.Memory
 	currentByte uint8
 	bytePosition int
 	bitPosition int
@@ -55,10 +115,12 @@ type BitstreamReader struct {
 
 func (b *BitstreamReader) readBit() (uint8, error) {
 	if b.bitPosition == 0 {
-		if b.bytePosition < len(b.bitstream) {
+		if b.memory != nil {
+			b.currentByte = b.memory.Read(uint16(b.bytePosition))
+		} else if b.bytePosition < len(b.bitstream) {
 			b.currentByte = b.bitstream[b.bytePosition]
 		} else {
-			return 0, errors.New("premature end of stream")
+			return 0, ErrPrematureEnd
 		}
 	}
 	
@@ -71,10 +133,18 @@ func (b *BitstreamReader) readBit() (uint8, error) {
 	} else {
 		b.bitPosition = 0;
 		b.bytePosition++
+		if b.memory != nil {
+			b.bytePosition &= 0xffff
+		}
 		return b.readBit()
 	}
 }
 
+// bitOffset returns the absolute position of the next bit to be read, in bits.
+func (b *BitstreamReader) bitOffset() int {
+	return b.bytePosition * 8 + b.bitPosition
+}
+
 func (b *BitstreamReader) readBits(numBits int) (int, error) {
 	var result int
 	for i := 0; i < numBits; i++ {
@@ -90,25 +160,94 @@ func (b *BitstreamReader) readBits(numBits int) (int, error) {
 	return result, nil
 }
 
+// RecordDecompressSprite records the decompression of a sprite using the
+// memory layout of the default profile.
 func RecordDecompressSprite(rom []byte, spritePtr, baseDataWidth, baseDataHeight int) *RecordedDecompression {
-	recording := RecordedDecompression{
-		Operations: make([]Operation, 0),
+	recording, err := TryRecordDecompressSprite(romdata.DefaultProfile, rom, spritePtr, baseDataWidth, baseDataHeight)
+	handle(err)
+	return recording
+}
+
+// TryRecordDecompressSprite records the decompression of a sprite using the
+// memory layout of the given profile. Instead of panicking, it returns a
+// *DecodeError if the sprite data is malformed or runs off the end of rom,
+// along with the operations recorded up to the failure.
+func TryRecordDecompressSprite(profile *romdata.Profile, rom []byte, spritePtr, baseDataWidth, baseDataHeight int) (*RecordedDecompression, error) {
+	recording := newRecording(profile, false)
+	spriteReader := BitstreamReader{
+		bitstream: rom,
+		bytePosition: spritePtr,
 	}
-	
+	return recording.record(&spriteReader, spritePtr, baseDataWidth, baseDataHeight)
+}
+
+// TryRecordDecompressBackSprite is TryRecordDecompressSprite for back sprites,
+// which are scaled by two instead of being copied/aligned according to their
+// base data dimensions.
+func TryRecordDecompressBackSprite(profile *romdata.Profile, rom []byte, spritePtr int) (*RecordedDecompression, error) {
+	recording := newRecording(profile, true)
 	spriteReader := BitstreamReader{
 		bitstream: rom,
 		bytePosition: spritePtr,
 	}
+	return recording.record(&spriteReader, spritePtr, -1, -1)
+}
+
+func newRecording(profile *romdata.Profile, back bool) *RecordedDecompression {
+	return &RecordedDecompression{
+		Operations: make([]Operation, 0),
+		Back: back,
+		buffers: spriteBuffers(profile.SpriteBuffers),
+	}
+}
+
+// TryRecordDecompressSpriteOn records the decompression of a sprite read
+// through mem, replaying every operation on mem as soon as it's recorded.
+// Sprite data running past the end of a ROM bank is read from whatever is
+// mapped after it, including bytes already changed by the decompression in
+// progress. On return, mem holds the result of the decompression.
+func TryRecordDecompressSpriteOn(profile *romdata.Profile, mem gbmem.Memory, spritePtr, baseDataWidth, baseDataHeight int) (*RecordedDecompression, error) {
+	recording := newRecording(profile, false)
+	recording.live = mem
+	spriteReader := BitstreamReader{
+		memory: mem,
+		bytePosition: spritePtr & 0xffff,
+	}
+	return recording.record(&spriteReader, spritePtr, baseDataWidth, baseDataHeight)
+}
+
+// TryRecordDecompressBackSpriteOn is TryRecordDecompressSpriteOn for back sprites.
+func TryRecordDecompressBackSpriteOn(profile *romdata.Profile, mem gbmem.Memory, spritePtr int) (*RecordedDecompression, error) {
+	recording := newRecording(profile, true)
+	recording.live = mem
+	spriteReader := BitstreamReader{
+		memory: mem,
+		bytePosition: spritePtr & 0xffff,
+	}
+	return recording.record(&spriteReader, spritePtr, -1, -1)
+}
+
+func (r *RecordedDecompression) record(spriteReader *BitstreamReader, spritePtr, baseDataWidth, baseDataHeight int) (*RecordedDecompression, error) {
+	wrapErr := func(err error) error {
+		return &DecodeError{
+			SpritePtr: spritePtr,
+			BytePosition: spriteReader.bytePosition,
+			Err: err,
+		}
+	}
 	
 	log.Println("Clearing buffers")
-	recording.fillBuffer(1);
-	recording.fillBuffer(2);
+	r.phase = PhaseClear
+	r.fillBuffer(1);
+	r.fillBuffer(2);
 	
-	widthTiles, err := spriteReader.readBits(4)
-	handle(err)
-	heightTiles, err := spriteReader.readBits(4)
-	handle(err)
+	widthTiles, heightTiles, err := readSpriteSize(spriteReader)
+	if err != nil {
+		return r, wrapErr(err)
+	}
 	log.Printf("Sprite size is %dx%d\n", widthTiles, heightTiles)
+	r.WidthTiles = widthTiles
+	r.HeightTiles = heightTiles
 	
 	if baseDataWidth < 0 {
 		baseDataWidth = widthTiles
@@ -117,86 +256,178 @@ func RecordDecompressSprite(rom []byte, spritePtr, baseDataWidth, baseDataHeight
 		baseDataHeight = heightTiles
 	}
 	
-	firstBuffer, err := spriteReader.readBit()
-	handle(err)
-	firstBuffer++
-	var secondBuffer uint8
-	
-	if firstBuffer == 1 {
-		secondBuffer = 2
-	} else {
-		secondBuffer = 1
+	firstBuffer, secondBuffer, err := readBufferOrder(spriteReader)
+	if err != nil {
+		return r, wrapErr(err)
 	}
+	r.FirstBuffer = firstBuffer
 	
-	bufferOrder := []uint8{firstBuffer, secondBuffer}
+	bufferOrder := []int{firstBuffer, secondBuffer}
 	log.Printf("Starting with BP%d, then BP%d\n", firstBuffer, secondBuffer)
 	
 	var decodeMode uint8
 	
 	for i := 0; i < 2; i++ {
 		if i == 1 {
-			decodeMode, err = spriteReader.readBit()
-			handle(err)
-			if decodeMode == 1 {
-				decodeMode <<= 1
-				bit, err := spriteReader.readBit()
-				handle(err)
-				decodeMode |= bit
+			decodeMode, err = readDecodeMode(spriteReader)
+			if err != nil {
+				return r, wrapErr(err)
 			}
+			r.DecodeMode = decodeMode
 		}
 		
-		log.Printf("Decompressing plane %d into BP%d...\n", i, int(bufferOrder[i]))
-		recording.decompressPlane(&spriteReader, heightTiles, widthTiles, int(bufferOrder[i]))
+		log.Printf("Decompressing plane %d into BP%d...\n", i, bufferOrder[i])
+		err = r.decompressPlane(spriteReader, heightTiles, widthTiles, bufferOrder[i])
+		if err != nil {
+			return r, wrapErr(err)
+		}
 	}
+	r.InputEnd = spriteReader.bytePosition
 	
 	log.Printf("Using decode mode %d\n", decodeMode)
 	
 	
+	for _, step := range unpackSteps(decodeMode, firstBuffer, secondBuffer) {
+		if step.xor {
+			r.xorBuffers(heightTiles, widthTiles, step.sourceBuffer, step.buffer)
+		} else {
+			r.deltaDecode(heightTiles, widthTiles, step.buffer)
+		}
+	}
+	
+	if r.Back {
+		r.scaleSpriteByTwo()
+	} else {
+		r.copyAlignSpriteData(baseDataHeight, baseDataWidth)
+	}
+	r.interlaceBuffers()
+	
+	return r, nil
+}
+
+func readSpriteSize(spriteReader *BitstreamReader) (widthTiles, heightTiles int, err error) {
+	widthTiles, err = spriteReader.readBits(4)
+	if err != nil {
+		return
+	}
+	heightTiles, err = spriteReader.readBits(4)
+	return
+}
+
+func readBufferOrder(spriteReader *BitstreamReader) (firstBuffer, secondBuffer int, err error) {
+	bit, err := spriteReader.readBit()
+	if err != nil {
+		return
+	}
+	if bit == 0 {
+		return 1, 2, nil
+	}
+	return 2, 1, nil
+}
+
+// readDecodeMode reads the 1-2 bit unpacking mode that precedes the second plane.
+// 0 is read as mode 0, 10 as mode 2 and 11 as mode 3.
+func readDecodeMode(spriteReader *BitstreamReader) (uint8, error) {
+	decodeMode, err := spriteReader.readBit()
+	if err != nil {
+		return 0, err
+	}
+	if decodeMode == 1 {
+		decodeMode <<= 1
+		bit, err := spriteReader.readBit()
+		if err != nil {
+			return 0, err
+		}
+		decodeMode |= bit
+	}
+	return decodeMode, nil
+}
+
+type unpackStep struct {
+	xor bool
+	buffer int
+	sourceBuffer int
+}
+
+// unpackSteps lists the post-processing steps the game performs for each
+// decode mode, in order. Delta decode steps act on buffer; XOR steps XOR
+// sourceBuffer into buffer.
+func unpackSteps(decodeMode uint8, firstBuffer, secondBuffer int) []unpackStep {
 	switch decodeMode {
 	case 0:
-		recording.deltaDecode(heightTiles, widthTiles, 1)
-		recording.deltaDecode(heightTiles, widthTiles, 2)
+		return []unpackStep{
+			{buffer: 1},
+			{buffer: 2},
+		}
 	case 2:
-		recording.deltaDecode(heightTiles, widthTiles, int(firstBuffer))
-		recording.xorBuffers(heightTiles, widthTiles, int(firstBuffer), int(secondBuffer))
+		return []unpackStep{
+			{buffer: firstBuffer},
+			{xor: true, buffer: secondBuffer, sourceBuffer: firstBuffer},
+		}
 	case 3:
-		recording.deltaDecode(heightTiles, widthTiles, int(secondBuffer))
-		recording.deltaDecode(heightTiles, widthTiles, int(firstBuffer))
-		recording.xorBuffers(heightTiles, widthTiles, int(firstBuffer), int(secondBuffer))
+		return []unpackStep{
+			{buffer: secondBuffer},
+			{buffer: firstBuffer},
+			{xor: true, buffer: secondBuffer, sourceBuffer: firstBuffer},
+		}
 	}
-	
-	recording.copyAlignSpriteData(baseDataHeight, baseDataWidth)
-	recording.interlaceBuffers()
-	
-	return &recording
+	panic(fmt.Sprintf("invalid decode mode %d", decodeMode))
 }
 
 func (r *RecordedDecompression) interlaceBuffers() {
 	log.Println("Interlacing buffers...")
+	r.phase = PhaseInterlace
+	forEachInterlaceCopy(r.buffers, func(destAddr, srcAddr uint16) {
+		r.appendOp(Operation{
+			T: DCopy,
+			DestAddr: destAddr,
+			Mask: 0xff,
+			SourceAddr: srcAddr,
+		})
+	})
+}
+
+func forEachInterlaceCopy(buffers spriteBuffers, visit func(destAddr, srcAddr uint16)) {
 	for offset := 0x187; offset >= 0; offset-- {
-		srcAddr2 := getBufferBaseAddr(1) + uint16(offset)
-		srcAddr1 := getBufferBaseAddr(0) + uint16(offset)
-		destAddr2 := getBufferBaseAddr(1) + uint16(offset) * 2 + 1
-		destAddr1 := getBufferBaseAddr(1) + uint16(offset) * 2
+		srcAddr2 := buffers.baseAddr(1) + uint16(offset)
+		srcAddr1 := buffers.baseAddr(0) + uint16(offset)
+		destAddr2 := buffers.baseAddr(1) + uint16(offset) * 2 + 1
+		destAddr1 := buffers.baseAddr(1) + uint16(offset) * 2
 		
-		r.Operations = append(r.Operations, Operation{
+		visit(destAddr2, srcAddr2)
+		visit(destAddr1, srcAddr1)
+	}
+}
+
+func (r *RecordedDecompression) copyAlignSpriteData(heightTiles, widthTiles int) {
+	log.Printf("Copying/aligning sprite data with size %dx%d...\n", widthTiles, heightTiles)
+	r.phase = PhaseCopyAlign
+	
+	r.fillBuffer(0)
+	forEachAlignCopy(r.buffers, heightTiles, widthTiles, 1, 0, func(destAddr, srcAddr uint16) {
+		r.appendOp(Operation {
 			T: DCopy,
-			DestAddr: destAddr2,
+			DestAddr: destAddr,
 			Mask: 0xff,
-			SourceAddr: srcAddr2,
+			SourceAddr: srcAddr,
 		})
-		r.Operations = append(r.Operations, Operation{
+	})
+	
+	r.fillBuffer(1)
+	forEachAlignCopy(r.buffers, heightTiles, widthTiles, 2, 1, func(destAddr, srcAddr uint16) {
+		r.appendOp(Operation {
 			T: DCopy,
-			DestAddr: destAddr1,
+			DestAddr: destAddr,
 			Mask: 0xff,
-			SourceAddr: srcAddr1,
+			SourceAddr: srcAddr,
 		})
-	}
+	})
 }
 
-func (r *RecordedDecompression) copyAlignSpriteData(heightTiles, widthTiles int) {
-	log.Printf("Copying/aligning sprite data with size %dx%d...\n", widthTiles, heightTiles)
-	startOffset := (7 * ((8 - widthTiles) / 2)) & 0xff
+func forEachAlignCopy(buffers spriteBuffers, heightTiles, widthTiles, srcBuffer, destBuffer int, visit func(destAddr, srcAddr uint16)) {
+	// the horizontal center is computed with an 8-bit srl, so widths over 8
+	// wrap around instead of going negative
+	startOffset := (7 * (((8 - widthTiles) & 0xff) >> 1)) & 0xff
 	startOffset = (startOffset + (7 - heightTiles)) & 0xff
 	startOffset = (8 * startOffset) & 0xff
 
@@ -208,41 +439,30 @@ func (r *RecordedDecompression) copyAlignSpriteData(heightTiles, widthTiles int)
 		widthTiles = 256
 	}
 	
-	r.fillBuffer(0)
-	
-	for column := 0; column < widthTiles; column++ {
-		for row := 0; row < rowCountForProcessing; row++ {
-			destAddr := getBufferBaseAddr(0) + uint16(startOffset) + uint16(column * 7 * 8) + uint16(row)
-			srcAddr := getBufferBaseAddr(1) + uint16(column * rowCountForProcessing) + uint16(row)
-			r.Operations = append(r.Operations, Operation {
-				T: DCopy,
-				DestAddr: destAddr,
-				Mask: 0xff,
-				SourceAddr: srcAddr,
-			})
-		}
-	}
-	
-	r.fillBuffer(1)
-	
 	for column := 0; column < widthTiles; column++ {
 		for row := 0; row < rowCountForProcessing; row++ {
-			destAddr := getBufferBaseAddr(1) + uint16(startOffset) + uint16(column * 7 * 8) + uint16(row)
-			srcAddr := getBufferBaseAddr(2) + uint16(column * rowCountForProcessing) + uint16(row)
-			r.Operations = append(r.Operations, Operation {
-				T: DCopy,
-				DestAddr: destAddr,
-				Mask: 0xff,
-				SourceAddr: srcAddr,
-			})
+			destAddr := buffers.baseAddr(destBuffer) + uint16(startOffset) + uint16(column * 7 * 8) + uint16(row)
+			srcAddr := buffers.baseAddr(srcBuffer) + uint16(column * rowCountForProcessing) + uint16(row)
+			visit(destAddr, srcAddr)
 		}
 	}
 }
 
 func (r *RecordedDecompression) xorBuffers(heightTiles, widthTiles, firstBuffer, secondBuffer int) {
 	log.Printf("Applying XOR from BP%d to BP%d\n", firstBuffer, secondBuffer)
-	rowCount := uint16(heightTiles * 8)
-	rowCountForProcessing := rowCount
+	r.phase = PhaseXor
+	forEachXorPair(r.buffers, heightTiles, widthTiles, firstBuffer, secondBuffer, func(destAddr, sourceAddr uint16) {
+		r.appendOp(Operation{
+			T: DXor,
+			DestAddr: destAddr,
+			Mask: 0xff,
+			SourceAddr: sourceAddr,
+		})
+	})
+}
+
+func forEachXorPair(buffers spriteBuffers, heightTiles, widthTiles, firstBuffer, secondBuffer int, visit func(destAddr, sourceAddr uint16)) {
+	rowCountForProcessing := uint16(heightTiles * 8)
 	if rowCountForProcessing == 0 {
 		rowCountForProcessing = 256
 	}
@@ -250,22 +470,38 @@ func (r *RecordedDecompression) xorBuffers(heightTiles, widthTiles, firstBuffer,
 		widthTiles = 32
 	}
 	
+	// the game walks both buffers linearly, so a height of 0 makes columns
+	// 256 bytes apart rather than overlapping like when delta decoding
 	for column := uint16(0); column < uint16(widthTiles); column++ {
 		for row := uint16(0); row < rowCountForProcessing; row++ {
-			sourceAddr := getBufferBaseAddr(firstBuffer) + rowCount * column + row
-			destAddr := getBufferBaseAddr(secondBuffer) + rowCount * column + row
-			r.Operations = append(r.Operations, Operation{
-				T: DXor,
-				DestAddr: destAddr,
-				Mask: 0xff,
-				SourceAddr: sourceAddr,
-			})
+			sourceAddr := buffers.baseAddr(firstBuffer) + rowCountForProcessing * column + row
+			destAddr := buffers.baseAddr(secondBuffer) + rowCountForProcessing * column + row
+			visit(destAddr, sourceAddr)
 		}
 	}
 }
 
 func (r *RecordedDecompression) deltaDecode(heightTiles, widthTiles, bufferIdx int) {
 	log.Printf("Performing delta decode on BP%d\n", bufferIdx)
+	r.phase = PhaseDelta
+	forEachDeltaDecodeByte(r.buffers, heightTiles, widthTiles, bufferIdx, func(addr, prevAddr uint16, rowStart bool) {
+		var startValueMask uint8
+		if rowStart {
+			startValueMask = 0
+		} else {
+			startValueMask = 1
+		}
+		r.appendOp(Operation{
+			T: DeltaDec,
+			DestAddr: addr,
+			Mask: 0xff,
+			Value: startValueMask,
+			SourceAddr: prevAddr,
+		})
+	})
+}
+
+func forEachDeltaDecodeByte(buffers spriteBuffers, heightTiles, widthTiles, bufferIdx int, visit func(addr, prevAddr uint16, rowStart bool)) {
 	rowCount := uint16(heightTiles * 8)
 	rowCountForProcessing := rowCount
 	if rowCountForProcessing == 0 {
@@ -277,33 +513,40 @@ func (r *RecordedDecompression) deltaDecode(heightTiles, widthTiles, bufferIdx i
 	
 	for row := uint16(0); row < rowCountForProcessing; row++ {
 		for column := uint16(0); column < uint16(widthTiles); column++ {
-			addr := getBufferBaseAddr(bufferIdx) + rowCount * column + row
+			addr := buffers.baseAddr(bufferIdx) + rowCount * column + row
 			var prevAddr uint16
 			if column > 0 {
-				prevAddr = getBufferBaseAddr(bufferIdx) + rowCount * (column - 1) + row
+				prevAddr = buffers.baseAddr(bufferIdx) + rowCount * (column - 1) + row
 			} else {
 				prevAddr = addr
 			}
-			
-			var startValueMask uint8
-			if column == 0 {
-				startValueMask = 0
-			} else {
-				startValueMask = 1
-			}
-			r.Operations = append(r.Operations, Operation{
-				T: DeltaDec,
-				DestAddr: addr,
-				Mask: 0xff,
-				Value: startValueMask,
-				SourceAddr: prevAddr,
-			})
+			visit(addr, prevAddr, column == 0)
 		}
 	}
 }
 
 func (r *RecordedDecompression) decompressPlane(spriteReader *BitstreamReader, 
-                                                heightTiles, widthTiles, bufferIdx int) {
+                                                heightTiles, widthTiles, bufferIdx int) error {
+	r.phase = PhaseImprint
+	return walkPlane(r.buffers, spriteReader, heightTiles, widthTiles, bufferIdx, func(addr uint16, mask, value uint8, streamOffset int) {
+		r.appendOp(Operation {
+			T: Or,
+			DestAddr: addr,
+			Mask: mask,
+			Value: value,
+			StreamByte: streamOffset / 8,
+			StreamBit: uint8(streamOffset % 8),
+		})
+		//log.Println(r.Operations[len(r.Operations) - 1])
+	})
+}
+
+// walkPlane reads one compressed plane and calls imprint for every non-zero
+// pixel pair, with the address of its byte, the mask of the pair within the
+// byte, the pair's value shifted into place and the bit offset in the input
+// the pair was read from.
+func walkPlane(buffers spriteBuffers, spriteReader *BitstreamReader, heightTiles, widthTiles, bufferIdx int,
+               imprint func(addr uint16, mask, value uint8, streamOffset int)) error {
 	rowCount := uint16(heightTiles * 8)
 	if heightTiles == 0 {
 		rowCount = 256
@@ -313,72 +556,83 @@ func (r *RecordedDecompression) decompressPlane(spriteReader *BitstreamReader,
 	if widthTiles == 0 {
 		columnCount = 128
 	}
-	totalOffset := rowCount * columnCount
+	totalOffset := int(rowCount) * int(columnCount)
 	outputRowIdx := uint16(0)
 	outputColumnIdx := uint16(0)
-	outputOffset := uint16(0)
+	outputOffset := 0
 	
 	currentMode, err := spriteReader.readBit()
-	handle(err)
+	if err != nil {
+		return err
+	}
 	if currentMode == 0 {
-		readRLEPacket(spriteReader, &outputOffset, &outputRowIdx, &outputColumnIdx, rowCount)
+		err = readRLEPacket(spriteReader, &outputOffset, &outputRowIdx, &outputColumnIdx, rowCount)
+		if err != nil {
+			return err
+		}
 		currentMode = 1
 	}
 	
 	for ; outputOffset < totalOffset; {
 		if currentMode == 0 {
-			readRLEPacket(spriteReader, &outputOffset, &outputRowIdx, &outputColumnIdx, rowCount)
+			err = readRLEPacket(spriteReader, &outputOffset, &outputRowIdx, &outputColumnIdx, rowCount)
+			if err != nil {
+				return err
+			}
 			currentMode = 1
 		} else {
 			code, err := spriteReader.readBits(2)
-			handle(err)
+			if err != nil {
+				return err
+			}
 			if code == 0 {
 				currentMode = 0
 				continue
 			}
-			r.Operations = append(r.Operations, Operation {
-				T: Or,
-				DestAddr: getBufferPixelPairAddr(bufferIdx, outputRowIdx, outputColumnIdx, rowCount),
-				Mask: 0xc0 >> ((outputColumnIdx % 4) * 2),
-				Value: uint8(code) << (6 - ((outputColumnIdx % 4) * 2)),
-			})
-			//log.Println(r.Operations[len(r.Operations) - 1], outputRowIdx, outputColumnIdx, rowCount)
+			imprint(buffers.pixelPairAddr(bufferIdx, outputRowIdx, outputColumnIdx, rowCount),
+			        0xc0 >> ((outputColumnIdx % 4) * 2),
+			        uint8(code) << (6 - ((outputColumnIdx % 4) * 2)),
+			        spriteReader.bitOffset() - 2)
 			outputOffset++
 			outputRowIdx, outputColumnIdx = recalcRowColumnIdx(outputOffset, rowCount)
 		}
 	}
+	return nil
 }
 
 func (r *RecordedDecompression) fillBuffer(bufIndex int) {
 	log.Printf("- clearing BP%d...", bufIndex)
 	var initAddr uint16
-	initAddr = getBufferBaseAddr(bufIndex)
+	initAddr = r.buffers.baseAddr(bufIndex)
 	var addr uint16
 	for addr = initAddr; addr < initAddr + 0x188; addr++ {
-		r.Operations = append(r.Operations,
-			Operation {
-				T: Fill,
-				DestAddr: addr,
-				Mask: 0xff,
-				Value: 0,
-			},
-		)
+		r.appendOp(Operation {
+			T: Fill,
+			DestAddr: addr,
+			Mask: 0xff,
+			Value: 0,
+		})
 	}
 }
 
 func readRLEPacket(reader *BitstreamReader,
-                   outputOffset, rowIdx, columnIdx *uint16,
-                   rowCount uint16) {
+                   outputOffset *int, rowIdx, columnIdx *uint16,
+                   rowCount uint16) error {
 
 	offset, err := readExpGolombNumber(reader)
-	handle(err)
-	*outputOffset += uint16(offset)
+	if err != nil {
+		return err
+	}
+	// the game counts the run down in a 16-bit register, so run lengths wrap
+	// around at 0x10000, with 0 standing for 0x10000
+	*outputOffset += (offset - 1) & 0xffff + 1
 	*rowIdx, *columnIdx = recalcRowColumnIdx(*outputOffset, rowCount)
+	return nil
 }
 
-func recalcRowColumnIdx(offset, rowCount uint16) (rowIdx, columnIdx uint16) {
-	columnIdx = offset / rowCount
-	rowIdx = offset % rowCount
+func recalcRowColumnIdx(offset int, rowCount uint16) (rowIdx, columnIdx uint16) {
+	columnIdx = uint16(offset / int(rowCount))
+	rowIdx = uint16(offset % int(rowCount))
 	return
 }
 
@@ -424,13 +678,18 @@ func readExpGolombNumber(reader *BitstreamReader) (int, error) {
 	return result, nil
 }
 
-func getBufferBaseAddr(bufIndex int) uint16 {
-	return 0xa000 + uint16(bufIndex) * 0x188
+// spriteBuffers holds the addresses of sprite buffers 0, 1 and 2.
+type spriteBuffers [3]uint16
+
+var defaultSpriteBuffers = spriteBuffers(romdata.DefaultProfile.SpriteBuffers)
+
+func (b spriteBuffers) baseAddr(bufIndex int) uint16 {
+	return b[bufIndex]
 }
 
-func getBufferPixelPairAddr(bufIndex int,
-                            row, column, rowCount uint16) uint16 {
-	baseAddr := getBufferBaseAddr(bufIndex)
+func (b spriteBuffers) pixelPairAddr(bufIndex int,
+                                     row, column, rowCount uint16) uint16 {
+	baseAddr := b.baseAddr(bufIndex)
 	columnAddr := baseAddr + (column / 4) * rowCount
 	pixelPairAddr := columnAddr + row
 	return pixelPairAddr
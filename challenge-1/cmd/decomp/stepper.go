@@ -0,0 +1,133 @@
+/*
+ * fools2024-solutions: source code for Kagamiin's solutions for TheZZAZZGlitch April Fools Event 2024's Security Testing Program.
+ * Copyright (C) 2024 Kagamiin~
+ * 
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License,
+ * or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program. If not, see <https://www.gnu.org/licenses/>.
+ */
+
+package decomp
+
+// A Stepper walks through a journal one operation at a time over a working
+// copy of a memory space, in both directions.
+type Stepper struct {
+	recording *RecordedDecompression
+	memory []byte
+	position int
+	integrator uint8
+	history []stepState
+}
+
+// stepState holds what is needed to exactly revert one applied operation.
+type stepState struct {
+	previousValue uint8
+	previousIntegrator uint8
+}
+
+func (r *RecordedDecompression) NewStepper(memory []byte) *Stepper {
+	workingCopy := make([]byte, len(memory))
+	copy(workingCopy, memory)
+	return &Stepper{
+		recording: r,
+		memory: workingCopy,
+		history: make([]stepState, 0, len(r.Operations)),
+	}
+}
+
+// Position returns the index of the next operation to be applied.
+func (s *Stepper) Position() int {
+	return s.position
+}
+
+func (s *Stepper) Len() int {
+	return len(s.recording.Operations)
+}
+
+func (s *Stepper) Memory() []byte {
+	return s.memory
+}
+
+// Current returns the next operation to be applied, if any.
+func (s *Stepper) Current() (Operation, bool) {
+	if s.position >= len(s.recording.Operations) {
+		return Operation{}, false
+	}
+	return s.recording.Operations[s.position], true
+}
+
+// Previous returns the last operation applied, if any.
+func (s *Stepper) Previous() (Operation, bool) {
+	if s.position == 0 {
+		return Operation{}, false
+	}
+	return s.recording.Operations[s.position - 1], true
+}
+
+func (s *Stepper) Forward() bool {
+	operation, ok := s.Current()
+	if !ok {
+		return false
+	}
+	s.history = append(s.history, stepState{
+		previousValue: s.memory[operation.DestAddr],
+		previousIntegrator: s.integrator,
+	})
+	s.integrator = operation.Do(&s.memory, s.integrator)
+	s.position++
+	return true
+}
+
+func (s *Stepper) Back() bool {
+	operation, ok := s.Previous()
+	if !ok {
+		return false
+	}
+	state := s.history[len(s.history) - 1]
+	s.history = s.history[:len(s.history) - 1]
+	s.memory[operation.DestAddr] = state.previousValue
+	s.integrator = state.previousIntegrator
+	s.position--
+	return true
+}
+
+// Seek moves forwards or backwards until position is the next operation to be applied.
+func (s *Stepper) Seek(position int) {
+	position = max(0, min(position, s.Len()))
+	for ; s.position < position && s.Forward(); {
+	}
+	for ; s.position > position && s.Back(); {
+	}
+}
+
+// Touches tells whether an operation writes to or reads from addr.
+func (o Operation) Touches(addr uint16) bool {
+	if o.DestAddr == addr {
+		return true
+	}
+	switch o.T {
+	case DCopy, DXor, DeltaDec, Scale:
+		return o.SourceAddr == addr
+	}
+	return false
+}
+
+// FindTouching returns the index of the first operation at or after from that
+// touches addr, or -1 if there is none.
+func (r *RecordedDecompression) FindTouching(addr uint16, from int) int {
+	for i := max(from, 0); i < len(r.Operations); i++ {
+		if r.Operations[i].Touches(addr) {
+			return i
+		}
+	}
+	return -1
+}
@@ -0,0 +1,70 @@
+/*
+ * fools2024-solutions: source code for Kagamiin's solutions for TheZZAZZGlitch April Fools Event 2024's Security Testing Program.
+ * Copyright (C) 2024 Kagamiin~
+ * 
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License,
+ * or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program. If not, see <https://www.gnu.org/licenses/>.
+ */
+
+package decomp_test
+
+import (
+	"bytes"
+	"io"
+	"log"
+	"math/rand"
+	"os"
+	"testing"
+
+	"github.com/Kagamiin/fools2024-solutions/challenge-1/cmd/decomp"
+)
+
+func Test_StepperMatchesApply(t *testing.T) {
+	log.SetOutput(io.Discard)
+	defer log.SetOutput(os.Stderr)
+
+	rng := rand.New(rand.NewSource(1))
+	stream, err := decomp.CompressSprite(randomPlane(rng, 5 * 6 * 8), randomPlane(rng, 5 * 6 * 8), 5, 6)
+	if err != nil {
+		t.Fatal(err)
+	}
+	memSpace := make([]byte, 65536)
+	rng.Read(memSpace)
+	copy(memSpace[streamAddr:], stream)
+	original := make([]byte, len(memSpace))
+	copy(original, memSpace)
+
+	recording := decomp.RecordDecompressSprite(memSpace, streamAddr, 8, 8)
+	stepper := recording.NewStepper(memSpace)
+	recording.ApplyRecording(&memSpace)
+
+	stepper.Seek(stepper.Len())
+	if !bytes.Equal(stepper.Memory(), memSpace) {
+		t.Error("stepping through the whole journal differs from applying it")
+	}
+
+	stepper.Seek(0)
+	if !bytes.Equal(stepper.Memory(), original) {
+		t.Error("stepping back through the whole journal does not restore the original memory")
+	}
+
+	index := recording.FindTouching(0xa400, 0)
+	if index < 0 || !recording.Operations[index].Touches(0xa400) {
+		t.Fatalf("expected to find an operation touching 0xa400, got %d", index)
+	}
+	for i := 0; i < index; i++ {
+		if recording.Operations[i].Touches(0xa400) {
+			t.Errorf("operation %d touches 0xa400 before the one found", i)
+		}
+	}
+}
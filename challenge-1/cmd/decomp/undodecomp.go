@@ -19,33 +19,158 @@
 package decomp
 
 import (
+	"fmt"
 	"log"
 )
 
+// An UndoConflict is a place where the memory being undone contradicts the
+// journal, such as bits a Fill should have cleared being set, or a copy
+// whose source no longer matches its destination. Conflicting bits end up in
+// the unknown bit map.
+type UndoConflict struct {
+	Index int
+	Operation Operation
+	Addr uint16
+	Bits uint8
+	Reason string
+}
+
+func (c UndoConflict) String() string {
+	return fmt.Sprintf("#%d %v (%v): 0x%04x bits %08b: %s", c.Index, c.Operation.T, c.Operation.Phase, c.Addr, c.Bits, c.Reason)
+}
+
+// undoTracker walks a journal backwards, keeping track of which bits of memory
+// are still determined by the data being undone.
+type undoTracker struct {
+	memory []byte
+	unknown []byte
+	index int
+	operation Operation
+	conflicts []UndoConflict
+}
+
 func (r *RecordedDecompression) UndoRecording(destMemory *[]byte) (unknownBitMap *[]byte) {
+	unknownBitMap, conflicts := r.UndoRecordingWithConflicts(destMemory)
+	if len(conflicts) > 0 {
+		log.Printf("Found %d conflicts between the journal and the data being undone\n", len(conflicts))
+	}
+	return unknownBitMap
+}
+
+// UndoRecordingWithConflicts undoes the journal like UndoRecording, and also
+// returns every conflict found along the way.
+func (r *RecordedDecompression) UndoRecordingWithConflicts(destMemory *[]byte) (unknownBitMap *[]byte, conflicts []UndoConflict) {
 	data := make([]byte, 65536)
 	unknownBitMap = &data
 	
 	log.Println("Undoing recorded decompression journal over saved data...")
 
+	tracker := undoTracker{
+		memory: *destMemory,
+		unknown: data,
+		conflicts: make([]UndoConflict, 0),
+	}
 	for i := len(r.Operations) - 1; i >= 0; i-- {
-		operation := r.Operations[i]
-		switch(operation.T) {
-		case Fill:
-			operation.MarkFill(unknownBitMap)
-		case Or:
-			operation.MarkOr(unknownBitMap)
-			operation.UndoOr(destMemory)
-		case DCopy:
-			operation.UndoDataCopy(destMemory)
-		case DXor:
-			operation.UndoDataXor(destMemory)
-		case DeltaDec:
-			operation.UndoDeltaDecode(destMemory)
-		}
+		tracker.index = i
+		tracker.operation = r.Operations[i]
+		tracker.undo()
 	}
 	
-	return
+	return unknownBitMap, tracker.conflicts
+}
+
+func (t *undoTracker) conflict(addr uint16, bits uint8, reason string) {
+	if bits == 0 {
+		return
+	}
+	t.conflicts = append(t.conflicts, UndoConflict{
+		Index: t.index,
+		Operation: t.operation,
+		Addr: addr,
+		Bits: bits,
+		Reason: reason,
+	})
+}
+
+// known returns the bits of the byte at addr that are determined.
+func (t *undoTracker) known(addr uint16) uint8 {
+	return ^t.unknown[addr]
+}
+
+func (t *undoTracker) undo() {
+	o := t.operation
+	dest := o.DestAddr
+	switch o.T {
+	case Fill:
+		t.conflict(dest, (t.memory[dest] ^ (o.Value & o.Mask)) & t.known(dest), "doesn't hold the filled value")
+		// the whole byte is written, whatever the mask
+		t.unknown[dest] = 0xff
+	case Or:
+		imprint := o.Value & o.Mask
+		t.conflict(dest, imprint & ^t.memory[dest] & t.known(dest), "imprinted bits are clear")
+		t.memory[dest] &= ^imprint
+		t.unknown[dest] |= imprint
+	case DCopy:
+		t.conflict(dest, t.memory[dest] & ^o.Mask & t.known(dest), "bits outside of the copy mask are set")
+		if o.SourceAddr == dest {
+			t.unknown[dest] |= ^o.Mask
+			return
+		}
+		value, known := t.memory[dest], t.known(dest) & o.Mask
+		t.unknown[dest] = 0xff
+		t.restoreSource(value, known)
+	case DXor:
+		if o.SourceAddr == dest {
+			t.conflict(dest, t.memory[dest] & o.Mask & t.known(dest), "bits XORed with themselves are set")
+			t.unknown[dest] |= o.Mask
+			return
+		}
+		o.UndoDataXor(&t.memory)
+		t.unknown[dest] |= t.unknown[o.SourceAddr] & o.Mask
+	case DeltaDec:
+		// every bit is undone from itself and the next lower one, and the
+		// lowest bit of the previous byte for the highest one
+		unknown := t.unknown[dest]
+		priorUnknown := unknown | unknown >> 1
+		if o.Value != 0 && o.SourceAddr == dest {
+			priorUnknown |= (priorUnknown & 1) << 7
+		} else if o.Value != 0 {
+			priorUnknown |= (t.unknown[o.SourceAddr] & 1) << 7
+		}
+		o.UndoDeltaDecode(&t.memory)
+		t.unknown[dest] = priorUnknown
+	case Scale:
+		value, known := t.memory[dest], t.known(dest)
+		var nybble, nybbleKnown uint8
+		for bit := 0; bit < 4; bit++ {
+			pair := uint8(3) << (bit * 2)
+			pairValue := value & pair
+			switch {
+			case known & pair == pair && pairValue != 0 && pairValue != pair:
+				t.conflict(dest, pair, "scaled bits differ")
+			case known & pair != 0:
+				nybbleKnown |= 1 << bit
+				if pairValue & known != 0 {
+					nybble |= 1 << bit
+				}
+			}
+		}
+		t.unknown[dest] = 0xff
+		t.restoreSource(nybble << o.Value, nybbleKnown << o.Value)
+	}
+}
+
+// restoreSource merges the value the source of the current operation must have
+// held into it. Bits only known from the destination become known, and known
+// bits that disagree with it become unknown.
+func (t *undoTracker) restoreSource(value, known uint8) {
+	source := t.operation.SourceAddr
+	sourceKnown := t.known(source)
+	conflicting := known & sourceKnown & (t.memory[source] ^ value)
+	t.conflict(source, conflicting, "source doesn't match what was copied from it")
+	restored := known & ^sourceKnown
+	t.memory[source] = (t.memory[source] & ^restored) | (value & restored)
+	t.unknown[source] = (t.unknown[source] & ^restored) | conflicting
 }
 
 func (o Operation) MarkOr(unknownBitMap *[]byte) {
@@ -68,9 +193,15 @@ func (o Operation) UndoDataXor(destMemory *[]byte) {
 	(*destMemory)[o.DestAddr] = (((*destMemory)[o.SourceAddr] ^ (*destMemory)[o.DestAddr]) & o.Mask) | ((*destMemory)[o.DestAddr] & ^o.Mask)
 }
 
+// UndoScale recovers the scaled nybble of the source from the destination.
+func (o Operation) UndoScale(destMemory *[]byte) {
+	nybbleMask := uint8(0x0f) << o.Value
+	source := (*destMemory)[o.SourceAddr] & ^nybbleMask
+	(*destMemory)[o.SourceAddr] = source | halveBits((*destMemory)[o.DestAddr]) << o.Value
+}
+
 func (o Operation) UndoDeltaDecode(destMemory *[]byte) {
 	originalVal := (*destMemory)[o.DestAddr]
-	valInPrevPosition := (*destMemory)[o.SourceAddr]
 	(*destMemory)[o.DestAddr] = 0
 	for bit := 0; bit < 8; bit++ {
 		if bit < 7 {
@@ -80,6 +211,9 @@ func (o Operation) UndoDeltaDecode(destMemory *[]byte) {
 				(*destMemory)[o.DestAddr] |= (1 << bit)
 			}
 		} else if o.Value != 0 {
+			// with a height of 0, every column of a row is the same byte,
+			// whose lowest bit is undone by the time the highest one is
+			valInPrevPosition := (*destMemory)[o.SourceAddr]
 			if ((originalVal & (1 << bit)) != 0 && (valInPrevPosition & 1) == 0) ||
 				((originalVal & (1 << bit)) == 0 && (valInPrevPosition & 1) != 0) {
 				
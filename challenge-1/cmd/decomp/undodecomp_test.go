@@ -0,0 +1,114 @@
+/*
+ * fools2024-solutions: source code for Kagamiin's solutions for TheZZAZZGlitch April Fools Event 2024's Security Testing Program.
+ * Copyright (C) 2024 Kagamiin~
+ * 
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License,
+ * or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program. If not, see <https://www.gnu.org/licenses/>.
+ */
+
+package decomp_test
+
+import (
+	"io"
+	"log"
+	"math/rand"
+	"os"
+	"testing"
+
+	"github.com/Kagamiin/fools2024-solutions/challenge-1/cmd/decomp"
+	"github.com/Kagamiin/fools2024-solutions/challenge-1/cmd/romdata"
+)
+
+func Test_UndoKnownBitsMatch(t *testing.T) {
+	log.SetOutput(io.Discard)
+	defer log.SetOutput(os.Stderr)
+
+	rng := rand.New(rand.NewSource(4))
+	for i := 0; i < 20; i++ {
+		widthTiles := rng.Intn(7) + 1
+		heightTiles := rng.Intn(7) + 1
+		plane1 := randomPlane(rng, widthTiles * heightTiles * 8)
+		plane2 := randomPlane(rng, widthTiles * heightTiles * 8)
+		stream, err := decomp.CompressSprite(plane1, plane2, widthTiles, heightTiles)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		original := make([]byte, 65536)
+		rng.Read(original)
+		copy(original[streamAddr:], stream)
+		// oversized base data dimensions make copy/align run over its own input
+		recording := decomp.RecordDecompressSprite(original, streamAddr, rng.Intn(16), rng.Intn(16))
+		if i % 4 == 0 {
+			recording, err = decomp.TryRecordDecompressBackSprite(romdata.DefaultProfile, original, streamAddr)
+			if err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		memSpace := make([]byte, 65536)
+		copy(memSpace, original)
+		recording.ApplyRecording(&memSpace)
+		unknownBitMap, conflicts := recording.UndoRecordingWithConflicts(&memSpace)
+		if len(conflicts) > 0 {
+			t.Fatalf("unexpected conflicts, first one: %v", conflicts[0])
+		}
+		for addr := range memSpace {
+			if wrong := (memSpace[addr] ^ original[addr]) & ^(*unknownBitMap)[addr]; wrong != 0 {
+				t.Fatalf("0x%04x: bits %08b are known but wrong", addr, wrong)
+			}
+		}
+	}
+}
+
+func Test_UndoConflicts(t *testing.T) {
+	log.SetOutput(io.Discard)
+	defer log.SetOutput(os.Stderr)
+
+	recording := decomp.RecordedDecompression{
+		Operations: []decomp.Operation{
+			{T: decomp.DCopy, DestAddr: 0x20, SourceAddr: 0x10, Mask: 0xff},
+			{T: decomp.Fill, DestAddr: 0x10, Mask: 0xff},
+			{T: decomp.Fill, DestAddr: 0x20, Mask: 0xff},
+			{T: decomp.DCopy, DestAddr: 0x40, SourceAddr: 0x30, Mask: 0xff},
+			{T: decomp.Or, DestAddr: 0x50, Mask: 0xf0, Value: 0xff},
+		},
+	}
+	memory := make([]byte, 65536)
+	memory[0x10] = 0x01
+	memory[0x30] = 0x0f
+	memory[0x40] = 0x3c
+	memory[0x50] = 0x70
+
+	unknownBitMap, conflicts := recording.UndoRecordingWithConflicts(&memory)
+	expected := []decomp.UndoConflict{
+		{Index: 4, Addr: 0x50, Bits: 0x80},
+		{Index: 3, Addr: 0x30, Bits: 0x33},
+		{Index: 1, Addr: 0x10, Bits: 0x01},
+	}
+	if len(conflicts) != len(expected) {
+		t.Fatalf("expected %d conflicts, got %v", len(expected), conflicts)
+	}
+	for i, conflict := range conflicts {
+		if conflict.Index != expected[i].Index || conflict.Addr != expected[i].Addr || conflict.Bits != expected[i].Bits {
+			t.Errorf("expected conflict #%d at 0x%04x bits %08b, got %v", expected[i].Index, expected[i].Addr, expected[i].Bits, conflict)
+		}
+	}
+
+	expectedUnknown := map[uint16]uint8{0x10: 0xff, 0x20: 0xff, 0x30: 0x33, 0x40: 0xff, 0x50: 0xf0}
+	for addr, bits := range expectedUnknown {
+		if (*unknownBitMap)[addr] != bits {
+			t.Errorf("0x%04x: expected unknown bits %08b, got %08b", addr, bits, (*unknownBitMap)[addr])
+		}
+	}
+}
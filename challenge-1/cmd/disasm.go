@@ -0,0 +1,135 @@
+/*
+ * fools2024-solutions: source code for Kagamiin's solutions for TheZZAZZGlitch April Fools Event 2024's Security Testing Program.
+ * Copyright (C) 2024 Kagamiin~
+ * 
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License,
+ * or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program. If not, see <https://www.gnu.org/licenses/>.
+ */
+
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/Kagamiin/fools2024-solutions/challenge-1/cmd/sm83"
+)
+
+func disassemble() {
+	flags := flag.NewFlagSet("disasm", flag.ExitOnError)
+	unknownPath := flags.String("unknown", "", "bit map of the unknown bits of the memory image, to mark them and list the decodings they allow")
+	symPath := flags.String("sym", "", "pokered .sym file to label addresses with")
+	romBankArg := flags.String("rom-bank", "1", "ROM bank (hex) to look labels in 0x4000-0x7fff up in")
+	sramBankArg := flags.String("sram-bank", "0", "SRAM bank (hex) to look labels in 0xa000-0xbfff up in")
+	maxAlternatives := flags.Int("max-alternatives", 16, "list the other decodings of an opcode only if there are at most this many")
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), `usage: 
+	%v disasm [options] result.bin addr[-addr]
+
+result.bin: memory image, such as the one recovered by undo
+addr: address or inclusive address range to disassemble, in hex (e.g. a7d0-a7ff)
+
+Disassembles SM83 code from the memory image. Bytes with unknown bits show
+them as ? in the nybbles they're in, and when the unknown bits of an opcode
+allow other instructions, these are listed under it.
+
+options:
+`, os.Args[0])
+		flags.PrintDefaults()
+	}
+	handle(flags.Parse(os.Args[2:]))
+	
+	if flags.NArg() != 2 {
+		flags.Usage()
+		os.Exit(1)
+	}
+	
+	memSpace, err := loadMemImage(flags.Arg(0))
+	handle(err)
+	start, end, err := parseAddrRange(flags.Arg(1))
+	handle(err)
+	
+	var unknown []byte
+	if *unknownPath != "" {
+		unknownBitMap, err := os.ReadFile(*unknownPath)
+		handle(err)
+		if len(unknownBitMap) != 65536 {
+			handle(fmt.Errorf("%s: expected a 65536 byte bit map, got %d bytes", *unknownPath, len(unknownBitMap)))
+		}
+		unknown = unknownBitMap[start:int(end) + 1]
+	}
+	
+	var labels sm83.Labeler
+	if *symPath != "" {
+		romBank, err := strconv.ParseUint(*romBankArg, 16, 8)
+		handle(err)
+		sramBank, err := strconv.ParseUint(*sramBankArg, 16, 8)
+		handle(err)
+		symFile, err := os.Open(*symPath)
+		handle(err)
+		symbols, err := sm83.ReadSymbols(symFile)
+		symFile.Close()
+		handle(err)
+		labels = symbols.At(uint8(romBank), uint8(sramBank))
+	}
+	
+	for _, line := range sm83.Disassemble(memSpace[start:int(end) + 1], unknown, start, labels) {
+		if labels != nil {
+			if label, ok := labels.Label(line.Addr); ok {
+				fmt.Printf("%s:\n", label)
+			}
+		}
+		fmt.Printf("%04x  %-10s %s", line.Addr, formatBytes(line.Bytes, line.Unknown), line.Text)
+		if line.HasUnknown() {
+			masks := make([]string, len(line.Unknown))
+			for i, mask := range line.Unknown {
+				masks[i] = fmt.Sprintf("%08b", mask)
+			}
+			fmt.Printf(" ; unknown bits %s", strings.Join(masks, " "))
+		}
+		if !line.Complete() {
+			fmt.Print(" ; cut off")
+		}
+		fmt.Println()
+		if len(line.Alternatives) > *maxAlternatives {
+			fmt.Printf("      %-10s or %d other decodings\n", "", len(line.Alternatives))
+			continue
+		}
+		for _, alternative := range line.Alternatives {
+			fmt.Printf("      %-10s or %s\n", formatBytes(alternative.Bytes, line.Unknown), alternative.Text)
+		}
+	}
+}
+
+// formatBytes shows bytes in hex, with ? for the nybbles holding unknown bits.
+func formatBytes(data []byte, unknown []uint8) string {
+	parts := make([]string, len(data))
+	for i, b := range data {
+		var mask uint8
+		if i < len(unknown) {
+			mask = unknown[i]
+		}
+		digits := []byte(fmt.Sprintf("%02x", b))
+		if mask & 0xf0 != 0 {
+			digits[0] = '?'
+		}
+		if mask & 0x0f != 0 {
+			digits[1] = '?'
+		}
+		parts[i] = string(digits)
+	}
+	return strings.Join(parts, " ")
+}
@@ -0,0 +1,103 @@
+/*
+ * fools2024-solutions: source code for Kagamiin's solutions for TheZZAZZGlitch April Fools Event 2024's Security Testing Program.
+ * Copyright (C) 2024 Kagamiin~
+ * 
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License,
+ * or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program. If not, see <https://www.gnu.org/licenses/>.
+ */
+
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/Kagamiin/fools2024-solutions/challenge-1/cmd/gen1sav"
+	"github.com/Kagamiin/fools2024-solutions/challenge-1/cmd/patch"
+)
+
+func exportSave() {
+	flags := flag.NewFlagSet("export", flag.ExitOnError)
+	outPath := flags.String("o", "recovered.sav", "path of the save file to write")
+	unknownPath := flags.String("unknown", "", "bit map of the unknown bits, over memory as written by undo or over the save file, to apply -fill to")
+	fillName := flags.String("fill", "keep", "what to put in place of unknown bits: keep (the recovered values), zero, one, or original (the bits of pokeblue.sav)")
+	ipsPath := flags.String("ips", "", "path of an IPS patch from pokeblue.sav to the exported save to write")
+	bpsPath := flags.String("bps", "", "path of a BPS patch from pokeblue.sav to the exported save to write")
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), `usage: 
+	%v export [options] pokeblue.sav result.bin
+
+pokeblue.sav: save file the data was recovered from
+result.bin: recovered memory image, as written by undo, whose SRAM replaces
+            bank 0 of the save file
+
+Rebuilds a save file from the recovered SRAM and the other banks of
+pokeblue.sav, and recomputes its checksums so that the game accepts it.
+Generates the following files in the current directory:
+- recovered.sav: contains the rebuilt save file (unless changed with -o)
+
+options:
+`, os.Args[0])
+		flags.PrintDefaults()
+	}
+	handle(flags.Parse(os.Args[2:]))
+	
+	if flags.NArg() != 2 {
+		flags.Usage()
+		os.Exit(1)
+	}
+	
+	policy, err := gen1sav.ParseFillPolicy(*fillName)
+	handle(err)
+	
+	original, err := readSavFile(flags.Arg(0))
+	handle(err)
+	memSpace, err := loadMemImage(flags.Arg(1))
+	handle(err)
+	
+	savData := make([]byte, gen1sav.SaveSize)
+	copy(savData, original)
+	copy(savData, memSpace[0xa000:0xc000])
+	
+	if *unknownPath != "" {
+		unknownBitMap, err := loadSaveBitMap(*unknownPath)
+		handle(err)
+		gen1sav.FillUnknown(savData, unknownBitMap, original, policy)
+	} else if policy != gen1sav.FillKeep {
+		log.Println("Warning: -fill has no effect without -unknown")
+	}
+	
+	for _, region := range gen1sav.ChecksumRegions() {
+		if !region.Valid(savData) {
+			log.Printf("Fixing the %s checksum\n", region.Name)
+		}
+	}
+	gen1sav.FixChecksums(savData)
+	
+	err = dumpBin(*outPath, &savData)
+	handle(err)
+	
+	if *ipsPath != "" {
+		ips, err := patch.IPS(original, savData)
+		handle(err)
+		handle(dumpBin(*ipsPath, &ips))
+	}
+	if *bpsPath != "" {
+		bps := patch.BPS(original, savData)
+		handle(dumpBin(*bpsPath, &bps))
+	}
+	
+	log.Println("Done.")
+}
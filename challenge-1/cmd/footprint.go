@@ -0,0 +1,118 @@
+/*
+ * fools2024-solutions: source code for Kagamiin's solutions for TheZZAZZGlitch April Fools Event 2024's Security Testing Program.
+ * Copyright (C) 2024 Kagamiin~
+ * 
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License,
+ * or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program. If not, see <https://www.gnu.org/licenses/>.
+ */
+
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+
+	"github.com/Kagamiin/fools2024-solutions/challenge-1/cmd/decomp"
+)
+
+func analyzeFootprint() {
+	flags := flag.NewFlagSet("footprint", flag.ExitOnError)
+	journalPath := flags.String("j", "", "analyze the given journal instead of recording a new one")
+	checkRange := flags.String("check", "", "address or inclusive address range (e.g. a7d0-a7ff) to report damage for")
+	verbose := flags.Bool("v", false, "log the decompression steps")
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), `usage: 
+	%v footprint [options] pokeblue.sav bank addr [width height]
+	%v footprint [options] -j journal
+
+pokeblue.sav: save file containing the source data where the sprite will be decompressed.
+bank: ROM bank where the sprite decompression is performed
+addr: pointer to the sprite
+width: width of the sprite in its base data, in tiles (omit to use the width from the sprite data)
+height: height of the sprite in its base data, in tiles (omit to use the height from the sprite data)
+
+Reports which memory regions the decompression journal writes to, and which
+address ranges are lost, imprinted or damaged but recoverable.
+
+options:
+`, os.Args[0], os.Args[0])
+		flags.PrintDefaults()
+	}
+	handle(flags.Parse(os.Args[2:]))
+	
+	var recording *decomp.RecordedDecompression
+	var err error
+	if *journalPath != "" {
+		if flags.NArg() != 0 {
+			flags.Usage()
+			os.Exit(1)
+		}
+		recording, err = readJournalFile(*journalPath)
+		handle(err)
+	} else {
+		if flags.NArg() != 3 && flags.NArg() != 5 {
+			flags.Usage()
+			os.Exit(1)
+		}
+		if !*verbose {
+			log.SetOutput(io.Discard)
+		}
+		recording = recordFromArgs(flags.Args())
+		log.SetOutput(os.Stderr)
+	}
+	
+	footprint := recording.Footprint()
+	
+	fmt.Printf("%d operations: %v\n", len(recording.Operations), footprint.OpCounts)
+	for _, region := range footprint.Regions {
+		fmt.Printf("\n%s (0x%04x-0x%04x): %v\n", region.Region.Name, region.Region.Start, region.Region.End, region.OpCounts)
+		for _, damage := range []decomp.Damage{decomp.Lost, decomp.Imprinted, decomp.Recoverable} {
+			ranges := region.Ranges[damage]
+			if len(ranges) == 0 {
+				continue
+			}
+			fmt.Printf("  %s:\n", damage)
+			printAddrRanges(ranges)
+		}
+	}
+	
+	if *checkRange != "" {
+		start, end, err := parseAddrRange(*checkRange)
+		handle(err)
+		
+		ranges := footprint.DamagedRanges(start, end)
+		fmt.Printf("\n0x%04x-0x%04x: ", start, end)
+		if len(ranges) == 0 {
+			fmt.Println("untouched")
+			return
+		}
+		fmt.Println("damaged")
+		for _, addrRange := range ranges {
+			fmt.Printf("  %v: %v\n", addrRange, footprint.ByteDamage[addrRange.Start])
+		}
+	}
+}
+
+func printAddrRanges(ranges []decomp.AddrRange) {
+	const perLine = 6
+	for i := 0; i < len(ranges); i += perLine {
+		fmt.Print("   ")
+		for _, addrRange := range ranges[i:min(i + perLine, len(ranges))] {
+			fmt.Printf(" %v", addrRange)
+		}
+		fmt.Println()
+	}
+}
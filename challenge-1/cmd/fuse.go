@@ -0,0 +1,112 @@
+/*
+ * fools2024-solutions: source code for Kagamiin's solutions for TheZZAZZGlitch April Fools Event 2024's Security Testing Program.
+ * Copyright (C) 2024 Kagamiin~
+ * 
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License,
+ * or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program. If not, see <https://www.gnu.org/licenses/>.
+ */
+
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/Kagamiin/fools2024-solutions/challenge-1/cmd/decomp"
+)
+
+func fuseSnapshots() {
+	flags := flag.NewFlagSet("fuse", flag.ExitOnError)
+	outPath := flags.String("o", "result.bin", "path of the fused memory image to write")
+	unknownPath := flags.String("unknown", "unknownbits.bin", "path of the unknown bit map to write")
+	reportPath := flags.String("disagreements", "", "path of a report of every byte where snapshots disagree to write (default: only log how many there are)")
+	strict := flags.Bool("strict", false, "make bits the snapshots disagree on unknown, instead of taking them from the first snapshot that knows them")
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), `usage: 
+	%v fuse [options] memory[:journal] memory[:journal]...
+
+memory: 64 KiB memory image, or 32 KiB save file, of the same game
+journal: decompression journal to undo over the memory image first; snapshots
+         without one are taken as clean, with every bit known
+
+Undoes each journal over its memory image, then takes every bit from the first
+snapshot, in the order given, that still knows it.
+Generates the following files in the current directory:
+- result.bin: contains the fused data (unless changed with -o)
+- unknownbits.bin: contains a bitmap of the bits no snapshot knows (unless changed with -unknown)
+
+options:
+`, os.Args[0])
+		flags.PrintDefaults()
+	}
+	handle(flags.Parse(os.Args[2:]))
+	
+	if flags.NArg() < 1 {
+		flags.Usage()
+		os.Exit(1)
+	}
+	
+	snapshots := make([]decomp.Snapshot, 0, flags.NArg())
+	names := make([]string, 0, flags.NArg())
+	for i, arg := range flags.Args() {
+		memoryPath, journalPath, hasJournal := strings.Cut(arg, ":")
+		memSpace, err := loadMemImage(memoryPath)
+		handle(err)
+		
+		name := fmt.Sprintf("#%d", i + 1)
+		if !hasJournal {
+			log.Printf("Snapshot %s: %s, clean\n", name, memoryPath)
+			snapshots = append(snapshots, decomp.Snapshot{Name: name, Memory: memSpace})
+		} else {
+			log.Printf("Snapshot %s: %s, undoing %s\n", name, memoryPath, journalPath)
+			recording, err := readJournalFile(journalPath)
+			handle(err)
+			snapshots = append(snapshots, recording.UndoSnapshot(name, memSpace))
+		}
+		names = append(names, name)
+	}
+	
+	memSpace, unknownBitMap, disagreements, err := decomp.FuseSnapshots(snapshots, *strict)
+	handle(err)
+	
+	err = dumpBin(*outPath, &memSpace)
+	handle(err)
+	
+	err = dumpBin(*unknownPath, &unknownBitMap)
+	handle(err)
+	
+	if *reportPath != "" {
+		err = writeDisagreements(*reportPath, disagreements, names)
+		handle(err)
+	}
+	
+	log.Println("Done.")
+}
+
+func writeDisagreements(path string, disagreements []decomp.Disagreement, names []string) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	
+	writer := bufio.NewWriter(file)
+	for _, disagreement := range disagreements {
+		fmt.Fprintln(writer, disagreement.Format(names))
+	}
+	return writer.Flush()
+}
@@ -0,0 +1,212 @@
+/*
+ * fools2024-solutions: source code for Kagamiin's solutions for TheZZAZZGlitch April Fools Event 2024's Security Testing Program.
+ * Copyright (C) 2024 Kagamiin~
+ * 
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License,
+ * or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program. If not, see <https://www.gnu.org/licenses/>.
+ */
+
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"sync"
+
+	"github.com/Kagamiin/fools2024-solutions/challenge-1/cmd/romdata"
+	"github.com/Kagamiin/fools2024-solutions/challenge-1/cmd/savestate"
+)
+
+// The ROM and RAM dump must be provided separately, and are loaded on first use.
+// An emulator savestate can stand in for the RAM dump.
+var pokéRom []byte
+var pokéRam []byte
+var loadGameDataOnce sync.Once
+
+// Settings from the global options.
+var profileName string
+var romPath string
+var ramPath string
+var statePath string
+var forceMismatch bool
+var bankedReads bool
+var backSprites bool
+var encounterWrites bool
+
+// The known dump the ROM was identified as, if any.
+var knownRom *romdata.KnownDump
+
+// extractGlobalArgs removes the global options from the arguments, wherever
+// they appear, and records their values.
+func extractGlobalArgs(args []string) []string {
+	valueArgs := map[string]*string{
+		"--profile": &profileName,
+		"--rom": &romPath,
+		"--ram": &ramPath,
+		"--state": &statePath,
+	}
+	result := make([]string, 0, len(args))
+	for i := 0; i < len(args); i++ {
+		if value, ok := valueArgs[args[i]]; ok && i + 1 < len(args) {
+			*value = args[i + 1]
+			i++
+			continue
+		}
+		if args[i] == "--force" {
+			forceMismatch = true
+			continue
+		}
+		if args[i] == "--banked" {
+			bankedReads = true
+			continue
+		}
+		if args[i] == "--back" {
+			backSprites = true
+			continue
+		}
+		if args[i] == "--encounter" {
+			encounterWrites = true
+			continue
+		}
+		result = append(result, args[i])
+	}
+	return result
+}
+
+func pathSetting(value, envVar, defaultPath string) string {
+	if value != "" {
+		return value
+	}
+	if env := os.Getenv(envVar); env != "" {
+		return env
+	}
+	return defaultPath
+}
+
+// refuse fails with the given error, unless --force was given, in which case
+// it's only logged.
+func refuse(err error) {
+	if forceMismatch {
+		log.Printf("Warning: %v; continuing anyway\n", err)
+		return
+	}
+	handle(fmt.Errorf("%w (use --force to run anyway)", err))
+}
+
+func loadGameData() {
+	loadGameDataOnce.Do(func() {
+		path := pathSetting(romPath, "POKEBLUE_ROM", "pokeblue.gb")
+		rom, err := os.ReadFile(path)
+		handle(err)
+		if len(rom) < 0x8000 || len(rom) % 0x4000 != 0 {
+			handle(fmt.Errorf("%s: expected a ROM made of 16 KiB banks, got %d bytes", path, len(rom)))
+		}
+
+		header, err := romdata.ParseHeader(rom)
+		handle(err)
+		log.Printf("ROM %s: %v\n", path, header)
+		if checksum := romdata.HeaderChecksum(rom); checksum != header.HeaderChecksum {
+			refuse(fmt.Errorf("%s: header checksum is 0x%02x, expected 0x%02x", path, checksum, header.HeaderChecksum))
+		}
+		if checksum := romdata.GlobalChecksum(rom); checksum != header.GlobalChecksum {
+			log.Printf("Warning: %s: global checksum is 0x%04x, expected 0x%04x; the dump may be bad or modified\n",
+			           path, checksum, header.GlobalChecksum)
+		}
+		knownRom = romdata.IdentifyDump(romdata.KnownRoms, rom)
+		if knownRom != nil {
+			log.Printf("ROM identified as %s\n", knownRom.Description)
+		} else {
+			log.Printf("ROM is not a known dump\n")
+		}
+
+		var ram []byte
+		if path = pathSetting(statePath, "POKEBLUE_STATE", ""); path != "" {
+			ram = loadState(path, header)
+		} else {
+			ram = loadRamDump(pathSetting(ramPath, "POKEBLUE_RAM", "pokeblue-ram.dmp"))
+		}
+
+		pokéRom = rom
+		pokéRam = ram
+	})
+}
+
+func loadRamDump(path string) []byte {
+	ram, err := os.ReadFile(path)
+	handle(err)
+	if len(ram) != 65536 {
+		handle(fmt.Errorf("%s: expected a 65536 byte RAM dump, got %d bytes", path, len(ram)))
+	}
+	knownRam := romdata.IdentifyDump(romdata.KnownRamDumps, ram)
+	if knownRam != nil {
+		log.Printf("RAM dump identified as %s\n", knownRam.Description)
+		if knownRom != nil && knownRam.Profile != knownRom.Profile {
+			refuse(fmt.Errorf("RAM dump %s was taken from %s, but the ROM is %s", path, knownRam.Profile, knownRom.Profile))
+		}
+	}
+	return ram
+}
+
+// loadState lays out an emulator savestate as a RAM dump.
+func loadState(path string, header *romdata.CartHeader) []byte {
+	state, err := savestate.ReadFile(path)
+	handle(err)
+	log.Printf("Savestate %s: %s format, WRAM bank %d\n", path, state.Format, state.WRAMBank)
+	if state.MBC != nil {
+		log.Printf("Savestate %s: ROM bank 0x%02x, SRAM bank %d, SRAM enabled: %v\n",
+		           path, state.MBC.ROMBank(), state.MBC.SRAMBank(), state.MBC.RAMEnable)
+	}
+	if state.Title != "" && state.Title != header.Title {
+		refuse(fmt.Errorf("savestate %s was made with %q, but the ROM title is %q", path, state.Title, header.Title))
+	}
+	return state.MemImage()
+}
+
+// loadProfile returns the profile selected with --profile, or the one the
+// ROM was identified as, completed for the ROM.
+func loadProfile() *romdata.Profile {
+	loadGameData()
+
+	var profile *romdata.Profile
+	var err error
+	switch {
+	case profileName != "":
+		profile, err = romdata.LookupProfile(profileName)
+		handle(err)
+		if knownRom != nil && knownRom.Profile != profile.Name {
+			refuse(fmt.Errorf("profile %s was selected, but the ROM is %s", profile.Name, knownRom.Profile))
+		}
+		header, err := romdata.ParseHeader(pokéRom)
+		handle(err)
+		if !profile.Matches(header) {
+			refuse(fmt.Errorf("profile %s was selected, but the ROM title is %q", profile.Name, header.Title))
+		}
+	case knownRom != nil:
+		profile, err = romdata.LookupProfile(knownRom.Profile)
+		handle(err)
+	default:
+		profile, err = romdata.DetectProfile(pokéRom)
+		if err != nil {
+			refuse(err)
+			profile = romdata.DefaultProfile
+		} else {
+			refuse(fmt.Errorf("ROM is not a known dump, and only its title matches profile %s", profile.Name))
+		}
+	}
+
+	profile, err = profile.Complete(pokéRom)
+	handle(err)
+	log.Printf("Using profile %s (%s)\n", profile.Name, profile.Description)
+	return profile
+}
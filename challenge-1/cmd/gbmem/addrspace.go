@@ -0,0 +1,119 @@
+/*
+ * fools2024-solutions: source code for Kagamiin's solutions for TheZZAZZGlitch April Fools Event 2024's Security Testing Program.
+ * Copyright (C) 2024 Kagamiin~
+ * 
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License,
+ * or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program. If not, see <https://www.gnu.org/licenses/>.
+ */
+
+package gbmem
+
+const (
+	ROMBankSize = 0x4000
+	SRAMBankSize = 0x2000
+)
+
+// AddressSpace models the address space of a DMG with an MBC1 cartridge, as
+// seen by the CPU:
+//   - writes to 0x0000-0x7fff go to the MBC1 registers instead of memory
+//   - cartridge RAM reads 0xff and ignores writes while disabled
+//   - 0xe000-0xfdff mirrors 0xc000-0xddff
+//   - 0xfea0-0xfeff reads 0x00 and ignores writes
+type AddressSpace struct {
+	ROM []byte
+	// SRAM holds all banks of cartridge RAM.
+	SRAM []byte
+	MBC MBC1
+	// ram backs VRAM, WRAM, OAM, I/O, HRAM and IE at their own addresses;
+	// the rest of it is unused.
+	ram [0x10000]byte
+}
+
+func NewAddressSpace(rom []byte, sram []byte) *AddressSpace {
+	return &AddressSpace{
+		ROM: rom,
+		SRAM: sram,
+		MBC: MBC1{Bank1: 1},
+	}
+}
+
+// LoadImage copies everything outside of the cartridge from a 64 KiB memory
+// image, such as a RAM dump.
+func (a *AddressSpace) LoadImage(image []byte) {
+	copy(a.ram[0x8000:0xa000], image[0x8000:0xa000])
+	copy(a.ram[0xc000:0xe000], image[0xc000:0xe000])
+	copy(a.ram[0xfe00:], image[0xfe00:])
+}
+
+// Image reads the whole address space as currently mapped.
+func (a *AddressSpace) Image() []byte {
+	image := make([]byte, 0x10000)
+	for addr := range image {
+		image[addr] = a.Read(uint16(addr))
+	}
+	return image
+}
+
+func (a *AddressSpace) romRead(bank int, offset uint16) uint8 {
+	banks := len(a.ROM) / ROMBankSize
+	if banks == 0 {
+		return 0xff
+	}
+	return a.ROM[(bank % banks) * ROMBankSize + int(offset)]
+}
+
+// sramOffset returns the offset in SRAM backing an address in 0xa000-0xbfff,
+// or false if cartridge RAM is disabled or absent.
+func (a *AddressSpace) sramOffset(addr uint16) (int, bool) {
+	banks := len(a.SRAM) / SRAMBankSize
+	if !a.MBC.RAMEnable || banks == 0 {
+		return 0, false
+	}
+	return (a.MBC.SRAMBank() % banks) * SRAMBankSize + int(addr - 0xa000), true
+}
+
+func (a *AddressSpace) Read(addr uint16) uint8 {
+	switch {
+	case addr < 0x4000:
+		return a.romRead(a.MBC.ROMBank0(), addr)
+	case addr < 0x8000:
+		return a.romRead(a.MBC.ROMBank(), addr - 0x4000)
+	case addr >= 0xa000 && addr < 0xc000:
+		if offset, ok := a.sramOffset(addr); ok {
+			return a.SRAM[offset]
+		}
+		return 0xff
+	case addr >= 0xe000 && addr < 0xfe00:
+		return a.ram[addr - 0x2000]
+	case addr >= 0xfea0 && addr < 0xff00:
+		return 0x00
+	}
+	return a.ram[addr]
+}
+
+func (a *AddressSpace) Write(addr uint16, value uint8) {
+	switch {
+	case addr < 0x8000:
+		a.MBC.Write(addr, value)
+	case addr >= 0xa000 && addr < 0xc000:
+		if offset, ok := a.sramOffset(addr); ok {
+			a.SRAM[offset] = value
+		}
+	case addr >= 0xe000 && addr < 0xfe00:
+		a.ram[addr - 0x2000] = value
+	case addr >= 0xfea0 && addr < 0xff00:
+		// unusable
+	default:
+		a.ram[addr] = value
+	}
+}
@@ -0,0 +1,116 @@
+/*
+ * fools2024-solutions: source code for Kagamiin's solutions for TheZZAZZGlitch April Fools Event 2024's Security Testing Program.
+ * Copyright (C) 2024 Kagamiin~
+ * 
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License,
+ * or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program. If not, see <https://www.gnu.org/licenses/>.
+ */
+
+package gbmem_test
+
+import (
+	"testing"
+
+	"github.com/Kagamiin/fools2024-solutions/challenge-1/cmd/decomp"
+	"github.com/Kagamiin/fools2024-solutions/challenge-1/cmd/gbmem"
+)
+
+// testRom returns a 64-bank ROM where every byte holds its bank number.
+func testRom() []byte {
+	rom := make([]byte, 64 * gbmem.ROMBankSize)
+	for i := range rom {
+		rom[i] = uint8(i / gbmem.ROMBankSize)
+	}
+	return rom
+}
+
+func Test_MBC1Banking(t *testing.T) {
+	space := gbmem.NewAddressSpace(testRom(), make([]byte, 4 * gbmem.SRAMBankSize))
+	expectRead := func(addr uint16, expected uint8) {
+		t.Helper()
+		if value := space.Read(addr); value != expected {
+			t.Errorf("read 0x%04x: expected 0x%02x, got 0x%02x", addr, expected, value)
+		}
+	}
+
+	expectRead(0x0000, 0)
+	expectRead(0x4000, 1)
+	space.Write(0x2000, 0x00)
+	expectRead(0x4000, 1)
+	space.Write(0x2100, 0x2c)
+	expectRead(0x7fff, 0x0c)
+	space.Write(0x4000, 0x01)
+	expectRead(0x4000, 0x2c)
+	expectRead(0x0000, 0)
+	// mode 1 also switches the lower ROM bank and the SRAM bank
+	space.Write(0x6000, 0x01)
+	expectRead(0x0000, 0x20)
+
+	// SRAM is disabled until 0x0a is written to 0x0000-0x1fff
+	space.Write(0xa000, 0x12)
+	expectRead(0xa000, 0xff)
+	space.Write(0x1fff, 0x0a)
+	space.Write(0xa000, 0x12)
+	expectRead(0xa000, 0x12)
+	if space.SRAM[gbmem.SRAMBankSize] != 0x12 {
+		t.Errorf("expected the write to land in SRAM bank 1")
+	}
+	space.Write(0x6000, 0x00)
+	expectRead(0xa000, 0x00)
+	space.Write(0x0000, 0x00)
+	expectRead(0xa000, 0xff)
+
+	// echo RAM
+	space.Write(0xe123, 0x34)
+	expectRead(0xc123, 0x34)
+	space.Write(0xd000, 0x56)
+	expectRead(0xf000, 0x56)
+	// unusable area
+	space.Write(0xfea0, 0x78)
+	expectRead(0xfea0, 0x00)
+	// ROM space is never written to
+	if space.ROM[0] != 0 {
+		t.Errorf("ROM was modified")
+	}
+}
+
+func Test_ReplayOnAddressSpace(t *testing.T) {
+	recording := &decomp.RecordedDecompression{
+		Operations: []decomp.Operation{
+			// wraps around into ROM space and selects bank 5
+			{T: decomp.Fill, DestAddr: 0x2000, Mask: 0xff, Value: 0x05},
+			{T: decomp.DCopy, DestAddr: 0xc000, SourceAddr: 0x4000, Mask: 0xff},
+			{T: decomp.Fill, DestAddr: 0xe001, Mask: 0xff, Value: 0x77},
+			// disables SRAM
+			{T: decomp.Fill, DestAddr: 0x0000, Mask: 0xff, Value: 0x00},
+			{T: decomp.DCopy, DestAddr: 0xc002, SourceAddr: 0xa000, Mask: 0xff},
+		},
+	}
+	space := gbmem.NewAddressSpace(testRom(), make([]byte, gbmem.SRAMBankSize))
+	space.Write(0x0000, 0x0a)
+	recording.ReplayRecording(space)
+
+	image := space.Image()
+	expected := map[uint16]uint8{0xc000: 0x05, 0xc001: 0x77, 0xc002: 0xff, 0x4000: 0x05, 0x2000: 0x00}
+	for addr, value := range expected {
+		if image[addr] != value {
+			t.Errorf("0x%04x: expected 0x%02x, got 0x%02x", addr, value, image[addr])
+		}
+	}
+
+	flat := make([]byte, 65536)
+	recording.ApplyRecording(&flat)
+	if flat[0x2000] != 0x05 || flat[0xc001] != 0x00 || flat[0xe001] != 0x77 {
+		t.Errorf("flat replay should write memory directly")
+	}
+}
@@ -0,0 +1,67 @@
+/*
+ * fools2024-solutions: source code for Kagamiin's solutions for TheZZAZZGlitch April Fools Event 2024's Security Testing Program.
+ * Copyright (C) 2024 Kagamiin~
+ * 
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License,
+ * or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program. If not, see <https://www.gnu.org/licenses/>.
+ */
+
+package gbmem
+
+// MBC1 holds the MBC1 registers, as last written by the game.
+type MBC1 struct {
+	RAMEnable bool
+	// Bank1 is the 5-bit ROM bank register at 0x2000-0x3fff. Writing 0 to it
+	// selects bank 1.
+	Bank1 uint8
+	// Bank2 is the 2-bit register at 0x4000-0x5fff, selecting either the
+	// upper ROM bank bits or the SRAM bank depending on Mode.
+	Bank2 uint8
+	Mode uint8
+}
+
+// Write updates the registers for a write to ROM space.
+func (m *MBC1) Write(addr uint16, value uint8) {
+	switch {
+	case addr < 0x2000:
+		m.RAMEnable = value & 0x0f == 0x0a
+	case addr < 0x4000:
+		m.Bank1 = value & 0x1f
+	case addr < 0x6000:
+		m.Bank2 = value & 0x03
+	case addr < 0x8000:
+		m.Mode = value & 0x01
+	}
+}
+
+// ROMBank returns the bank mapped at 0x4000-0x7fff.
+func (m *MBC1) ROMBank() int {
+	return int(m.Bank2) << 5 | int(max(m.Bank1, 1))
+}
+
+// ROMBank0 returns the bank mapped at 0x0000-0x3fff, which only differs from
+// bank 0 in mode 1 on ROMs of 1 MiB or more.
+func (m *MBC1) ROMBank0() int {
+	if m.Mode == 1 {
+		return int(m.Bank2) << 5
+	}
+	return 0
+}
+
+// SRAMBank returns the bank mapped at 0xa000-0xbfff.
+func (m *MBC1) SRAMBank() int {
+	if m.Mode == 1 {
+		return int(m.Bank2)
+	}
+	return 0
+}
@@ -0,0 +1,36 @@
+/*
+ * fools2024-solutions: source code for Kagamiin's solutions for TheZZAZZGlitch April Fools Event 2024's Security Testing Program.
+ * Copyright (C) 2024 Kagamiin~
+ * 
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License,
+ * or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program. If not, see <https://www.gnu.org/licenses/>.
+ */
+
+package gbmem
+
+// Memory is anything decompression operations can be replayed on.
+type Memory interface {
+	Read(addr uint16) uint8
+	Write(addr uint16, value uint8)
+}
+
+// Flat is a plain 64 KiB memory image, where every address is backed by RAM.
+type Flat []byte
+
+func (f Flat) Read(addr uint16) uint8 {
+	return f[addr]
+}
+
+func (f Flat) Write(addr uint16, value uint8) {
+	f[addr] = value
+}
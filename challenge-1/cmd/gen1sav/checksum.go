@@ -0,0 +1,84 @@
+/*
+ * fools2024-solutions: source code for Kagamiin's solutions for TheZZAZZGlitch April Fools Event 2024's Security Testing Program.
+ * Copyright (C) 2024 Kagamiin~
+ * 
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License,
+ * or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program. If not, see <https://www.gnu.org/licenses/>.
+ */
+
+package gen1sav
+
+import (
+	"fmt"
+)
+
+// SaveSize is the size of a save file, holding all 4 banks of SRAM.
+const SaveSize = 0x8000
+
+// A ChecksumRegion is a range of a save file covered by a checksum. The game
+// stores the complement of the 8-bit sum of the range, so that the sum of the
+// range and the checksum byte is always 0xff.
+type ChecksumRegion struct {
+	Name string
+	Start int
+	// End is exclusive.
+	End int
+	Checksum int
+}
+
+const (
+	mainDataStart = 0x2598
+	mainDataEnd = 0x3523
+	boxSize = 0x462
+	boxesPerBank = 6
+)
+
+// ChecksumRegions returns the regions checked by the English releases of Red
+// and Blue: the main data in bank 1, and every bank of boxes in banks 2 and 3
+// along with each box in them. Bank 0, which holds the Hall of Fame, isn't
+// covered by any checksum.
+func ChecksumRegions() []ChecksumRegion {
+	regions := []ChecksumRegion{
+		{"main data", mainDataStart, mainDataEnd, mainDataEnd},
+	}
+	for bank := 2; bank <= 3; bank++ {
+		bankStart := bank * 0x2000
+		boxesEnd := bankStart + boxesPerBank * boxSize
+		regions = append(regions, ChecksumRegion{fmt.Sprintf("bank %d boxes", bank), bankStart, boxesEnd, boxesEnd})
+		for i := 0; i < boxesPerBank; i++ {
+			box := (bank - 2) * boxesPerBank + i + 1
+			boxStart := bankStart + i * boxSize
+			regions = append(regions, ChecksumRegion{fmt.Sprintf("box %d", box), boxStart, boxStart + boxSize, boxesEnd + 1 + i})
+		}
+	}
+	return regions
+}
+
+// Compute returns the checksum the game would store for the region.
+func (c ChecksumRegion) Compute(sav []byte) uint8 {
+	var sum uint8
+	for _, b := range sav[c.Start:c.End] {
+		sum += b
+	}
+	return ^sum
+}
+
+func (c ChecksumRegion) Valid(sav []byte) bool {
+	return c.Compute(sav) == sav[c.Checksum]
+}
+
+// contains tells whether the checksum covers the byte at offset, including
+// the checksum byte itself.
+func (c ChecksumRegion) contains(offset int) bool {
+	return (offset >= c.Start && offset < c.End) || offset == c.Checksum
+}
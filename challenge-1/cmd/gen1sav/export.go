@@ -0,0 +1,71 @@
+/*
+ * fools2024-solutions: source code for Kagamiin's solutions for TheZZAZZGlitch April Fools Event 2024's Security Testing Program.
+ * Copyright (C) 2024 Kagamiin~
+ * 
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License,
+ * or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program. If not, see <https://www.gnu.org/licenses/>.
+ */
+
+package gen1sav
+
+import (
+	"fmt"
+)
+
+// FillPolicy is what to put in place of unknown bits when exporting a save.
+type FillPolicy int
+
+const (
+	// FillKeep leaves the best-effort values recovered for them.
+	FillKeep FillPolicy = iota
+	FillZero
+	FillOne
+	// FillOriginal takes them from the save the data was recovered from.
+	FillOriginal
+)
+
+var fillPolicyNames = map[string]FillPolicy{
+	"keep": FillKeep,
+	"zero": FillZero,
+	"one": FillOne,
+	"original": FillOriginal,
+}
+
+func ParseFillPolicy(name string) (FillPolicy, error) {
+	policy, ok := fillPolicyNames[name]
+	if !ok {
+		return FillKeep, fmt.Errorf("unknown fill policy %q (expected keep, zero, one or original)", name)
+	}
+	return policy, nil
+}
+
+// FillUnknown replaces the unknown bits of sav according to the policy.
+func FillUnknown(sav, unknown, original []byte, policy FillPolicy) {
+	for i, mask := range unknown {
+		switch policy {
+		case FillZero:
+			sav[i] &= ^mask
+		case FillOne:
+			sav[i] |= mask
+		case FillOriginal:
+			sav[i] = sav[i] & ^mask | original[i] & mask
+		}
+	}
+}
+
+// FixChecksums recomputes every checksum of the save.
+func FixChecksums(sav []byte) {
+	for _, region := range ChecksumRegions() {
+		sav[region.Checksum] = region.Compute(sav)
+	}
+}
@@ -0,0 +1,55 @@
+/*
+ * fools2024-solutions: source code for Kagamiin's solutions for TheZZAZZGlitch April Fools Event 2024's Security Testing Program.
+ * Copyright (C) 2024 Kagamiin~
+ * 
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License,
+ * or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program. If not, see <https://www.gnu.org/licenses/>.
+ */
+
+package gen1sav_test
+
+import (
+	"bytes"
+	"math/rand"
+	"testing"
+
+	"github.com/Kagamiin/fools2024-solutions/challenge-1/cmd/gen1sav"
+)
+
+func Test_FixChecksums(t *testing.T) {
+	rng := rand.New(rand.NewSource(21))
+	sav := make([]byte, gen1sav.SaveSize)
+	rng.Read(sav)
+	original := bytes.Clone(sav)
+
+	gen1sav.FixChecksums(sav)
+	regions := gen1sav.ChecksumRegions()
+	isChecksum := make(map[int]bool)
+	for _, region := range regions {
+		if !region.Valid(sav) {
+			t.Errorf("%s: checksum doesn't hold", region.Name)
+		}
+		isChecksum[region.Checksum] = true
+	}
+	for i := range sav {
+		if !isChecksum[i] && sav[i] != original[i] {
+			t.Fatalf("0x%04x: expected 0x%02x to be left alone, got 0x%02x", i, original[i], sav[i])
+		}
+	}
+
+	fixed := bytes.Clone(sav)
+	gen1sav.FixChecksums(sav)
+	if !bytes.Equal(sav, fixed) {
+		t.Error("fixing the checksums of a valid save changed it")
+	}
+}
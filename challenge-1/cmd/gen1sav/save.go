@@ -0,0 +1,373 @@
+/*
+ * fools2024-solutions: source code for Kagamiin's solutions for TheZZAZZGlitch April Fools Event 2024's Security Testing Program.
+ * Copyright (C) 2024 Kagamiin~
+ * 
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License,
+ * or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program. If not, see <https://www.gnu.org/licenses/>.
+ */
+
+package gen1sav
+
+import (
+	"fmt"
+)
+
+// Layout of the English releases of Red and Blue. Most of bank 1 is a copy of
+// WRAM, so offsets into it are given by the WRAM address they're saved from.
+const (
+	NameLength = 11
+	PartyCapacity = 6
+	BoxCapacity = 20
+	NumBoxes = 12
+	BagCapacity = 20
+	PCItemCapacity = 50
+	HallOfFameCapacity = 50
+	NumDexEntries = 151
+
+	partyMonSize = 0x2c
+	boxMonSize = 0x21
+	hofMonSize = 0x10
+	hofTeamSize = hofMonSize * PartyCapacity
+
+	playerNameOffset = 0x2598
+	hallOfFameOffset = 0x0598
+	partyDataOffset = 0x2f2c
+	curBoxDataOffset = 0x30c0
+
+	wMainDataStart = 0xd2f7
+	wPokedexOwned = 0xd2f7
+	wPokedexSeen = 0xd30a
+	wNumBagItems = 0xd31d
+	wPlayerMoney = 0xd347
+	wRivalName = 0xd34a
+	wOptions = 0xd355
+	wObtainedBadges = 0xd356
+	wPlayerID = 0xd359
+	wCurMap = 0xd35e
+	wYCoord = 0xd361
+	wXCoord = 0xd362
+	wNumBoxItems = 0xd53a
+	wCurrentBoxNum = 0xd5a0
+	wNumHoFTeams = 0xd5a2
+	wPlayerCoins = 0xd5a4
+	wPlayTimeHours = 0xda41
+	wPlayTimeMaxed = 0xda42
+	wPlayTimeMinutes = 0xda43
+	wPlayTimeSeconds = 0xda44
+)
+
+// mainData returns the offset in the save file of a WRAM address in the main
+// data, which is saved right after the player's name.
+func mainData(addr int) int {
+	return playerNameOffset + NameLength + addr - wMainDataStart
+}
+
+type Item struct {
+	ID uint8
+	Quantity uint8
+}
+
+// PartyStats holds the part of a Pokémon's data that is only kept while it's
+// in the party.
+type PartyStats struct {
+	Level uint8
+	MaxHP uint16
+	Attack uint16
+	Defense uint16
+	Speed uint16
+	Special uint16
+}
+
+type Mon struct {
+	Species uint8
+	HP uint16
+	// BoxLevel is the level the Pokémon had when it was last deposited.
+	BoxLevel uint8
+	Status uint8
+	Types [2]uint8
+	CatchRate uint8
+	Moves [4]uint8
+	OTID uint16
+	Exp uint32
+	// StatExp holds the stat experience for HP, Attack, Defense, Speed and
+	// Special, in that order.
+	StatExp [5]uint16
+	DVs uint16
+	PP [4]uint8
+	// Party is nil for boxed Pokémon.
+	Party *PartyStats
+	OTName string
+	Nickname string
+}
+
+type Box struct {
+	Number int
+	// Current is set for the box that was selected when saving, which is
+	// read from the main data instead of its bank.
+	Current bool
+	Mons []Mon
+}
+
+type HallOfFameMon struct {
+	Species uint8
+	Level uint8
+	Nickname string
+}
+
+type ChecksumStatus struct {
+	Region string
+	Stored uint8
+	Computed uint8
+	Valid bool
+}
+
+type PlayTime struct {
+	Hours uint8
+	Minutes uint8
+	Seconds uint8
+	Maxed bool
+}
+
+// Save is a decoded save file. Counts found out of range are clamped, and a
+// warning is kept for each of them.
+type Save struct {
+	PlayerName string
+	RivalName string
+	PlayerID uint16
+	Money int
+	Coins int
+	Badges uint8
+	Options uint8
+	Map uint8
+	Y uint8
+	X uint8
+	PlayTime PlayTime
+	// Owned and Seen hold Pokédex numbers.
+	Owned []int
+	Seen []int
+	BagItems []Item
+	PCItems []Item
+	Party []Mon
+	// Boxes holds all 12 boxes, or only the current one if the boxes were
+	// never initialized by changing box.
+	Boxes []Box
+	HallOfFame [][]HallOfFameMon
+	Checksums []ChecksumStatus
+	Warnings []string
+}
+
+// Parse decodes a save file of the English releases of Red and Blue.
+func Parse(sav []byte) (*Save, error) {
+	if len(sav) != SaveSize {
+		return nil, fmt.Errorf("expected a %d byte save file, got %d bytes", SaveSize, len(sav))
+	}
+	p := parser{sav: sav}
+	s := &Save{
+		PlayerName: p.name(playerNameOffset),
+		RivalName: p.name(mainData(wRivalName)),
+		PlayerID: p.word(mainData(wPlayerID)),
+		Money: p.bcd(mainData(wPlayerMoney), 3),
+		Coins: p.bcd(mainData(wPlayerCoins), 2),
+		Badges: sav[mainData(wObtainedBadges)],
+		Options: sav[mainData(wOptions)],
+		Map: sav[mainData(wCurMap)],
+		Y: sav[mainData(wYCoord)],
+		X: sav[mainData(wXCoord)],
+		PlayTime: PlayTime{
+			Hours: sav[mainData(wPlayTimeHours)],
+			Minutes: sav[mainData(wPlayTimeMinutes)],
+			Seconds: sav[mainData(wPlayTimeSeconds)],
+			Maxed: sav[mainData(wPlayTimeMaxed)] != 0,
+		},
+		Owned: p.dexFlags(mainData(wPokedexOwned)),
+		Seen: p.dexFlags(mainData(wPokedexSeen)),
+		BagItems: p.items("bag", mainData(wNumBagItems), BagCapacity),
+		PCItems: p.items("PC items", mainData(wNumBoxItems), PCItemCapacity),
+		Party: p.mons("party", partyDataOffset, PartyCapacity, partyMonSize),
+	}
+
+	currentBox := int(sav[mainData(wCurrentBoxNum)])
+	boxesInitialized := currentBox & 0x80 != 0
+	currentBox &= 0x7f
+	if currentBox >= NumBoxes {
+		p.warn("current box %d out of range", currentBox + 1)
+		currentBox = 0
+	}
+	s.Boxes = make([]Box, 0, NumBoxes)
+	for i := 0; i < NumBoxes; i++ {
+		offset := BoxOffset(i)
+		if i == currentBox {
+			offset = curBoxDataOffset
+		} else if !boxesInitialized {
+			continue
+		}
+		s.Boxes = append(s.Boxes, Box{
+			Number: i + 1,
+			Current: i == currentBox,
+			Mons: p.mons(fmt.Sprintf("box %d", i + 1), offset, BoxCapacity, boxMonSize),
+		})
+	}
+
+	s.HallOfFame = p.hallOfFame(int(sav[mainData(wNumHoFTeams)]))
+
+	for _, region := range ChecksumRegions() {
+		computed := region.Compute(sav)
+		s.Checksums = append(s.Checksums, ChecksumStatus{
+			Region: region.Name,
+			Stored: sav[region.Checksum],
+			Computed: computed,
+			Valid: computed == sav[region.Checksum],
+		})
+	}
+	s.Warnings = p.warnings
+	return s, nil
+}
+
+// BoxOffset returns where the given box, counting from 0, is kept in banks 2
+// and 3.
+func BoxOffset(box int) int {
+	return (2 + box / boxesPerBank) * 0x2000 + box % boxesPerBank * boxSize
+}
+
+type parser struct {
+	sav []byte
+	warnings []string
+}
+
+func (p *parser) warn(format string, args ...any) {
+	p.warnings = append(p.warnings, fmt.Sprintf(format, args...))
+}
+
+func (p *parser) name(offset int) string {
+	text, _, _ := DecodeText(p.sav[offset:offset + NameLength])
+	return text
+}
+
+func (p *parser) word(offset int) uint16 {
+	return uint16(p.sav[offset]) << 8 | uint16(p.sav[offset + 1])
+}
+
+// bcd decodes a big endian binary coded decimal number.
+func (p *parser) bcd(offset, length int) int {
+	value := 0
+	for _, b := range p.sav[offset:offset + length] {
+		value = value * 100 + int(b >> 4) * 10 + int(b & 0x0f)
+	}
+	return value
+}
+
+// count reads a list count, clamped to the list's capacity.
+func (p *parser) count(list string, offset, capacity int) int {
+	count := int(p.sav[offset])
+	if count > capacity {
+		p.warn("%s: count %d is over the capacity of %d", list, count, capacity)
+		count = capacity
+	}
+	return count
+}
+
+func (p *parser) dexFlags(offset int) []int {
+	dex := make([]int, 0)
+	for i := 0; i < NumDexEntries; i++ {
+		if p.sav[offset + i / 8] & (1 << (i % 8)) != 0 {
+			dex = append(dex, i + 1)
+		}
+	}
+	return dex
+}
+
+// items reads a count, followed by item and quantity pairs.
+func (p *parser) items(list string, offset, capacity int) []Item {
+	count := p.count(list, offset, capacity)
+	items := make([]Item, count)
+	for i := range items {
+		items[i] = Item{p.sav[offset + 1 + i * 2], p.sav[offset + 2 + i * 2]}
+	}
+	return items
+}
+
+// mons reads a party or box: a count, a list of species terminated by 0xff,
+// the Pokémon data, then the OT names and nicknames.
+func (p *parser) mons(list string, offset, capacity, monSize int) []Mon {
+	count := p.count(list, offset, capacity)
+	dataOffset := offset + 2 + capacity
+	otOffset := dataOffset + capacity * monSize
+	nickOffset := otOffset + capacity * NameLength
+	mons := make([]Mon, count)
+	for i := range mons {
+		if species := p.sav[offset + 1 + i]; species != p.sav[dataOffset + i * monSize] {
+			p.warn("%s: species list has 0x%02x for Pokémon %d, but its data has 0x%02x",
+			       list, species, i + 1, p.sav[dataOffset + i * monSize])
+		}
+		mons[i] = p.mon(dataOffset + i * monSize, monSize == partyMonSize)
+		mons[i].OTName = p.name(otOffset + i * NameLength)
+		mons[i].Nickname = p.name(nickOffset + i * NameLength)
+	}
+	return mons
+}
+
+func (p *parser) mon(offset int, party bool) Mon {
+	data := p.sav[offset:]
+	mon := Mon{
+		Species: data[0],
+		HP: p.word(offset + 1),
+		BoxLevel: data[3],
+		Status: data[4],
+		Types: [2]uint8{data[5], data[6]},
+		CatchRate: data[7],
+		Moves: [4]uint8{data[8], data[9], data[10], data[11]},
+		OTID: p.word(offset + 12),
+		Exp: uint32(data[14]) << 16 | uint32(data[15]) << 8 | uint32(data[16]),
+		DVs: p.word(offset + 27),
+		PP: [4]uint8{data[29], data[30], data[31], data[32]},
+	}
+	for i := range mon.StatExp {
+		mon.StatExp[i] = p.word(offset + 17 + i * 2)
+	}
+	if party {
+		mon.Party = &PartyStats{
+			Level: data[33],
+			MaxHP: p.word(offset + 34),
+			Attack: p.word(offset + 36),
+			Defense: p.word(offset + 38),
+			Speed: p.word(offset + 40),
+			Special: p.word(offset + 42),
+		}
+	}
+	return mon
+}
+
+// hallOfFame reads the recorded teams, each of which is cut short by a 0xff
+// species if it had less than 6 Pokémon.
+func (p *parser) hallOfFame(count int) [][]HallOfFameMon {
+	if count > HallOfFameCapacity {
+		p.warn("Hall of Fame: count %d is over the capacity of %d", count, HallOfFameCapacity)
+		count = HallOfFameCapacity
+	}
+	teams := make([][]HallOfFameMon, count)
+	for i := range teams {
+		teams[i] = make([]HallOfFameMon, 0, PartyCapacity)
+		for j := 0; j < PartyCapacity; j++ {
+			offset := hallOfFameOffset + i * hofTeamSize + j * hofMonSize
+			if p.sav[offset] == 0xff {
+				break
+			}
+			teams[i] = append(teams[i], HallOfFameMon{
+				Species: p.sav[offset],
+				Level: p.sav[offset + 1],
+				Nickname: p.name(offset + 2),
+			})
+		}
+	}
+	return teams
+}
@@ -0,0 +1,90 @@
+/*
+ * fools2024-solutions: source code for Kagamiin's solutions for TheZZAZZGlitch April Fools Event 2024's Security Testing Program.
+ * Copyright (C) 2024 Kagamiin~
+ * 
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License,
+ * or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program. If not, see <https://www.gnu.org/licenses/>.
+ */
+
+package gen1sav_test
+
+import (
+	"testing"
+
+	"github.com/Kagamiin/fools2024-solutions/challenge-1/cmd/gen1sav"
+)
+
+func Test_Parse(t *testing.T) {
+	sav := make([]byte, gen1sav.SaveSize)
+	// player name "RED"
+	copy(sav[0x2598:], []byte{0x91, 0x84, 0x83, 0x50})
+	// money
+	copy(sav[0x25f3:], []byte{0x01, 0x23, 0x45})
+	// seen Bulbasaur and Mew
+	sav[0x25b6] = 0x01
+	sav[0x25b6 + 18] = 0x40
+	// box 2 current, boxes initialized
+	sav[0x284c] = 0x81
+	// one Hall of Fame team with a single level 62 Charmander "CHAR"
+	sav[0x284e] = 1
+	copy(sav[0x0598:], []byte{0xb0, 62, 0x82, 0x87, 0x80, 0x91, 0x50})
+	sav[0x05a8] = 0xff
+	// party of one level 5 Charmander
+	sav[0x2f2c] = 1
+	copy(sav[0x2f2d:], []byte{0xb0, 0xff})
+	sav[0x2f34] = 0xb0
+	sav[0x2f34 + 33] = 5
+	copy(sav[0x307e:], []byte{0x82, 0x87, 0x80, 0x91, 0x50})
+	// box 1 in bank 2 holding one Pokémon, box 3 with a bad count
+	sav[0x4000] = 1
+	copy(sav[0x4001:], []byte{0x99, 0xff})
+	sav[0x4016] = 0x99
+	sav[0x48c4] = 25
+	regions := gen1sav.ChecksumRegions()
+	for i := len(regions) - 1; i >= 0; i-- {
+		sav[regions[i].Checksum] = regions[i].Compute(sav)
+	}
+	sav[0x5a4d] ^= 1
+
+	save, err := gen1sav.Parse(sav)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if save.PlayerName != "RED" || save.Money != 12345 {
+		t.Errorf("unexpected player %q with ¥%d", save.PlayerName, save.Money)
+	}
+	if len(save.Seen) != 2 || save.Seen[0] != 1 || save.Seen[1] != 151 {
+		t.Errorf("unexpected seen flags %v", save.Seen)
+	}
+	if len(save.Party) != 1 || save.Party[0].Party == nil || save.Party[0].Party.Level != 5 || save.Party[0].Nickname != "CHAR" {
+		t.Errorf("unexpected party %+v", save.Party)
+	}
+	if len(save.Boxes) != gen1sav.NumBoxes || !save.Boxes[1].Current || len(save.Boxes[0].Mons) != 1 || save.Boxes[0].Mons[0].Species != 0x99 {
+		t.Errorf("unexpected boxes %+v", save.Boxes)
+	}
+	if len(save.Boxes[2].Mons) != gen1sav.BoxCapacity || len(save.Warnings) == 0 {
+		t.Errorf("expected the count of box 3 to be clamped with a warning, got %d Pokémon and warnings %v", len(save.Boxes[2].Mons), save.Warnings)
+	}
+	if len(save.HallOfFame) != 1 || len(save.HallOfFame[0]) != 1 || save.HallOfFame[0][0] != (gen1sav.HallOfFameMon{0xb0, 62, "CHAR"}) {
+		t.Errorf("unexpected Hall of Fame %+v", save.HallOfFame)
+	}
+	for _, checksum := range save.Checksums {
+		if checksum.Valid != (checksum.Region != "box 1") {
+			t.Errorf("%s: unexpected checksum status %+v", checksum.Region, checksum)
+		}
+	}
+
+	if _, err := gen1sav.Parse(sav[:0x2000]); err == nil {
+		t.Error("expected an error for a truncated save file")
+	}
+}
@@ -0,0 +1,313 @@
+/*
+ * fools2024-solutions: source code for Kagamiin's solutions for TheZZAZZGlitch April Fools Event 2024's Security Testing Program.
+ * Copyright (C) 2024 Kagamiin~
+ * 
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License,
+ * or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program. If not, see <https://www.gnu.org/licenses/>.
+ */
+
+package gen1sav
+
+import (
+	"fmt"
+	"sort"
+)
+
+// Components with at most this many unknown bits left after propagation are
+// solved exactly, by trying every assignment.
+const maxEnumeratedBits = 20
+
+// A Bit is a single bit of a save file.
+type Bit struct {
+	Offset int
+	Bit uint8
+}
+
+func (b Bit) String() string {
+	return fmt.Sprintf("0x%04x.%d", b.Offset, b.Bit)
+}
+
+type Solution struct {
+	// Data is the save file with every determined bit filled in.
+	Data []byte
+	// Unknown is a bit map of the bits that are still ambiguous.
+	Unknown []byte
+	// Determined lists the bits that were unknown and got determined.
+	Determined []Bit
+	// Ambiguous lists the bits covered by a checksum that can still take
+	// either value. Uncovered counts the unknown bits outside of any checksum.
+	Ambiguous []Bit
+	Uncovered int
+	// Unsatisfiable lists the checksums that no assignment of the unknown
+	// bits can satisfy.
+	Unsatisfiable []string
+}
+
+// Unique tells whether the unknown bits have a single consistent assignment.
+func (s *Solution) Unique() bool {
+	return len(s.Ambiguous) == 0 && s.Uncovered == 0 && len(s.Unsatisfiable) == 0
+}
+
+type solver struct {
+	data []byte
+	unknown []byte
+	regions []ChecksumRegion
+	determined []Bit
+	unsatisfiable map[string]bool
+}
+
+// Solve treats the bits set in unknown as variables, and finds the values the
+// checksums of the save file allow for them. Bytes in known override both the
+// data and the unknown bit map. data and unknown are left untouched.
+func Solve(data, unknown []byte, known map[int]uint8) (*Solution, error) {
+	if len(data) != SaveSize || len(unknown) != SaveSize {
+		return nil, fmt.Errorf("expected a %d byte save file and unknown bit map, got %d and %d bytes", SaveSize, len(data), len(unknown))
+	}
+	s := solver{
+		data: append([]byte(nil), data...),
+		unknown: append([]byte(nil), unknown...),
+		regions: ChecksumRegions(),
+		unsatisfiable: make(map[string]bool),
+	}
+	for offset, value := range known {
+		if offset < 0 || offset >= SaveSize {
+			return nil, fmt.Errorf("known byte at 0x%x is outside of the save file", offset)
+		}
+		s.data[offset] = value
+		s.unknown[offset] = 0
+	}
+
+	for s.propagate() {
+	}
+	s.enumerate()
+
+	solution := Solution{
+		Data: s.data,
+		Unknown: s.unknown,
+		Determined: s.determined,
+		Ambiguous: make([]Bit, 0),
+	}
+	for offset, bits := range s.unknown {
+		for bit := uint8(0); bit < 8; bit++ {
+			if bits & (1 << bit) == 0 {
+				continue
+			}
+			if s.covered(offset) {
+				solution.Ambiguous = append(solution.Ambiguous, Bit{offset, bit})
+			} else {
+				solution.Uncovered++
+			}
+		}
+	}
+	for name := range s.unsatisfiable {
+		solution.Unsatisfiable = append(solution.Unsatisfiable, name)
+	}
+	sort.Strings(solution.Unsatisfiable)
+	return &solution, nil
+}
+
+func (s *solver) covered(offset int) bool {
+	for _, region := range s.regions {
+		if region.contains(offset) {
+			return true
+		}
+	}
+	return false
+}
+
+// equation returns the unknown bits of a region, and the value their weighted
+// sum must have modulo 256 for the checksum to hold.
+func (s *solver) equation(region ChecksumRegion) (bits []Bit, target uint8) {
+	sum := uint8(0)
+	add := func(offset int) {
+		sum += s.data[offset] & ^s.unknown[offset]
+		for bit := uint8(0); bit < 8; bit++ {
+			if s.unknown[offset] & (1 << bit) != 0 {
+				bits = append(bits, Bit{offset, bit})
+			}
+		}
+	}
+	for offset := region.Start; offset < region.End; offset++ {
+		add(offset)
+	}
+	add(region.Checksum)
+	return bits, 0xff - sum
+}
+
+func (s *solver) fix(bit Bit, value uint8) {
+	mask := uint8(1) << bit.Bit
+	s.data[bit.Offset] = (s.data[bit.Offset] & ^mask) | (value << bit.Bit)
+	s.unknown[bit.Offset] &= ^mask
+	s.determined = append(s.determined, bit)
+}
+
+type residues [256]bool
+
+// propagate fixes every bit that can only take one value for some checksum to
+// hold on its own, and tells whether any bit got fixed.
+func (s *solver) propagate() bool {
+	changed := false
+	for _, region := range s.regions {
+		bits, target := s.equation(region)
+		if len(bits) == 0 {
+			if target != 0 {
+				s.unsatisfiable[region.Name] = true
+			}
+			continue
+		}
+
+		// forward[i] holds the sums reachable with the first i bits, and
+		// backward[i] the sums from which the target can be reached with the
+		// bits from i onwards.
+		forward := make([]residues, len(bits) + 1)
+		backward := make([]residues, len(bits) + 1)
+		forward[0][0] = true
+		for i, bit := range bits {
+			weight := uint8(1) << bit.Bit
+			for r := 0; r < 256; r++ {
+				if forward[i][r] {
+					forward[i + 1][r] = true
+					forward[i + 1][uint8(r) + weight] = true
+				}
+			}
+		}
+		backward[len(bits)][target] = true
+		for i := len(bits) - 1; i >= 0; i-- {
+			weight := uint8(1) << bits[i].Bit
+			for r := 0; r < 256; r++ {
+				backward[i][r] = backward[i + 1][r] || backward[i + 1][uint8(r) + weight]
+			}
+		}
+		if !backward[0][0] {
+			s.unsatisfiable[region.Name] = true
+			continue
+		}
+
+		for i, bit := range bits {
+			weight := uint8(1) << bit.Bit
+			canBe := [2]bool{}
+			for r := 0; r < 256; r++ {
+				if forward[i][r] {
+					canBe[0] = canBe[0] || backward[i + 1][r]
+					canBe[1] = canBe[1] || backward[i + 1][uint8(r) + weight]
+				}
+			}
+			if canBe[0] != canBe[1] {
+				value := uint8(0)
+				if canBe[1] {
+					value = 1
+				}
+				s.fix(bit, value)
+				changed = true
+			}
+		}
+	}
+	return changed
+}
+
+// enumerate solves groups of checksums sharing unknown bits exactly, when
+// they're small enough.
+func (s *solver) enumerate() {
+	type equation struct {
+		region ChecksumRegion
+		bits []Bit
+		target uint8
+	}
+	equations := make([]equation, 0)
+	for _, region := range s.regions {
+		bits, target := s.equation(region)
+		if len(bits) > 0 {
+			equations = append(equations, equation{region, bits, target})
+		}
+	}
+
+	// group the equations sharing bits
+	group := make([]int, len(equations))
+	for i := range group {
+		group[i] = i
+	}
+	var find func(i int) int
+	find = func(i int) int {
+		if group[i] != i {
+			group[i] = find(group[i])
+		}
+		return group[i]
+	}
+	owner := make(map[Bit]int)
+	for i, eq := range equations {
+		for _, bit := range eq.bits {
+			if j, ok := owner[bit]; ok {
+				group[find(i)] = find(j)
+			} else {
+				owner[bit] = i
+			}
+		}
+	}
+	groups := make(map[int][]int)
+	for i := range equations {
+		groups[find(i)] = append(groups[find(i)], i)
+	}
+
+	for _, members := range groups {
+		var bits []Bit
+		index := make(map[Bit]int)
+		for _, i := range members {
+			for _, bit := range equations[i].bits {
+				if _, ok := index[bit]; !ok {
+					index[bit] = len(bits)
+					bits = append(bits, bit)
+				}
+			}
+		}
+		if len(bits) > maxEnumeratedBits {
+			continue
+		}
+
+		solutions := 0
+		var ones, zeros uint32
+		for assignment := uint32(0); assignment < 1 << len(bits); assignment++ {
+			ok := true
+			for _, i := range members {
+				sum := uint8(0)
+				for _, bit := range equations[i].bits {
+					if assignment & (1 << index[bit]) != 0 {
+						sum += 1 << bit.Bit
+					}
+				}
+				if sum != equations[i].target {
+					ok = false
+					break
+				}
+			}
+			if ok {
+				solutions++
+				ones |= assignment
+				zeros |= ^assignment
+			}
+		}
+		if solutions == 0 {
+			for _, i := range members {
+				s.unsatisfiable[equations[i].region.Name] = true
+			}
+			continue
+		}
+		for i, bit := range bits {
+			switch {
+			case ones & (1 << i) == 0:
+				s.fix(bit, 0)
+			case zeros & (1 << i) == 0:
+				s.fix(bit, 1)
+			}
+		}
+	}
+}
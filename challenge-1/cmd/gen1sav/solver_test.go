@@ -0,0 +1,123 @@
+/*
+ * fools2024-solutions: source code for Kagamiin's solutions for TheZZAZZGlitch April Fools Event 2024's Security Testing Program.
+ * Copyright (C) 2024 Kagamiin~
+ * 
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License,
+ * or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program. If not, see <https://www.gnu.org/licenses/>.
+ */
+
+package gen1sav_test
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/Kagamiin/fools2024-solutions/challenge-1/cmd/gen1sav"
+)
+
+func testSave() []byte {
+	rng := rand.New(rand.NewSource(1))
+	sav := make([]byte, gen1sav.SaveSize)
+	rng.Read(sav)
+	// the box checksums have to be written before the bank checksums
+	regions := gen1sav.ChecksumRegions()
+	for i := len(regions) - 1; i >= 0; i-- {
+		sav[regions[i].Checksum] = regions[i].Compute(sav)
+	}
+	return sav
+}
+
+func Test_ChecksumRegions(t *testing.T) {
+	sav := testSave()
+	for _, region := range gen1sav.ChecksumRegions() {
+		if !region.Valid(sav) {
+			t.Errorf("%s: checksum doesn't hold", region.Name)
+		}
+	}
+	regions := gen1sav.ChecksumRegions()
+	if len(regions) != 15 || regions[1].Checksum != 0x5a4c || regions[2].Checksum != 0x5a4d || regions[14].Checksum != 0x7a52 {
+		t.Errorf("unexpected checksum layout %v", regions)
+	}
+}
+
+func Test_SolveUnique(t *testing.T) {
+	sav := testSave()
+	damaged := append([]byte(nil), sav...)
+	unknown := make([]byte, gen1sav.SaveSize)
+	// bits of different weights in a box, and one in the main data
+	unknown[0x4100] = 0x81
+	unknown[0x4101] = 0x06
+	unknown[0x2600] = 0x10
+	for i := range damaged {
+		damaged[i] ^= unknown[i]
+	}
+
+	solution, err := gen1sav.Solve(damaged, unknown, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !solution.Unique() || len(solution.Determined) != 5 {
+		t.Fatalf("expected a unique solution, got %d ambiguous bits and %v", len(solution.Ambiguous), solution.Unsatisfiable)
+	}
+	for i := range sav {
+		if solution.Data[i] != sav[i] {
+			t.Fatalf("0x%04x: expected 0x%02x, got 0x%02x", i, sav[i], solution.Data[i])
+		}
+	}
+}
+
+func Test_SolveAmbiguous(t *testing.T) {
+	sav := testSave()
+	// two bits of the same weight, which the checksums can't tell apart if
+	// only one of them is set
+	sav[0x2600] |= 0x01
+	sav[0x2601] &= 0xfe
+	sav[0x3523] = gen1sav.ChecksumRegions()[0].Compute(sav)
+	unknown := make([]byte, gen1sav.SaveSize)
+	unknown[0x2600] = 0x01
+	unknown[0x2601] = 0x01
+	// bank 0 isn't covered by any checksum
+	unknown[0x0100] = 0xff
+
+	solution, err := gen1sav.Solve(sav, unknown, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if solution.Unique() || len(solution.Ambiguous) != 2 || solution.Uncovered != 8 {
+		t.Errorf("expected 2 ambiguous and 8 uncovered bits, got %v and %d", solution.Ambiguous, solution.Uncovered)
+	}
+
+	// a known byte settles it
+	solution, err = gen1sav.Solve(sav, unknown, map[int]uint8{0x2600: sav[0x2600], 0x0100: sav[0x0100]})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !solution.Unique() || solution.Data[0x2601] != sav[0x2601] {
+		t.Errorf("expected a unique solution with the known byte, got %v", solution.Ambiguous)
+	}
+}
+
+func Test_SolveUnsatisfiable(t *testing.T) {
+	sav := testSave()
+	sav[0x6000] ^= 0x01
+	unknown := make([]byte, gen1sav.SaveSize)
+	unknown[0x4000] = 0x01
+
+	solution, err := gen1sav.Solve(sav, unknown, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(solution.Unsatisfiable) != 2 || solution.Unsatisfiable[0] != "bank 3 boxes" || solution.Unsatisfiable[1] != "box 7" {
+		t.Errorf("expected the bank 3 and box 7 checksums to fail, got %v", solution.Unsatisfiable)
+	}
+}
@@ -0,0 +1,86 @@
+/*
+ * fools2024-solutions: source code for Kagamiin's solutions for TheZZAZZGlitch April Fools Event 2024's Security Testing Program.
+ * Copyright (C) 2024 Kagamiin~
+ * 
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License,
+ * or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program. If not, see <https://www.gnu.org/licenses/>.
+ */
+
+package gen1sav
+
+import (
+	"fmt"
+	"strings"
+)
+
+// TextTerminator ends strings, names included.
+const TextTerminator = 0x50
+
+// charmap holds the characters of the English releases, and the control
+// characters with something printable to show for them.
+var charmap = map[uint8]string{
+	0x49: "\n", 0x4a: "PkMn", 0x4b: "\n", 0x4c: "\n", 0x4e: "\n", 0x4f: "\n",
+	0x51: "\n\n", 0x52: "<PLAYER>", 0x53: "<RIVAL>", 0x54: "POKé", 0x55: "\n", 0x56: "……",
+	0x57: "<DONE>", 0x58: "<PROMPT>", 0x59: "<TARGET>", 0x5a: "<USER>", 0x5b: "PC",
+	0x5c: "TM", 0x5d: "TRAINER", 0x5e: "ROCKET", 0x5f: ".",
+	0x7f: " ",
+	0x9a: "(", 0x9b: ")", 0x9c: ":", 0x9d: ";", 0x9e: "[", 0x9f: "]",
+	0xba: "é", 0xbb: "'d", 0xbc: "'l", 0xbd: "'s", 0xbe: "'t", 0xbf: "'v",
+	0xe0: "'", 0xe1: "ᴾₖ", 0xe2: "ᴹₙ", 0xe3: "-", 0xe4: "'r", 0xe5: "'m", 0xe6: "?", 0xe7: "!", 0xe8: ".",
+	0xec: "▷", 0xed: "▶", 0xee: "▼", 0xef: "♂",
+	0xf0: "¥", 0xf1: "×", 0xf2: ".", 0xf3: "/", 0xf4: ",", 0xf5: "♀",
+}
+
+func init() {
+	for i := uint8(0); i < 26; i++ {
+		charmap[0x80 + i] = string(rune('A' + i))
+		charmap[0xa0 + i] = string(rune('a' + i))
+	}
+	for i := uint8(0); i < 10; i++ {
+		charmap[0xf6 + i] = string(rune('0' + i))
+	}
+}
+
+// IsTextChar tells whether a byte is a character or control character that can
+// appear in text.
+func IsTextChar(b uint8) bool {
+	_, ok := charmap[b]
+	return ok
+}
+
+// IsLetter tells whether a byte is a letter or digit.
+func IsLetter(b uint8) bool {
+	return (b >= 0x80 && b <= 0x99) || (b >= 0xa0 && b <= 0xb9) || b >= 0xf6
+}
+
+// DecodeText decodes text up to its terminator, showing bytes that aren't
+// characters as <XX>. It returns the position of the terminator, or the length
+// of data if there's none, and whether the text is terminated and made only
+// of characters.
+func DecodeText(data []byte) (text string, end int, ok bool) {
+	var sb strings.Builder
+	ok = true
+	for end = 0; end < len(data); end++ {
+		b := data[end]
+		if b == TextTerminator {
+			return sb.String(), end, ok
+		}
+		if char, isChar := charmap[b]; isChar {
+			sb.WriteString(char)
+		} else {
+			fmt.Fprintf(&sb, "<%02X>", b)
+			ok = false
+		}
+	}
+	return sb.String(), end, false
+}
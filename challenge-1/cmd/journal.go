@@ -0,0 +1,343 @@
+/*
+ * fools2024-solutions: source code for Kagamiin's solutions for TheZZAZZGlitch April Fools Event 2024's Security Testing Program.
+ * Copyright (C) 2024 Kagamiin~
+ * 
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License,
+ * or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program. If not, see <https://www.gnu.org/licenses/>.
+ */
+
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/Kagamiin/fools2024-solutions/challenge-1/cmd/decomp"
+	"github.com/Kagamiin/fools2024-solutions/challenge-1/cmd/gbmem"
+)
+
+func journalFormatFromPath(path string) decomp.JournalFormat {
+	switch filepath.Ext(path) {
+	case ".json":
+		return decomp.JournalJSON
+	case ".jsonl":
+		return decomp.JournalJSONL
+	}
+	return decomp.JournalBinary
+}
+
+func parseJournalFormat(name, path string) (decomp.JournalFormat, error) {
+	switch name {
+	case "":
+		return journalFormatFromPath(path), nil
+	case "bin":
+		return decomp.JournalBinary, nil
+	case "json":
+		return decomp.JournalJSON, nil
+	case "jsonl":
+		return decomp.JournalJSONL, nil
+	}
+	return 0, fmt.Errorf("unknown journal format %q", name)
+}
+
+func readJournalFile(path string) (*decomp.RecordedDecompression, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		ec := f.Close()
+		handle(ec)
+	}()
+	
+	return decomp.ReadJournal(f)
+}
+
+func writeJournalFile(path string, recording *decomp.RecordedDecompression, format decomp.JournalFormat) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		ec := f.Close()
+		handle(ec)
+	}()
+	
+	return recording.WriteJournal(f, format)
+}
+
+// loadMemImage loads either a 64 KiB memory image (such as result.bin or
+// decompressed.bin), or a 32 KiB save file, which gets placed into a memory
+// image the same way the recovery path does.
+func loadMemImage(path string) ([]byte, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	
+	switch len(data) {
+	case 65536:
+		return data, nil
+	case 32768:
+		memSpace := make([]byte, 65536)
+		prepareMemSpace(memSpace, data)
+		return memSpace, nil
+	}
+	return nil, fmt.Errorf("%s: expected a 65536 byte memory image or a 32768 byte save file, got %d bytes", path, len(data))
+}
+
+// loadSRAMBanks reads the save file holding the SRAM banks of a banked address
+// space: the one at savPath if given, or else memPath if it's a save file.
+// It returns nil if there's none, leaving banks 1-3 blank.
+func loadSRAMBanks(savPath, memPath string) ([]byte, error) {
+	if savPath != "" {
+		return readSavFile(savPath)
+	}
+	info, err := os.Stat(memPath)
+	if err != nil {
+		return nil, err
+	}
+	if info.Size() != 32768 {
+		return nil, nil
+	}
+	return readSavFile(memPath)
+}
+
+// newBankedSpace sets up the address space the way the game leaves it while
+// decompressing a sprite: the sprite's ROM bank mapped in, and the first bank
+// of SRAM enabled. All four banks of SRAM are taken from the save file, if
+// any, so that overflowing writes switching SRAM banks reach the right data,
+// except for bank 0, which is taken from the memory image.
+func newBankedSpace(memSpace, savData []byte, bank uint8) *gbmem.AddressSpace {
+	loadGameData()
+	sram := make([]byte, 4 * gbmem.SRAMBankSize)
+	copy(sram, savData)
+	copy(sram, memSpace[0xa000:0xc000])
+	space := gbmem.NewAddressSpace(pokéRom, sram)
+	space.LoadImage(memSpace)
+	space.MBC.Write(0x0000, 0x0a)
+	space.MBC.Write(0x2000, bank)
+	space.MBC.Write(0x4000, bank >> 5)
+	return space
+}
+
+func writeConflicts(path string, conflicts []decomp.UndoConflict) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	
+	writer := bufio.NewWriter(file)
+	for _, conflict := range conflicts {
+		fmt.Fprintln(writer, conflict)
+	}
+	return writer.Flush()
+}
+
+// recordFromArgs records the decompression of a sprite given the
+// pokeblue.sav bank addr [width height] arguments shared by several subcommands.
+func recordFromArgs(args []string) *decomp.RecordedDecompression {
+	savData, err := readSavFile(args[0])
+	handle(err)
+	
+	bank, err := strconv.ParseUint(args[1], 16, 64)
+	handle(err)
+	
+	addr, err := strconv.ParseUint(args[2], 16, 64)
+	handle(err)
+	
+	width := int64(-1)
+	height := int64(-1)
+	if len(args) >= 5 {
+		width, err = strconv.ParseInt(args[3], 16, 64)
+		handle(err)
+		
+		height, err = strconv.ParseInt(args[4], 16, 64)
+		handle(err)
+	}
+	
+	memSpace := make([]byte, 65536)
+	
+	prepareMemSpace(memSpace, savData)
+	
+	recording, err := recordSprite(loadProfile(), memSpace, savData, uint8(bank), int(addr), int(width), int(height))
+	handle(err)
+	return recording
+}
+
+func recordJournal() {
+	flags := flag.NewFlagSet("record", flag.ExitOnError)
+	outPath := flags.String("o", "journal.bin", "path of the journal to write")
+	formatName := flags.String("format", "", "journal format: bin, json or jsonl (default: guessed from the output path)")
+	speciesArg := flags.String("species", "", "record the sprite of the given species index (hex) instead of taking bank and addr")
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), `usage: 
+	%v record [options] pokeblue.sav bank addr [width height]
+	%v record [options] -species id pokeblue.sav
+
+pokeblue.sav: save file containing the source data where the sprite will be decompressed.
+bank: ROM bank where the sprite decompression is performed
+addr: pointer to the sprite
+width: width of the sprite in its base data, in tiles (omit to use the width from the sprite data)
+height: height of the sprite in its base data, in tiles (omit to use the height from the sprite data)
+Generates the following files in the current directory:
+- journal.bin: contains the decompression journal (unless changed with -o)
+
+With -species, the journal also covers the rest of a wild encounter with the
+species if --encounter is given.
+
+options:
+`, os.Args[0], os.Args[0])
+		flags.PrintDefaults()
+	}
+	handle(flags.Parse(os.Args[2:]))
+	
+	if (*speciesArg == "" && flags.NArg() != 3 && flags.NArg() != 5) || (*speciesArg != "" && flags.NArg() != 1) {
+		flags.Usage()
+		os.Exit(1)
+	}
+	
+	format, err := parseJournalFormat(*formatName, *outPath)
+	handle(err)
+	
+	var recording *decomp.RecordedDecompression
+	if *speciesArg != "" {
+		species, err := strconv.ParseUint(strings.TrimPrefix(strings.ToLower(*speciesArg), "0x"), 16, 8)
+		handle(err)
+		savData, err := readSavFile(flags.Arg(0))
+		handle(err)
+		memSpace := make([]byte, 65536)
+		prepareMemSpace(memSpace, savData)
+		_, recording, err = recordSpeciesSprite(loadProfile(), memSpace, savData, uint8(species))
+		handle(err)
+	} else {
+		recording = recordFromArgs(flags.Args())
+	}
+	
+	err = writeJournalFile(*outPath, recording, format)
+	handle(err)
+	
+	log.Printf("Wrote %d operations to %s\n", len(recording.Operations), *outPath)
+	log.Println("Done.")
+}
+
+func applyJournal() {
+	flags := flag.NewFlagSet("apply", flag.ExitOnError)
+	outPath := flags.String("o", "applied.bin", "path of the memory image to write")
+	banked := flags.Bool("banked", false, "replay on a model of the MBC1 address space, where writes to ROM space switch banks, echo RAM mirrors WRAM and disabled SRAM ignores writes")
+	bankArg := flags.String("bank", "1", "ROM bank (hex) mapped in at the start of a -banked replay")
+	savPath := flags.String("sav", "", "save file holding SRAM banks 1-3 for a -banked replay (default: memory, if it's a save file)")
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), `usage: 
+	%v apply [options] journal memory
+
+journal: decompression journal, as written by the record subcommand
+memory: 64 KiB memory image, or 32 KiB save file, to apply the journal over
+Generates the following files in the current directory:
+- applied.bin: contains the memory image after applying the journal (unless changed with -o)
+
+options:
+`, os.Args[0])
+		flags.PrintDefaults()
+	}
+	handle(flags.Parse(os.Args[2:]))
+	
+	if flags.NArg() != 2 {
+		flags.Usage()
+		os.Exit(1)
+	}
+	
+	recording, err := readJournalFile(flags.Arg(0))
+	handle(err)
+	
+	memSpace, err := loadMemImage(flags.Arg(1))
+	handle(err)
+	
+	if *banked {
+		bank, err := strconv.ParseUint(*bankArg, 16, 8)
+		handle(err)
+		savData, err := loadSRAMBanks(*savPath, flags.Arg(1))
+		handle(err)
+		space := newBankedSpace(memSpace, savData, uint8(bank))
+		recording.ReplayRecording(space)
+		log.Printf("MBC1 state after replay: ROM bank 0x%02x, SRAM bank %d, SRAM enabled: %v, mode %d\n",
+		           space.MBC.ROMBank(), space.MBC.SRAMBank(), space.MBC.RAMEnable, space.MBC.Mode)
+		memSpace = space.Image()
+	} else {
+		recording.ApplyRecording(&memSpace)
+	}
+	
+	err = dumpBin(*outPath, &memSpace)
+	handle(err)
+	
+	log.Println("Done.")
+}
+
+func undoJournal() {
+	flags := flag.NewFlagSet("undo", flag.ExitOnError)
+	outPath := flags.String("o", "result.bin", "path of the recovered memory image to write")
+	unknownPath := flags.String("unknown", "unknownbits.bin", "path of the unknown bit map to write")
+	conflictsPath := flags.String("conflicts", "", "path of a report of every conflict between the journal and the memory image to write (default: only log how many there are)")
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), `usage: 
+	%v undo [options] journal memory
+
+journal: decompression journal, as written by the record subcommand
+memory: 64 KiB memory image, or 32 KiB save file, to undo the journal over
+
+The journal is undone over flat memory, even if it was recorded with --banked:
+bank switches made by overflowing writes aren't undone.
+Generates the following files in the current directory:
+- result.bin: contains the best-effort unscrambled data (unless changed with -o)
+- unknownbits.bin: contains a bitmap of memory where data was permanently overwritten (unless changed with -unknown)
+
+options:
+`, os.Args[0])
+		flags.PrintDefaults()
+	}
+	handle(flags.Parse(os.Args[2:]))
+	
+	if flags.NArg() != 2 {
+		flags.Usage()
+		os.Exit(1)
+	}
+	
+	recording, err := readJournalFile(flags.Arg(0))
+	handle(err)
+	
+	memSpace, err := loadMemImage(flags.Arg(1))
+	handle(err)
+	
+	unknownBitMap, conflicts := recording.UndoRecordingWithConflicts(&memSpace)
+	log.Printf("Found %d conflicts between the journal and the memory image\n", len(conflicts))
+	
+	err = dumpBin(*outPath, &memSpace)
+	handle(err)
+	
+	err = dumpBin(*unknownPath, unknownBitMap)
+	handle(err)
+	
+	if *conflictsPath != "" {
+		err = writeConflicts(*conflictsPath, conflicts)
+		handle(err)
+	}
+	
+	log.Println("Done.")
+}
@@ -20,36 +20,72 @@ package main
 
 import (
 	"bufio"
-	_ "embed"
 	"fmt"
 	"log"
 	"os"
 	"strconv"
+	"strings"
 
 	"github.com/Kagamiin/fools2024-solutions/challenge-1/cmd/decomp"
+	"github.com/Kagamiin/fools2024-solutions/challenge-1/cmd/gbmem"
+	"github.com/Kagamiin/fools2024-solutions/challenge-1/cmd/romdata"
 )
 
-// The ROM must be provided separately and is not included with the repository.
-//go:embed pokeblue.gb
-var pokéRom []byte
 
-//go:embed pokeblue-ram.dmp
-var pokéRam []byte
-
-const MissingnoOffset = 0x1900
-const MissingnoBaseWidth = 8
-const MissingnoBaseHeight = 8
+// Species index of the Missingno whose sprite scrambles the save data.
+const MissingnoSpecies = 0x1f
 
 func main() {
+	os.Args = extractGlobalArgs(os.Args)
+	
 	if len(os.Args) < 2 || os.Args[1] == "-h" || os.Args[1] == "help" || os.Args[1] == "--help" {
 		fmt.Printf(`usage: 
 	%v rest_in_miss_forever_ingno.sav
 	%v (--decompress|-d) pokeblue.sav bank addr width height
+	%v (--decompress|-d) --species id pokeblue.sav
+	%v (--png|-p) [options] decompressed.bin [width height]
+	%v record [options] pokeblue.sav bank addr [width height]
+	%v apply [options] journal memory
+	%v undo [options] journal memory
+	%v fuse [options] memory[:journal] memory[:journal]...
+	%v step [options] journal memory
+	%v blame [options] journal addr[-addr]
+	%v footprint [options] pokeblue.sav bank addr [width height]
+	%v catalogue [options] pokeblue.sav
+	%v solve [options] pokeblue.sav unknownbits.bin
+	%v savinfo [options] pokeblue.sav
+	%v export [options] pokeblue.sav result.bin
+	%v candidates [options] result.bin unknownbits.bin addr[-addr]
+	%v disasm [options] result.bin addr[-addr]
+	%v run [options] result.bin
+
+Global options, accepted before or after the mode:
+	--rom path: ROM to use (default: $POKEBLUE_ROM, or pokeblue.gb)
+	--ram path: 64 KiB RAM dump to use as the baseline for WRAM and VRAM
+	            (default: $POKEBLUE_RAM, or pokeblue-ram.dmp)
+	--state path: emulator savestate (BGB, mGBA or SameBoy/BESS) to use as
+	              the baseline instead of the RAM dump (default: $POKEBLUE_STATE)
+	--profile name: game release to reproduce, instead of identifying it from
+	                the ROM. Known profiles: %v
+	--force: run even if the ROM, RAM dump, savestate and profile don't match
+	--banked: read sprite data through a model of the MBC1 address space,
+	          seeing changes made by the decompression in progress, instead
+	          of a flat copy of memory with the sprite's bank mapped in
+	--back: decompress back sprites, which are scaled by two instead of being
+	        copied/aligned to their base data dimensions. Species lookups use
+	        the back sprite pointer
+	--encounter: also journal some of what the rest of a wild encounter writes
+	             before the sprite of a species gets decompressed: the base
+	             data loaded into wMonHeader, wd11e and the Pokédex seen flag.
+	             The enemy's battle data (wEnemyMon*) isn't journaled
 
 rest_in_miss_forever_ingno.sav: save file containing the data to be unscrambled.
 Generates the following files in the current directory:
 - result.bin: contains the best-effort unscrambled data
-- unknownbits.bin: contains a bitmap of memory where data was permanently overwritten`, os.Args[0], os.Args[0])
+- unknownbits.bin: contains a bitmap of memory where data was permanently overwritten
+
+Run a subcommand with -h for details on its options.`, os.Args[0], os.Args[0], os.Args[0], os.Args[0], os.Args[0], os.Args[0], os.Args[0], os.Args[0], os.Args[0], os.Args[0], os.Args[0], os.Args[0], os.Args[0], os.Args[0], os.Args[0], os.Args[0], os.Args[0], os.Args[0],
+		           strings.Join(romdata.ProfileNames(), ", "))
 		os.Exit(1)
 	}
 	
@@ -58,6 +94,56 @@ Generates the following files in the current directory:
 		return
 	}
 	
+	if os.Args[1] == "--png" || os.Args[1] == "-p" {
+		exportPng()
+		return
+	}
+	
+	switch os.Args[1] {
+	case "record":
+		recordJournal()
+		return
+	case "apply":
+		applyJournal()
+		return
+	case "undo":
+		undoJournal()
+		return
+	case "fuse":
+		fuseSnapshots()
+		return
+	case "step":
+		stepJournal()
+		return
+	case "blame":
+		blameJournal()
+		return
+	case "footprint":
+		analyzeFootprint()
+		return
+	case "catalogue":
+		catalogueSprites()
+		return
+	case "solve":
+		solveChecksums()
+		return
+	case "savinfo":
+		showSaveInfo()
+		return
+	case "export":
+		exportSave()
+		return
+	case "disasm":
+		disassemble()
+		return
+	case "run":
+		runPayload()
+		return
+	case "candidates":
+		listCandidates()
+		return
+	}
+	
 	savData, err := readSavFile(os.Args[1])
 	handle(err)
 	
@@ -65,7 +151,8 @@ Generates the following files in the current directory:
 	
 	prepareMemSpace(memSpace, savData)
 	
-	recording := decomp.RecordDecompressSprite(pokéRom, MissingnoOffset, MissingnoBaseWidth, MissingnoBaseHeight)
+	_, recording, err := recordSpeciesSprite(loadProfile(), memSpace, savData, MissingnoSpecies)
+	handle(err)
 	
 	unknownBitMap := recording.UndoRecording(&memSpace)
 
@@ -83,17 +170,25 @@ func decompressSprite() {
 		fmt.Printf(`usage: 
 	%v rest_in_miss_forever_ingno.sav
 	%v (--decompress|-d) pokeblue.sav bank addr [width height]
+	%v (--decompress|-d) --species id pokeblue.sav
 
 pokeblue.sav: save file containing the source data where the sprite will be decompressed.
 bank: ROM bank where the sprite decompression is performed
 addr: pointer to the sprite
 width: width of the sprite in its base data, in tiles (omit to use the width from the sprite data)
 height: height of the sprite in its base data, in tiles (omit to use the height from the sprite data)
+id: species index whose front sprite is decompressed, with its bank, pointer and
+    base data dimensions looked up the same way the game does
 Generates the following files in the current directory:
-- decompressed.sav: contains the save data after decompression`, os.Args[0], os.Args[0])
+- decompressed.sav: contains the save data after decompression`, os.Args[0], os.Args[0], os.Args[0])
 		os.Exit(1)
 	}
 	
+	if os.Args[2] == "--species" {
+		decompressSpeciesSprite(os.Args[3], os.Args[4])
+		return
+	}
+	
 	savData, err := readSavFile(os.Args[2])
 	handle(err)
 	
@@ -121,17 +216,33 @@ Generates the following files in the current directory:
 	
 	prepareMemSpace(memSpace, savData)
 	
-	for offs := 0; offs < 0x4000; offs++ {
-		if bank == 0 {
-			bank = 1
-		}
-		srcAddr := int(bank) * 0x4000 + offs
-		destAddr := 0x4000 + offs
-		memSpace[destAddr] = pokéRom[srcAddr]
-	}
+	recording, err := recordSprite(loadProfile(), memSpace, savData, uint8(bank), int(addr), int(width), int(height))
+	handle(err)
+	memSpace = replaySprite(recording, memSpace, savData, uint8(bank))
 	
-	recording := decomp.RecordDecompressSprite(memSpace, int(addr), int(width), int(height))
-	recording.ApplyRecording(&memSpace)
+	err = dumpBin("decompressed.bin", &memSpace)
+	handle(err)
+	
+	log.Println("Done.")
+}
+
+func decompressSpeciesSprite(speciesArg, savPath string) {
+	species, err := strconv.ParseUint(strings.TrimPrefix(strings.ToLower(speciesArg), "0x"), 16, 8)
+	handle(err)
+	
+	savData, err := readSavFile(savPath)
+	handle(err)
+	
+	memSpace := make([]byte, 65536)
+	
+	prepareMemSpace(memSpace, savData)
+	
+	sprite, recording, err := recordSpeciesSprite(loadProfile(), memSpace, savData, uint8(species))
+	handle(err)
+	log.Printf("Species 0x%02x: sprite at %02x:%04x, base data dimensions %dx%d\n",
+	           sprite.Species, sprite.Bank, spritePic(sprite), sprite.BaseWidth(), sprite.BaseHeight())
+	
+	memSpace = replaySprite(recording, memSpace, savData, sprite.Bank)
 	
 	err = dumpBin("decompressed.bin", &memSpace)
 	handle(err)
@@ -139,7 +250,83 @@ Generates the following files in the current directory:
 	log.Println("Done.")
 }
 
+// recordSpeciesSprite looks up the sprite of a species and records its
+// decompression on a copy of memSpace with the sprite's ROM bank mapped in.
+// On decoding errors, the partial recording is returned along with the error.
+func recordSpeciesSprite(profile *romdata.Profile, memSpace, savData []byte, species uint8) (*romdata.SpeciesSprite, *decomp.RecordedDecompression, error) {
+	loadGameData()
+	sprite, err := profile.LookupSpeciesSprite(pokéRom, species)
+	if err != nil {
+		return nil, nil, err
+	}
+	
+	if !encounterWrites {
+		recording, err := recordSprite(profile, memSpace, savData, sprite.Bank, int(spritePic(sprite)), sprite.BaseWidth(), sprite.BaseHeight())
+		return sprite, recording, err
+	}
+	
+	// the encounter's writes come first, and the sprite reads see them
+	encounterMem := make([]byte, len(memSpace))
+	copy(encounterMem, memSpace)
+	encounter, err := decomp.RecordEncounter(profile, pokéRom, gbmem.Flat(encounterMem), species)
+	if err != nil {
+		return sprite, nil, err
+	}
+	recording, err := recordSprite(profile, encounterMem, savData, sprite.Bank, int(spritePic(sprite)), sprite.BaseWidth(), sprite.BaseHeight())
+	if recording != nil {
+		recording = encounter.WithSprite(recording)
+	}
+	return sprite, recording, err
+}
+
+// spritePic returns the pointer to the front sprite of a species, or to its
+// back sprite with --back.
+func spritePic(sprite *romdata.SpeciesSprite) uint16 {
+	if backSprites {
+		return sprite.BackPic
+	}
+	return sprite.FrontPic
+}
+
+// recordSprite records the decompression of the sprite at addr over a copy of
+// memSpace with the given ROM bank mapped in. On decoding errors, the partial
+// recording is returned along with the error. With --banked, savData supplies
+// SRAM banks 1-3.
+func recordSprite(profile *romdata.Profile, memSpace, savData []byte, bank uint8, addr, width, height int) (*decomp.RecordedDecompression, error) {
+	if bankedReads {
+		space := newBankedSpace(memSpace, savData, bank)
+		if backSprites {
+			return decomp.TryRecordDecompressBackSpriteOn(profile, space, addr)
+		}
+		return decomp.TryRecordDecompressSpriteOn(profile, space, addr, width, height)
+	}
+	
+	spriteMem := make([]byte, len(memSpace))
+	copy(spriteMem, memSpace)
+	mapRomBank(spriteMem, int(bank))
+	
+	if backSprites {
+		return decomp.TryRecordDecompressBackSprite(profile, spriteMem, addr)
+	}
+	return decomp.TryRecordDecompressSprite(profile, spriteMem, addr, width, height)
+}
+
+// replaySprite applies a recording made by recordSprite over memSpace, and
+// returns the resulting memory space.
+func replaySprite(recording *decomp.RecordedDecompression, memSpace, savData []byte, bank uint8) []byte {
+	if bankedReads {
+		space := newBankedSpace(memSpace, savData, bank)
+		recording.ReplayRecording(space)
+		return space.Image()
+	}
+	
+	mapRomBank(memSpace, int(bank))
+	recording.ApplyRecording(&memSpace)
+	return memSpace
+}
+
 func prepareMemSpace(memSpace []byte, savData []byte) {
+	loadGameData()
 	for offs := 0; offs < 0x2000; offs++ {
 		srcAddr := offs
 		destAddr := 0xa000 + offs
@@ -166,6 +353,18 @@ func prepareMemSpace(memSpace []byte, savData []byte) {
 	}
 }
 
+func mapRomBank(memSpace []byte, bank int) {
+	loadGameData()
+	if bank == 0 {
+		bank = 1
+	}
+	for offs := 0; offs < 0x4000; offs++ {
+		srcAddr := bank * 0x4000 + offs
+		destAddr := 0x4000 + offs
+		memSpace[destAddr] = pokéRom[srcAddr]
+	}
+}
+
 func readSavFile(path string) ([]byte, error) {
 	savFile, err := os.Open(path)
 	defer func() {
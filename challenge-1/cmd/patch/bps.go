@@ -0,0 +1,192 @@
+/*
+ * fools2024-solutions: source code for Kagamiin's solutions for TheZZAZZGlitch April Fools Event 2024's Security Testing Program.
+ * Copyright (C) 2024 Kagamiin~
+ * 
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License,
+ * or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program. If not, see <https://www.gnu.org/licenses/>.
+ */
+
+package patch
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"hash/crc32"
+)
+
+var bpsMagic = []byte("BPS1")
+
+// Actions of a BPS patch.
+const (
+	bpsSourceRead = iota
+	bpsTargetRead
+	bpsSourceCopy
+	bpsTargetCopy
+)
+
+func writeNumber(patch *bytes.Buffer, n uint64) {
+	for {
+		x := byte(n & 0x7f)
+		n >>= 7
+		if n == 0 {
+			patch.WriteByte(0x80 | x)
+			return
+		}
+		patch.WriteByte(x)
+		n--
+	}
+}
+
+func readNumber(patch []byte, at *int) (uint64, error) {
+	var n uint64
+	shift := uint64(1)
+	for {
+		if *at >= len(patch) {
+			return 0, fmt.Errorf("BPS: %w: number cut off", ErrBadPatch)
+		}
+		x := patch[*at]
+		*at++
+		n += uint64(x & 0x7f) * shift
+		if x & 0x80 != 0 {
+			return n, nil
+		}
+		shift <<= 7
+		n += shift
+	}
+}
+
+// BPS makes a BPS patch turning source into target. Only bytes that changed are
+// stored, and everything else is read from the source at the same offset.
+func BPS(source, target []byte) []byte {
+	var patch bytes.Buffer
+	patch.Write(bpsMagic)
+	writeNumber(&patch, uint64(len(source)))
+	writeNumber(&patch, uint64(len(target)))
+	writeNumber(&patch, 0)
+
+	same := func(offset int) bool {
+		return offset < len(source) && source[offset] == target[offset]
+	}
+	for offset := 0; offset < len(target); {
+		end := offset
+		if same(offset) {
+			for end < len(target) && same(end) {
+				end++
+			}
+			writeNumber(&patch, uint64(end - offset - 1) << 2 | bpsSourceRead)
+		} else {
+			for end < len(target) && !same(end) {
+				end++
+			}
+			writeNumber(&patch, uint64(end - offset - 1) << 2 | bpsTargetRead)
+			patch.Write(target[offset:end])
+		}
+		offset = end
+	}
+
+	binary.Write(&patch, binary.LittleEndian, crc32.ChecksumIEEE(source))
+	binary.Write(&patch, binary.LittleEndian, crc32.ChecksumIEEE(target))
+	binary.Write(&patch, binary.LittleEndian, crc32.ChecksumIEEE(patch.Bytes()))
+	return patch.Bytes()
+}
+
+// ApplyBPS applies a BPS patch, checking all three of its checksums.
+func ApplyBPS(source, patch []byte) ([]byte, error) {
+	if !bytes.HasPrefix(patch, bpsMagic) || len(patch) < len(bpsMagic) + 12 {
+		return nil, fmt.Errorf("BPS: %w: no BPS1 header", ErrBadPatch)
+	}
+	footer := patch[len(patch) - 12:]
+	if crc32.ChecksumIEEE(patch[:len(patch) - 4]) != binary.LittleEndian.Uint32(footer[8:]) {
+		return nil, fmt.Errorf("BPS: %w: patch checksum mismatch", ErrBadPatch)
+	}
+	if crc32.ChecksumIEEE(source) != binary.LittleEndian.Uint32(footer[0:]) {
+		return nil, fmt.Errorf("BPS: source checksum mismatch, the patch is for another file")
+	}
+	actions := patch[:len(patch) - 12]
+
+	at := len(bpsMagic)
+	sourceSize, err := readNumber(actions, &at)
+	if err != nil {
+		return nil, err
+	}
+	targetSize, err := readNumber(actions, &at)
+	if err != nil {
+		return nil, err
+	}
+	metadataSize, err := readNumber(actions, &at)
+	if err != nil {
+		return nil, err
+	}
+	if sourceSize != uint64(len(source)) {
+		return nil, fmt.Errorf("BPS: expected a %d byte source, got %d bytes", sourceSize, len(source))
+	}
+	at += int(metadataSize)
+
+	target := make([]byte, 0, targetSize)
+	var sourceRelative, targetRelative int64
+	for at < len(actions) {
+		action, err := readNumber(actions, &at)
+		if err != nil {
+			return nil, err
+		}
+		length := int(action >> 2) + 1
+		switch action & 3 {
+		case bpsSourceRead:
+			if len(target) + length > len(source) {
+				return nil, fmt.Errorf("BPS: %w: source read past the end", ErrBadPatch)
+			}
+			target = append(target, source[len(target):len(target) + length]...)
+		case bpsTargetRead:
+			if at + length > len(actions) {
+				return nil, fmt.Errorf("BPS: %w: target read cut off", ErrBadPatch)
+			}
+			target = append(target, actions[at:at + length]...)
+			at += length
+		case bpsSourceCopy, bpsTargetCopy:
+			data, err := readNumber(actions, &at)
+			if err != nil {
+				return nil, err
+			}
+			delta := int64(data >> 1)
+			if data & 1 != 0 {
+				delta = -delta
+			}
+			if action & 3 == bpsSourceCopy {
+				sourceRelative += delta
+				if sourceRelative < 0 || sourceRelative + int64(length) > int64(len(source)) {
+					return nil, fmt.Errorf("BPS: %w: source copy out of range", ErrBadPatch)
+				}
+				target = append(target, source[sourceRelative:sourceRelative + int64(length)]...)
+				sourceRelative += int64(length)
+			} else {
+				targetRelative += delta
+				if targetRelative < 0 || targetRelative >= int64(len(target)) {
+					return nil, fmt.Errorf("BPS: %w: target copy out of range", ErrBadPatch)
+				}
+				// the copy can overlap what it's writing
+				for i := 0; i < length; i++ {
+					target = append(target, target[targetRelative])
+					targetRelative++
+				}
+			}
+		}
+	}
+	if uint64(len(target)) != targetSize {
+		return nil, fmt.Errorf("BPS: %w: expected a %d byte target, got %d bytes", ErrBadPatch, targetSize, len(target))
+	}
+	if crc32.ChecksumIEEE(target) != binary.LittleEndian.Uint32(footer[4:]) {
+		return nil, fmt.Errorf("BPS: target checksum mismatch")
+	}
+	return target, nil
+}
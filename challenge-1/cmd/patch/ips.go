@@ -0,0 +1,109 @@
+/*
+ * fools2024-solutions: source code for Kagamiin's solutions for TheZZAZZGlitch April Fools Event 2024's Security Testing Program.
+ * Copyright (C) 2024 Kagamiin~
+ * 
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License,
+ * or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program. If not, see <https://www.gnu.org/licenses/>.
+ */
+
+package patch
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+)
+
+var ipsMagic = []byte("PATCH")
+var ipsFooter = []byte("EOF")
+
+const (
+	ipsMaxOffset = 0xffffff
+	ipsMaxRecord = 0xffff
+	// A record starting here would be read as the footer.
+	ipsFooterOffset = 0x454f46
+)
+
+var ErrBadPatch = errors.New("malformed patch")
+
+// IPS makes an IPS patch turning original into modified. IPS can't shrink a
+// file, so modified must be at least as long as original.
+func IPS(original, modified []byte) ([]byte, error) {
+	if len(modified) < len(original) {
+		return nil, fmt.Errorf("IPS can't truncate a %d byte file to %d bytes", len(original), len(modified))
+	}
+	if len(modified) > ipsMaxOffset {
+		return nil, fmt.Errorf("IPS can't address a %d byte file", len(modified))
+	}
+	var patch bytes.Buffer
+	patch.Write(ipsMagic)
+	for offset := 0; offset < len(modified); {
+		if offset < len(original) && original[offset] == modified[offset] {
+			offset++
+			continue
+		}
+		start := offset
+		if start == ipsFooterOffset {
+			start--
+		}
+		end := offset
+		for end < len(modified) && end - start < ipsMaxRecord && (end >= len(original) || original[end] != modified[end]) {
+			end++
+		}
+		patch.Write([]byte{byte(start >> 16), byte(start >> 8), byte(start)})
+		patch.Write([]byte{byte((end - start) >> 8), byte(end - start)})
+		patch.Write(modified[start:end])
+		offset = end
+	}
+	patch.Write(ipsFooter)
+	return patch.Bytes(), nil
+}
+
+// ApplyIPS applies an IPS patch, RLE records included.
+func ApplyIPS(original, patch []byte) ([]byte, error) {
+	if !bytes.HasPrefix(patch, ipsMagic) {
+		return nil, fmt.Errorf("IPS: %w: no PATCH header", ErrBadPatch)
+	}
+	result := append([]byte(nil), original...)
+	write := func(offset int, data []byte) {
+		if offset + len(data) > len(result) {
+			result = append(result, make([]byte, offset + len(data) - len(result))...)
+		}
+		copy(result[offset:], data)
+	}
+	for at := len(ipsMagic); ; {
+		if bytes.Equal(patch[at:], ipsFooter) {
+			return result, nil
+		}
+		if at + 5 > len(patch) {
+			return nil, fmt.Errorf("IPS: %w: record cut off at 0x%x", ErrBadPatch, at)
+		}
+		offset := int(patch[at]) << 16 | int(patch[at + 1]) << 8 | int(patch[at + 2])
+		size := int(patch[at + 3]) << 8 | int(patch[at + 4])
+		at += 5
+		if size == 0 {
+			if at + 3 > len(patch) {
+				return nil, fmt.Errorf("IPS: %w: RLE record cut off at 0x%x", ErrBadPatch, at)
+			}
+			count := int(patch[at]) << 8 | int(patch[at + 1])
+			write(offset, bytes.Repeat(patch[at + 2:at + 3], count))
+			at += 3
+			continue
+		}
+		if at + size > len(patch) {
+			return nil, fmt.Errorf("IPS: %w: record cut off at 0x%x", ErrBadPatch, at)
+		}
+		write(offset, patch[at:at + size])
+		at += size
+	}
+}
@@ -0,0 +1,90 @@
+/*
+ * fools2024-solutions: source code for Kagamiin's solutions for TheZZAZZGlitch April Fools Event 2024's Security Testing Program.
+ * Copyright (C) 2024 Kagamiin~
+ * 
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License,
+ * or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program. If not, see <https://www.gnu.org/licenses/>.
+ */
+
+package patch_test
+
+import (
+	"bytes"
+	"math/rand"
+	"testing"
+
+	"github.com/Kagamiin/fools2024-solutions/challenge-1/cmd/patch"
+)
+
+func testFiles() (original, modified []byte) {
+	rng := rand.New(rand.NewSource(2))
+	original = make([]byte, 0x8000)
+	rng.Read(original)
+	modified = append([]byte(nil), original...)
+	// scattered changes, a long run, and changes at both ends
+	for i := 0; i < 50; i++ {
+		modified[rng.Intn(len(modified))] ^= uint8(rng.Intn(255) + 1)
+	}
+	for i := 0x1000; i < 0x1300; i++ {
+		modified[i] = ^original[i]
+	}
+	modified[0] ^= 1
+	modified[len(modified) - 1] ^= 1
+	return
+}
+
+func Test_IPSRoundTrip(t *testing.T) {
+	original, modified := testFiles()
+	ips, err := patch.IPS(original, modified)
+	if err != nil {
+		t.Fatal(err)
+	}
+	result, err := patch.ApplyIPS(original, ips)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(result, modified) {
+		t.Error("applying the IPS patch doesn't give back the modified file")
+	}
+
+	extended, err := patch.IPS(original[:0x100], modified)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result, err = patch.ApplyIPS(original[:0x100], extended); err != nil || !bytes.Equal(result, modified) {
+		t.Errorf("applying an IPS patch that extends the file failed: %v", err)
+	}
+	if _, err = patch.IPS(modified, original[:0x100]); err == nil {
+		t.Error("expected an error for an IPS patch truncating the file")
+	}
+}
+
+func Test_BPSRoundTrip(t *testing.T) {
+	original, modified := testFiles()
+	bps := patch.BPS(original, modified)
+	result, err := patch.ApplyBPS(original, bps)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(result, modified) {
+		t.Error("applying the BPS patch doesn't give back the modified file")
+	}
+
+	if _, err = patch.ApplyBPS(modified, bps); err == nil {
+		t.Error("expected an error for a BPS patch applied to the wrong source")
+	}
+	bps[10] ^= 1
+	if _, err = patch.ApplyBPS(original, bps); err == nil {
+		t.Error("expected an error for a corrupted BPS patch")
+	}
+}
@@ -0,0 +1,102 @@
+/*
+ * fools2024-solutions: source code for Kagamiin's solutions for TheZZAZZGlitch April Fools Event 2024's Security Testing Program.
+ * Copyright (C) 2024 Kagamiin~
+ * 
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License,
+ * or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program. If not, see <https://www.gnu.org/licenses/>.
+ */
+
+package main
+
+import (
+	"flag"
+	"fmt"
+	"image/png"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/Kagamiin/fools2024-solutions/challenge-1/cmd/romdata"
+	"github.com/Kagamiin/fools2024-solutions/challenge-1/cmd/spriteimg"
+)
+
+func exportPng() {
+	flags := flag.NewFlagSet("--png", flag.ExitOnError)
+	paletteName := flags.String("palette", "dmg", "palette to use: " + strings.Join(spriteimg.PaletteNames(), ", "))
+	scale := flags.Int("scale", 1, "integer factor to scale the image up by")
+	outPath := flags.String("o", "sprite.png", "path of the PNG file to write")
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), `usage: 
+	%v (--png|-p) [options] decompressed.bin [width height]
+
+decompressed.bin: memory image left by --decompress, with the interlaced sprite in the profile's sprite buffer 1
+width: width of the sprite in its base data, in tiles (omit to export the whole 7x7 tile area)
+height: height of the sprite in its base data, in tiles (omit to export the whole 7x7 tile area)
+Generates the following files in the current directory:
+- sprite.png: contains the sprite image (unless changed with -o)
+
+options:
+`, os.Args[0])
+		flags.PrintDefaults()
+	}
+	handle(flags.Parse(os.Args[2:]))
+	
+	if flags.NArg() != 1 && flags.NArg() != 3 {
+		flags.Usage()
+		os.Exit(1)
+	}
+	
+	palette, ok := spriteimg.Palettes[*paletteName]
+	if !ok {
+		log.Fatalf("unknown palette %q", *paletteName)
+	}
+	
+	memSpace, err := os.ReadFile(flags.Arg(0))
+	handle(err)
+	if len(memSpace) != 65536 {
+		log.Fatalf("expected a 65536 byte memory image, got %d bytes", len(memSpace))
+	}
+	
+	// the interlaced sprite spans sprite buffers 1 and 2
+	spriteStart := int(loadProfile().SpriteBuffers[1])
+	tiles := memSpace[spriteStart:spriteStart + 2 * romdata.SpriteBufferSize]
+	widthTiles := 7
+	heightTiles := 7
+	if flags.NArg() == 3 {
+		width, err := strconv.ParseInt(flags.Arg(1), 16, 64)
+		handle(err)
+		height, err := strconv.ParseInt(flags.Arg(2), 16, 64)
+		handle(err)
+		
+		widthTiles = int(width)
+		heightTiles = int(height)
+		tiles, err = spriteimg.CropAligned(tiles, widthTiles, heightTiles)
+		handle(err)
+	}
+	
+	img, err := spriteimg.Render(tiles, widthTiles, heightTiles, *scale, palette)
+	handle(err)
+	
+	f, err := os.Create(*outPath)
+	handle(err)
+	defer func() {
+		ec := f.Close()
+		handle(ec)
+	}()
+	
+	err = png.Encode(f, img)
+	handle(err)
+	
+	log.Println("Done.")
+}
@@ -0,0 +1,127 @@
+/*
+ * fools2024-solutions: source code for Kagamiin's solutions for TheZZAZZGlitch April Fools Event 2024's Security Testing Program.
+ * Copyright (C) 2024 Kagamiin~
+ * 
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License,
+ * or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program. If not, see <https://www.gnu.org/licenses/>.
+ */
+
+package romdata
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"strings"
+)
+
+type CartHeader struct {
+	Title string
+	NewLicensee string
+	CartType uint8
+	ROMSize uint8
+	RAMSize uint8
+	// Destination is 0 for Japan and 1 for everywhere else.
+	Destination uint8
+	OldLicensee uint8
+	Version uint8
+	HeaderChecksum uint8
+	GlobalChecksum uint16
+}
+
+// ParseHeader reads the cartridge header at 0x0134-0x014f of a ROM.
+func ParseHeader(rom []byte) (*CartHeader, error) {
+	if len(rom) < 0x150 {
+		return nil, fmt.Errorf("ROM too small to hold a cartridge header")
+	}
+	return &CartHeader{
+		Title: strings.TrimRight(string(rom[0x134:0x144]), "\x00"),
+		NewLicensee: string(rom[0x144:0x146]),
+		CartType: rom[0x147],
+		ROMSize: rom[0x148],
+		RAMSize: rom[0x149],
+		Destination: rom[0x14a],
+		OldLicensee: rom[0x14b],
+		Version: rom[0x14c],
+		HeaderChecksum: rom[0x14d],
+		GlobalChecksum: uint16(rom[0x14e]) << 8 | uint16(rom[0x14f]),
+	}, nil
+}
+
+func (h *CartHeader) Japanese() bool {
+	return h.Destination == 0
+}
+
+// Licensee returns the licensee code, which is in the new licensee field if
+// the old one is 0x33.
+func (h *CartHeader) Licensee() string {
+	if h.OldLicensee == 0x33 {
+		return h.NewLicensee
+	}
+	return fmt.Sprintf("%02X", h.OldLicensee)
+}
+
+func (h *CartHeader) String() string {
+	return fmt.Sprintf("%q, licensee %s, version %d, header checksum 0x%02x, global checksum 0x%04x",
+	                   h.Title, h.Licensee(), h.Version, h.HeaderChecksum, h.GlobalChecksum)
+}
+
+// HeaderChecksum computes the checksum of the cartridge header, the same way
+// the boot ROM does before starting the game.
+func HeaderChecksum(rom []byte) uint8 {
+	var checksum uint8
+	for _, b := range rom[0x134:0x14d] {
+		checksum = checksum - b - 1
+	}
+	return checksum
+}
+
+// GlobalChecksum computes the sum of all bytes in the ROM except the global
+// checksum itself. Nothing checks it on real hardware, but a mismatch is a
+// good sign of a bad or modified dump.
+func GlobalChecksum(rom []byte) uint16 {
+	var checksum uint16
+	for i, b := range rom {
+		if i != 0x14e && i != 0x14f {
+			checksum += uint16(b)
+		}
+	}
+	return checksum
+}
+
+type KnownDump struct {
+	SHA1 string
+	Profile string
+	Description string
+}
+
+var KnownRoms = []KnownDump{
+	{"ea9bcae617fdf159b045185467ae58b2e4a48b9a", "red-en", "Pokémon Red (UE) [S]"},
+	{"d7037c83e1ae5b39bde3c30787637ba1d4c48ce2", "blue-en", "Pokémon Blue (UE) [S]"},
+}
+
+var KnownRamDumps = []KnownDump{
+	{"4cef0a20ee784887dc1352d3e860b95b94426153", "blue-en", "pokeblue-ram.dmp from the challenge 1 solution"},
+}
+
+// IdentifyDump looks for data among the given known dumps by its SHA-1 hash.
+func IdentifyDump(known []KnownDump, data []byte) *KnownDump {
+	hash := sha1.Sum(data)
+	digest := hex.EncodeToString(hash[:])
+	for i := range known {
+		if known[i].SHA1 == digest {
+			return &known[i]
+		}
+	}
+	return nil
+}
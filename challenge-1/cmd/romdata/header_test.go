@@ -0,0 +1,73 @@
+/*
+ * fools2024-solutions: source code for Kagamiin's solutions for TheZZAZZGlitch April Fools Event 2024's Security Testing Program.
+ * Copyright (C) 2024 Kagamiin~
+ * 
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License,
+ * or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program. If not, see <https://www.gnu.org/licenses/>.
+ */
+
+package romdata_test
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"testing"
+
+	"github.com/Kagamiin/fools2024-solutions/challenge-1/cmd/romdata"
+)
+
+func Test_ParseHeader(t *testing.T) {
+	rom := testRom("POKEMON BLUE")
+	rom[0x14b] = 0x01
+	rom[0x14d] = romdata.HeaderChecksum(rom)
+	globalChecksum := romdata.GlobalChecksum(rom)
+	rom[0x14e] = uint8(globalChecksum >> 8)
+	rom[0x14f] = uint8(globalChecksum)
+
+	header, err := romdata.ParseHeader(rom)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if header.Title != "POKEMON BLUE" || header.Japanese() || header.Licensee() != "01" {
+		t.Errorf("unexpected header %v", header)
+	}
+	if header.HeaderChecksum != romdata.HeaderChecksum(rom) {
+		t.Errorf("header checksum 0x%02x doesn't match 0x%02x", header.HeaderChecksum, romdata.HeaderChecksum(rom))
+	}
+	// the global checksum doesn't cover itself
+	if header.GlobalChecksum != romdata.GlobalChecksum(rom) {
+		t.Errorf("global checksum 0x%04x doesn't match 0x%04x", header.GlobalChecksum, romdata.GlobalChecksum(rom))
+	}
+
+	rom[0x140]++
+	if romdata.HeaderChecksum(rom) == header.HeaderChecksum {
+		t.Error("header checksum didn't change with the title")
+	}
+
+	if _, err := romdata.ParseHeader(rom[:0x100]); err == nil {
+		t.Error("expected an error for a truncated ROM")
+	}
+}
+
+func Test_IdentifyDump(t *testing.T) {
+	data := []byte("not a ROM")
+	if dump := romdata.IdentifyDump(romdata.KnownRoms, data); dump != nil {
+		t.Errorf("unexpectedly identified data as %s", dump.Description)
+	}
+
+	hash := sha1.Sum(data)
+	known := []romdata.KnownDump{{hex.EncodeToString(hash[:]), "blue-en", "test dump"}}
+	if dump := romdata.IdentifyDump(known, data); dump == nil || dump.Description != "test dump" {
+		t.Errorf("expected data to be identified as the test dump, got %v", dump)
+	}
+}
@@ -0,0 +1,166 @@
+/*
+ * fools2024-solutions: source code for Kagamiin's solutions for TheZZAZZGlitch April Fools Event 2024's Security Testing Program.
+ * Copyright (C) 2024 Kagamiin~
+ * 
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License,
+ * or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program. If not, see <https://www.gnu.org/licenses/>.
+ */
+
+package romdata
+
+import (
+	"fmt"
+)
+
+// Wildcard byte for code patterns.
+const wildcard = -1
+
+func matchAt(data []byte, pattern []int, at int) bool {
+	if at < 0 || at + len(pattern) > len(data) {
+		return false
+	}
+	for j, b := range pattern {
+		if b != wildcard && int(data[at + j]) != b {
+			return false
+		}
+	}
+	return true
+}
+
+// findPattern returns the offset of the first match of pattern in data at or
+// after from, or -1.
+func findPattern(data []byte, pattern []int, from int) int {
+	for i := from; i + len(pattern) <= len(data); i++ {
+		if matchAt(data, pattern, i) {
+			return i
+		}
+	}
+	return -1
+}
+
+func homeBank(rom []byte) []byte {
+	return rom[:min(len(rom), 0x4000)]
+}
+
+// locateBaseStats finds the base data tables from GetMonHeader:
+//
+//	dec a / ld bc, BASE_DATA_SIZE / ld hl, BaseStats / call AddNTimes
+//
+// with the bank switched in at the start of the function, and
+//
+//	ld hl, MewBaseStats / ld de, wMonHeader / ld bc, BASE_DATA_SIZE / ld a, BANK(MewBaseStats) / call FarCopyData
+//
+// which doesn't exist in releases that keep Mew in the main table.
+func locateBaseStats(rom []byte) (baseStats, mewBaseStats BankAddr, err error) {
+	home := homeBank(rom)
+	i := findPattern(home, []int{0x3d, 0x01, BaseDataSize, 0x00, 0x21, wildcard, wildcard, 0xcd}, 0)
+	if i < 0 {
+		err = fmt.Errorf("looking for BaseStats: %w", ErrNotFound)
+		return
+	}
+	baseStats.Addr = uint16(home[i + 5]) | uint16(home[i + 6]) << 8
+
+	// ld a, BANK(BaseStats) / ldh [hLoadedROMBank], a / ld [MBC1RomBank], a
+	bankSwitch := []int{0x3e, wildcard, 0xe0, wildcard, 0xea, 0x00, 0x20}
+	bankAt := -1
+	for j := max(0, i - 0x40); j < i; j++ {
+		if matchAt(home, bankSwitch, j) {
+			bankAt = j
+		}
+	}
+	if bankAt < 0 {
+		err = fmt.Errorf("looking for the bank of BaseStats: %w", ErrNotFound)
+		return
+	}
+	baseStats.Bank = home[bankAt + 1]
+
+	i = findPattern(home, []int{0x21, wildcard, wildcard, 0x11, wildcard, wildcard, 0x01, BaseDataSize, 0x00, 0x3e, wildcard, 0xcd}, 0)
+	if i >= 0 {
+		mewBaseStats.Addr = uint16(home[i + 1]) | uint16(home[i + 2]) << 8
+		mewBaseStats.Bank = home[i + 10]
+	}
+	return
+}
+
+// locatePokedexOrder finds the table from IndexToPokedex:
+//
+//	dec a / ld hl, PokedexOrder / ld b, 0 / ld c, a / add hl, bc / ld a, [hl]
+func locatePokedexOrder(rom []byte) (BankAddr, error) {
+	i := findPattern(rom, []int{0x3d, 0x21, wildcard, wildcard, 0x06, 0x00, 0x4f, 0x09, 0x7e}, 0)
+	if i < 0 {
+		return BankAddr{}, fmt.Errorf("looking for PokedexOrder: %w", ErrNotFound)
+	}
+	return BankAddr{
+		Bank: uint8(i / 0x4000),
+		Addr: uint16(rom[i + 2]) | uint16(rom[i + 3]) << 8,
+	}, nil
+}
+
+// locateSpriteBankRules reads the bank rules from the comparison chain in
+// UncompressMonSprite:
+//
+//	ld b, a / cp MEW / ld a, BANK(MewPicFront) / jr z, .GotBank
+//	ld a, b / cp FOSSIL_KABUTOPS / ld a, BANK(FossilKabutopsPic) / jr z, .GotBank
+//	ld a, b / cp TANGELA + 1 / ld a, BANK("Pics 1") / jr c, .GotBank
+//	...
+//	ld a, BANK("Pics 5")
+//	.GotBank
+//
+// Every jump must land right after the last load for the chain to be accepted.
+func locateSpriteBankRules(rom []byte) (SpriteBankRules, error) {
+	home := homeBank(rom)
+	for i := findPattern(home, []int{0x47, 0xfe}, 0); i >= 0; i = findPattern(home, []int{0x47, 0xfe}, i + 1) {
+		if rules, ok := parseSpriteBankRules(home, i + 1); ok {
+			return rules, nil
+		}
+	}
+	return SpriteBankRules{}, fmt.Errorf("looking for the sprite bank rules: %w", ErrNotFound)
+}
+
+func parseSpriteBankRules(home []byte, at int) (SpriteBankRules, bool) {
+	var rules SpriteBankRules
+	var targets []int
+	for {
+		// cp n / ld a, n / jr z|c, e
+		if !matchAt(home, []int{0xfe, wildcard, 0x3e, wildcard, wildcard, wildcard}, at) {
+			return rules, false
+		}
+		if home[at + 4] != 0x28 && home[at + 4] != 0x38 {
+			return rules, false
+		}
+		rules.Rules = append(rules.Rules, BankRule{
+			Species: home[at + 1],
+			Below: home[at + 4] == 0x38,
+			Bank: home[at + 3],
+		})
+		targets = append(targets, at + 6 + int(int8(home[at + 5])))
+		at += 6
+		// ld a, b
+		if at < len(home) && home[at] == 0x78 {
+			at++
+			continue
+		}
+		break
+	}
+	// ld a, n
+	if at + 1 >= len(home) || home[at] != 0x3e || len(rules.Rules) < 3 {
+		return rules, false
+	}
+	rules.Default = home[at + 1]
+	for _, target := range targets {
+		if target != at + 2 {
+			return rules, false
+		}
+	}
+	return rules, true
+}
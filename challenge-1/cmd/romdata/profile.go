@@ -0,0 +1,240 @@
+/*
+ * fools2024-solutions: source code for Kagamiin's solutions for TheZZAZZGlitch April Fools Event 2024's Security Testing Program.
+ * Copyright (C) 2024 Kagamiin~
+ * 
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License,
+ * or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program. If not, see <https://www.gnu.org/licenses/>.
+ */
+
+package romdata
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+type BankAddr struct {
+	Bank uint8
+	Addr uint16
+}
+
+func (b BankAddr) String() string {
+	return fmt.Sprintf("%02x:%04x", b.Bank, b.Addr)
+}
+
+// BankRule is one of the comparisons UncompressMonSprite makes against the
+// species index to pick the bank of its sprite. If Below is set, the rule
+// matches indices below Species, otherwise only Species itself.
+type BankRule struct {
+	Species uint8
+	Below bool
+	Bank uint8
+}
+
+type SpriteBankRules struct {
+	// Rules are tried in order, and the first matching one wins.
+	Rules []BankRule
+	Default uint8
+}
+
+func (s *SpriteBankRules) Bank(species uint8) uint8 {
+	for _, rule := range s.Rules {
+		if (rule.Below && species < rule.Species) || (!rule.Below && species == rule.Species) {
+			return rule.Bank
+		}
+	}
+	return s.Default
+}
+
+// Profile holds the version specific data needed to reproduce the sprite
+// decompression of a given release of the game. Table locations and bank
+// rules left zero are unknown for the release, and get located by looking at
+// the ROM's code with Complete.
+type Profile struct {
+	Name string
+	Description string
+	// Title and Japanese are matched against the cartridge header.
+	Title string
+	Japanese bool
+	SpriteBuffers [3]uint16
+	BaseStats BankAddr
+	// MewBaseStats is only set for releases where GetMonHeader reads Mew's
+	// base data from a separate entry.
+	MewBaseStats BankAddr
+	PokedexOrder BankAddr
+	SpriteBanks SpriteBankRules
+	Symbols map[string]uint16
+}
+
+var gen1SpriteBuffers = [3]uint16{0xa000, 0xa188, 0xa310}
+
+// SpriteBufferSize is the size of each sprite buffer, holding 7x7 tiles of a
+// single bitplane.
+const SpriteBufferSize = 0x188
+
+var redBlueSpriteBanks = SpriteBankRules{
+	Rules: []BankRule{
+		{SpeciesMew, false, 0x01},
+		{SpeciesFossilKabutops, false, 0x0b},
+		{0x1f, true, 0x09},
+		{0x4a, true, 0x0a},
+		{0x74, true, 0x0b},
+		{0x99, true, 0x0c},
+	},
+	Default: 0x0d,
+}
+
+var redBlueSymbols = map[string]uint16{
+	"wAudioROMBank": 0xc0ef,
+	"wcf91": 0xcf91,
+	"wMonHeader": 0xd0b8,
+	"wMonHSpriteDim": 0xd0c2,
+	"wMonHFrontSprite": 0xd0c3,
+	"wd11e": 0xd11e,
+	"wPokedexOwned": 0xd2f7,
+	"wPokedexSeen": 0xd30a,
+	"wNumBagItems": 0xd31d,
+	"wBagItems": 0xd31e,
+}
+
+func redBlueProfile(name, description, title string) *Profile {
+	return &Profile{
+		Name: name,
+		Description: description,
+		Title: title,
+		SpriteBuffers: gen1SpriteBuffers,
+		BaseStats: BankAddr{0x0e, 0x43de},
+		MewBaseStats: BankAddr{0x01, 0x425b},
+		PokedexOrder: BankAddr{0x10, 0x5024},
+		SpriteBanks: redBlueSpriteBanks,
+		Symbols: redBlueSymbols,
+	}
+}
+
+// locatedProfile returns a profile whose tables and bank rules are located
+// from the ROM's code by Complete. Its WRAM symbols are unknown.
+func locatedProfile(name, description, title string, japanese bool) *Profile {
+	return &Profile{
+		Name: name,
+		Description: description,
+		Title: title,
+		Japanese: japanese,
+		SpriteBuffers: gen1SpriteBuffers,
+	}
+}
+
+// Profiles lists the releases sharing the sprite decompression code of Red and
+// Blue. Only the English ones have their WRAM symbols known.
+var Profiles = map[string]*Profile{
+	"red-en": redBlueProfile("red-en", "Pokémon Red (English)", "POKEMON RED"),
+	"blue-en": redBlueProfile("blue-en", "Pokémon Blue (English)", "POKEMON BLUE"),
+	"red-jp": locatedProfile("red-jp", "Pocket Monsters Aka (Japanese)", "POKEMON RED", true),
+	"green-jp": locatedProfile("green-jp", "Pocket Monsters Midori (Japanese)", "POKEMON GREEN", true),
+	"blue-jp": locatedProfile("blue-jp", "Pocket Monsters Ao (Japanese)", "POKEMON BLUE", true),
+	"red-fr": locatedProfile("red-fr", "Pokémon Rouge (French)", "POKEMON RED", false),
+	"blue-fr": locatedProfile("blue-fr", "Pokémon Bleue (French)", "POKEMON BLUE", false),
+	"red-de": locatedProfile("red-de", "Pokémon Rote Edition (German)", "POKEMON RED", false),
+	"blue-de": locatedProfile("blue-de", "Pokémon Blaue Edition (German)", "POKEMON BLUE", false),
+	"red-it": locatedProfile("red-it", "Pokémon Rosso (Italian)", "POKEMON RED", false),
+	"blue-it": locatedProfile("blue-it", "Pokémon Blu (Italian)", "POKEMON BLUE", false),
+	"red-es": locatedProfile("red-es", "Pokémon Rojo (Spanish)", "POKEMON RED", false),
+	"blue-es": locatedProfile("blue-es", "Pokémon Azul (Spanish)", "POKEMON BLUE", false),
+}
+
+// DefaultProfile is the release the challenge was made for.
+var DefaultProfile = Profiles["blue-en"]
+
+func ProfileNames() []string {
+	names := make([]string, 0, len(Profiles))
+	for name := range Profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func LookupProfile(name string) (*Profile, error) {
+	profile, ok := Profiles[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown profile %q (known profiles: %s)", name, strings.Join(ProfileNames(), ", "))
+	}
+	return profile, nil
+}
+
+// DetectProfile picks a profile from the title and destination code in the
+// ROM's cartridge header. The European releases share their header with the
+// English ones, so such a ROM can't be told apart and is an error.
+func DetectProfile(rom []byte) (*Profile, error) {
+	header, err := ParseHeader(rom)
+	if err != nil {
+		return nil, err
+	}
+
+	matches := make([]string, 0)
+	for _, name := range ProfileNames() {
+		if Profiles[name].Matches(header) {
+			matches = append(matches, name)
+		}
+	}
+	switch len(matches) {
+	case 0:
+		return nil, fmt.Errorf("no profile matches ROM title %q", header.Title)
+	case 1:
+		return Profiles[matches[0]], nil
+	}
+	return nil, fmt.Errorf("ROM title %q doesn't tell profiles %s apart", header.Title, strings.Join(matches, ", "))
+}
+
+// Matches tells whether the cartridge header is compatible with the profile.
+func (p *Profile) Matches(header *CartHeader) bool {
+	return p.Title == header.Title && p.Japanese == header.Japanese()
+}
+
+// Symbol returns the address of a WRAM symbol for the profile's release.
+func (p *Profile) Symbol(name string) (uint16, error) {
+	addr, ok := p.Symbols[name]
+	if !ok {
+		return 0, fmt.Errorf("address of %s is unknown for profile %s", name, p.Name)
+	}
+	return addr, nil
+}
+
+// Complete returns a copy of the profile where any unknown table locations or
+// bank rules have been located from the ROM's code.
+func (p *Profile) Complete(rom []byte) (*Profile, error) {
+	completed := *p
+	if completed.BaseStats.Addr == 0 {
+		baseStats, mewBaseStats, err := locateBaseStats(rom)
+		if err != nil {
+			return nil, fmt.Errorf("profile %s: %w", p.Name, err)
+		}
+		completed.BaseStats = baseStats
+		completed.MewBaseStats = mewBaseStats
+	}
+	if completed.PokedexOrder.Addr == 0 {
+		pokedexOrder, err := locatePokedexOrder(rom)
+		if err != nil {
+			return nil, fmt.Errorf("profile %s: %w", p.Name, err)
+		}
+		completed.PokedexOrder = pokedexOrder
+	}
+	if len(completed.SpriteBanks.Rules) == 0 {
+		spriteBanks, err := locateSpriteBankRules(rom)
+		if err != nil {
+			return nil, fmt.Errorf("profile %s: %w", p.Name, err)
+		}
+		completed.SpriteBanks = spriteBanks
+	}
+	return &completed, nil
+}
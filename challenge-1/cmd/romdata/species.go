@@ -0,0 +1,182 @@
+/*
+ * fools2024-solutions: source code for Kagamiin's solutions for TheZZAZZGlitch April Fools Event 2024's Security Testing Program.
+ * Copyright (C) 2024 Kagamiin~
+ * 
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License,
+ * or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program. If not, see <https://www.gnu.org/licenses/>.
+ */
+
+package romdata
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+)
+
+const BaseDataSize = 28
+
+// Offsets of the sprite fields within a base data entry.
+const (
+	baseDataSpriteDim = 10
+	baseDataFrontPic = 11
+	baseDataBackPic = 13
+)
+
+// Species indices that GetMonHeader and UncompressMonSprite handle specially.
+const (
+	SpeciesMew = 0x15
+	SpeciesFossilKabutops = 0xb6
+	SpeciesFossilAerodactyl = 0xb7
+	SpeciesMonGhost = 0xb8
+)
+
+var ErrNotFound = errors.New("pattern not found in ROM")
+
+type SpeciesSprite struct {
+	Species uint8
+	// DexNumber is the result of IndexToPokedex, or 0 for the fossils and the
+	// ghost, which never go through it.
+	DexNumber uint8
+	// Location of the base data entry the sprite fields were read from. Zero
+	// for the fossils and the ghost, whose sprite fields are hardcoded.
+	HeaderBank uint8
+	HeaderAddr uint16
+	Dimensions uint8
+	FrontPic uint16
+	// BackPic is meaningless for the fossils and the ghost, as GetMonHeader
+	// doesn't write it for them.
+	BackPic uint16
+	Bank uint8
+}
+
+// BaseWidth returns the width of the sprite in its base data, in tiles, the
+// way LoadUncompressedSpriteData reads it.
+func (s *SpeciesSprite) BaseWidth() int {
+	return int(s.Dimensions & 0xf)
+}
+
+// BaseHeight returns the height of the sprite in its base data, in tiles, the
+// way LoadUncompressedSpriteData reads it.
+func (s *SpeciesSprite) BaseHeight() int {
+	return int(s.Dimensions >> 4)
+}
+
+// ReadBanked reads a byte from the given address, with the given ROM bank
+// switched in. Addresses past the end of rom read as 0xff, like open bus.
+func ReadBanked(rom []byte, bank uint8, addr uint16) uint8 {
+	offset := int(addr)
+	if addr >= 0x4000 {
+		offset = int(bank) * 0x4000 + int(addr) - 0x4000
+	}
+	if offset >= len(rom) {
+		return 0xff
+	}
+	return rom[offset]
+}
+
+func readBankedWord(rom []byte, bank uint8, addr uint16) uint16 {
+	return uint16(ReadBanked(rom, bank, addr)) | uint16(ReadBanked(rom, bank, addr + 1)) << 8
+}
+
+// IndexToPokedex converts a species index to a Pokédex number the way the
+// game does, reading past the end of the table for glitch indices.
+func (p *Profile) IndexToPokedex(rom []byte, species uint8) uint8 {
+	return ReadBanked(rom, p.PokedexOrder.Bank, p.PokedexOrder.Addr + uint16(species - 1))
+}
+
+// SpriteBank returns the ROM bank UncompressMonSprite switches to before
+// decompressing the front sprite of the given species.
+func (p *Profile) SpriteBank(species uint8) uint8 {
+	return p.SpriteBanks.Bank(species)
+}
+
+// LookupSpeciesSprite resolves the front sprite of the given species the same
+// way GetMonHeader and UncompressMonSprite do. The profile must have been
+// completed for the ROM beforehand if any of its tables are unknown.
+func (p *Profile) LookupSpeciesSprite(rom []byte, species uint8) (*SpeciesSprite, error) {
+	if p.BaseStats.Addr == 0 || p.PokedexOrder.Addr == 0 || len(p.SpriteBanks.Rules) == 0 {
+		return nil, fmt.Errorf("profile %s has unknown table locations", p.Name)
+	}
+	sprite := SpeciesSprite{
+		Species: species,
+		Bank: p.SpriteBank(species),
+	}
+
+	switch {
+	case species == SpeciesFossilKabutops || species == SpeciesMonGhost || species == SpeciesFossilAerodactyl:
+		pics, err := findSpecialPics(rom)
+		if err != nil {
+			return nil, err
+		}
+		special := pics[species]
+		sprite.Dimensions = special.dimensions
+		sprite.FrontPic = special.pic
+		return &sprite, nil
+	case species == SpeciesMew && p.MewBaseStats.Addr != 0:
+		sprite.HeaderBank = p.MewBaseStats.Bank
+		sprite.HeaderAddr = p.MewBaseStats.Addr
+		sprite.DexNumber = ReadBanked(rom, sprite.HeaderBank, sprite.HeaderAddr)
+	default:
+		sprite.HeaderBank = p.BaseStats.Bank
+		sprite.DexNumber = p.IndexToPokedex(rom, species)
+		// AddNTimes wraps around at 16 bits
+		sprite.HeaderAddr = p.BaseStats.Addr + uint16(sprite.DexNumber - 1) * BaseDataSize
+	}
+
+	sprite.Dimensions = ReadBanked(rom, sprite.HeaderBank, sprite.HeaderAddr + baseDataSpriteDim)
+	sprite.FrontPic = readBankedWord(rom, sprite.HeaderBank, sprite.HeaderAddr + baseDataFrontPic)
+	sprite.BackPic = readBankedWord(rom, sprite.HeaderBank, sprite.HeaderAddr + baseDataBackPic)
+	return &sprite, nil
+}
+
+type specialPic struct {
+	dimensions uint8
+	pic uint16
+}
+
+// findSpecialPics finds the sprite pointers GetMonHeader hardcodes for the
+// fossils and the ghost, by looking for its comparisons in the home bank:
+//
+//	ld de, FossilKabutopsPic / ld b, $66 / cp FOSSIL_KABUTOPS / jr z
+//	ld de, GhostPic / cp MON_GHOST / jr z
+//	ld de, FossilAerodactylPic / ld b, $77 / cp FOSSIL_AERODACTYL / jr z
+func findSpecialPics(rom []byte) (map[uint8]specialPic, error) {
+	home := rom[:min(len(rom), 0x4000)]
+	patterns := []struct {
+		species uint8
+		code []byte
+		dimensions uint8
+	}{
+		{SpeciesFossilKabutops, []byte{0x06, 0x66, 0xfe, SpeciesFossilKabutops, 0x28}, 0x66},
+		{SpeciesMonGhost, []byte{0xfe, SpeciesMonGhost, 0x28}, 0x66},
+		{SpeciesFossilAerodactyl, []byte{0x06, 0x77, 0xfe, SpeciesFossilAerodactyl, 0x28}, 0x77},
+	}
+
+	pics := make(map[uint8]specialPic)
+	from := 0
+	for _, pattern := range patterns {
+		i := bytes.Index(home[from:], pattern.code)
+		// the pattern must be preceded by ld de, nnnn
+		if i < 0 || from + i < 3 || home[from + i - 3] != 0x11 {
+			return nil, fmt.Errorf("looking for the sprite of species 0x%02x: %w", pattern.species, ErrNotFound)
+		}
+		i += from
+		pics[pattern.species] = specialPic{
+			dimensions: pattern.dimensions,
+			pic: uint16(home[i - 2]) | uint16(home[i - 1]) << 8,
+		}
+		from = i + len(pattern.code)
+	}
+	return pics, nil
+}
@@ -0,0 +1,197 @@
+/*
+ * fools2024-solutions: source code for Kagamiin's solutions for TheZZAZZGlitch April Fools Event 2024's Security Testing Program.
+ * Copyright (C) 2024 Kagamiin~
+ * 
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License,
+ * or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program. If not, see <https://www.gnu.org/licenses/>.
+ */
+
+package romdata_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/Kagamiin/fools2024-solutions/challenge-1/cmd/romdata"
+)
+
+func bankedOffset(bank uint8, addr uint16) int {
+	return int(bank) * 0x4000 + int(addr) - 0x4000
+}
+
+func putBaseData(rom []byte, bank uint8, addr uint16, dex, dimensions uint8, frontPic uint16) {
+	offset := bankedOffset(bank, addr)
+	rom[offset] = dex
+	rom[offset + 10] = dimensions
+	rom[offset + 11] = uint8(frontPic)
+	rom[offset + 12] = uint8(frontPic >> 8)
+}
+
+// spriteBankCode assembles the comparison chain of UncompressMonSprite for
+// the given rules.
+func spriteBankCode(rules romdata.SpriteBankRules) []byte {
+	code := []byte{0x47}
+	var jumps []int
+	for i, rule := range rules.Rules {
+		if i > 0 {
+			code = append(code, 0x78)
+		}
+		jr := byte(0x28)
+		if rule.Below {
+			jr = 0x38
+		}
+		code = append(code, 0xfe, rule.Species, 0x3e, rule.Bank, jr, 0x00)
+		jumps = append(jumps, len(code) - 1)
+	}
+	code = append(code, 0x3e, rules.Default)
+	for _, jump := range jumps {
+		code[jump] = byte(len(code) - (jump + 1))
+	}
+	return code
+}
+
+// testRom builds a ROM with just enough of the species data and of the code
+// reading it for sprite lookups and profile completion to work.
+func testRom(title string) []byte {
+	rom := make([]byte, 0x100000)
+	profile := romdata.DefaultProfile
+	copy(rom[0x134:], title)
+	rom[0x14a] = 0x01
+
+	// Pokédex order: index 0x99 is Bulbasaur, 0x1f is Missingno
+	pokedexOrder := bankedOffset(profile.PokedexOrder.Bank, profile.PokedexOrder.Addr)
+	rom[pokedexOrder + 0x99 - 1] = 1
+	rom[pokedexOrder + 0x1f - 1] = 0
+
+	putBaseData(rom, profile.BaseStats.Bank, profile.BaseStats.Addr, 1, 0x55, 0x5345)
+	putBaseData(rom, profile.BaseStats.Bank, profile.BaseStats.Addr + 0xff * romdata.BaseDataSize, 0, 0x88, 0x1900)
+	putBaseData(rom, profile.MewBaseStats.Bank, profile.MewBaseStats.Addr, 151, 0x55, 0x4000)
+
+	getMonHeader := []byte{
+		0xf0, 0xb8, 0xf5, 0x3e, profile.BaseStats.Bank, 0xe0, 0xb8, 0xea, 0x00, 0x20,
+		0x11, 0x34, 0x12, 0x06, 0x66, 0xfe, 0xb6, 0x28, 0x10,
+		0x11, 0x78, 0x56, 0xfe, 0xb8, 0x28, 0x0b,
+		0x11, 0xbc, 0x9a, 0x06, 0x77, 0xfe, 0xb7, 0x28, 0x04,
+		0xfe, 0x15, 0x28, 0x10,
+		0x3d, 0x01, 0x1c, 0x00, 0x21, uint8(profile.BaseStats.Addr), uint8(profile.BaseStats.Addr >> 8), 0xcd, 0x00, 0x00,
+		0x21, uint8(profile.MewBaseStats.Addr), uint8(profile.MewBaseStats.Addr >> 8), 0x11, 0xb8, 0xd0,
+		0x01, 0x1c, 0x00, 0x3e, profile.MewBaseStats.Bank, 0xcd, 0x00, 0x00,
+	}
+	copy(rom[0x1537:], getMonHeader)
+	copy(rom[0x1665:], spriteBankCode(profile.SpriteBanks))
+
+	indexToPokedex := []byte{0xc5, 0xe5, 0xfa, 0x1e, 0xd1, 0x3d, 0x21,
+	                         uint8(profile.PokedexOrder.Addr), uint8(profile.PokedexOrder.Addr >> 8),
+	                         0x06, 0x00, 0x4f, 0x09, 0x7e, 0xea, 0x1e, 0xd1, 0xe1, 0xc1, 0xc9}
+	copy(rom[bankedOffset(profile.PokedexOrder.Bank, 0x7000):], indexToPokedex)
+	return rom
+}
+
+func Test_LookupSpeciesSprite(t *testing.T) {
+	rom := testRom("POKEMON BLUE")
+	cases := []struct {
+		species uint8
+		dex uint8
+		dimensions uint8
+		frontPic uint16
+		bank uint8
+	}{
+		{0x99, 1, 0x55, 0x5345, 0x0d},
+		{0x1f, 0, 0x88, 0x1900, 0x0a},
+		{romdata.SpeciesMew, 151, 0x55, 0x4000, 0x01},
+		{romdata.SpeciesFossilKabutops, 0, 0x66, 0x1234, 0x0b},
+		{romdata.SpeciesMonGhost, 0, 0x66, 0x5678, 0x0d},
+		{romdata.SpeciesFossilAerodactyl, 0, 0x77, 0x9abc, 0x0d},
+	}
+	for _, c := range cases {
+		sprite, err := romdata.DefaultProfile.LookupSpeciesSprite(rom, c.species)
+		if err != nil {
+			t.Fatalf("species 0x%02x: %v", c.species, err)
+		}
+		if sprite.DexNumber != c.dex || sprite.Dimensions != c.dimensions || sprite.FrontPic != c.frontPic || sprite.Bank != c.bank {
+			t.Errorf("species 0x%02x: got %+v", c.species, sprite)
+		}
+	}
+}
+
+func Test_LookupSpeciesSpriteMissingCode(t *testing.T) {
+	rom := make([]byte, 0x100000)
+	if _, err := romdata.DefaultProfile.LookupSpeciesSprite(rom, romdata.SpeciesMonGhost); err == nil {
+		t.Error("expected an error for a ROM without GetMonHeader")
+	}
+}
+
+func Test_SpriteBank(t *testing.T) {
+	cases := map[uint8]uint8{
+		0x00: 0x09, 0x1e: 0x09, 0x1f: 0x0a, 0x49: 0x0a, 0x4a: 0x0b, 0x73: 0x0b,
+		0x74: 0x0c, 0x98: 0x0c, 0x99: 0x0d, 0xff: 0x0d,
+		romdata.SpeciesMew: 0x01, romdata.SpeciesFossilKabutops: 0x0b,
+	}
+	for species, bank := range cases {
+		if got := romdata.DefaultProfile.SpriteBank(species); got != bank {
+			t.Errorf("species 0x%02x: expected bank 0x%02x, got 0x%02x", species, bank, got)
+		}
+	}
+}
+
+func Test_CompleteProfile(t *testing.T) {
+	rom := testRom("POKEMON BLUE")
+	profile := romdata.Profiles["blue-it"]
+	if _, err := profile.LookupSpeciesSprite(rom, 0x99); err == nil {
+		t.Error("expected an error when looking up sprites with an incomplete profile")
+	}
+
+	completed, err := profile.Complete(rom)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := romdata.DefaultProfile
+	if completed.BaseStats != expected.BaseStats || completed.MewBaseStats != expected.MewBaseStats || completed.PokedexOrder != expected.PokedexOrder {
+		t.Errorf("located tables %v %v %v, expected %v %v %v", completed.BaseStats, completed.MewBaseStats, completed.PokedexOrder,
+		         expected.BaseStats, expected.MewBaseStats, expected.PokedexOrder)
+	}
+	if !reflect.DeepEqual(completed.SpriteBanks, expected.SpriteBanks) {
+		t.Errorf("located bank rules %+v, expected %+v", completed.SpriteBanks, expected.SpriteBanks)
+	}
+	if len(profile.SpriteBanks.Rules) != 0 {
+		t.Error("Complete modified the original profile")
+	}
+}
+
+func Test_DetectProfile(t *testing.T) {
+	cases := map[string]string{"POKEMON RED": "red-jp", "POKEMON GREEN": "green-jp", "POKEMON BLUE": "blue-jp"}
+	for title, name := range cases {
+		rom := testRom(title)
+		rom[0x14a] = 0
+		profile, err := romdata.DetectProfile(rom)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if profile.Name != name {
+			t.Errorf("%s: expected profile %s, got %s", title, name, profile.Name)
+		}
+	}
+
+	if _, err := romdata.DetectProfile(testRom("POKEMON BLUE")); err == nil {
+		t.Error("expected an error for a title shared by the English and European releases")
+	}
+	if _, err := romdata.DetectProfile(testRom("POKEMON GREEN")); err == nil {
+		t.Error("expected an error for a release without a profile")
+	}
+	if _, err := romdata.DetectProfile(testRom("POKEMON YELLOW")); err == nil {
+		t.Error("expected an error for a release without a profile")
+	}
+	if _, err := romdata.DetectProfile(testRom("TETRIS")); err == nil {
+		t.Error("expected an error for an unknown game")
+	}
+}
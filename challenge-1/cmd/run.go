@@ -0,0 +1,259 @@
+/*
+ * fools2024-solutions: source code for Kagamiin's solutions for TheZZAZZGlitch April Fools Event 2024's Security Testing Program.
+ * Copyright (C) 2024 Kagamiin~
+ * 
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License,
+ * or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program. If not, see <https://www.gnu.org/licenses/>.
+ */
+
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/Kagamiin/fools2024-solutions/challenge-1/cmd/gen1sav"
+	"github.com/Kagamiin/fools2024-solutions/challenge-1/cmd/romdata"
+	"github.com/Kagamiin/fools2024-solutions/challenge-1/cmd/sm83"
+)
+
+// Longest string read for a single text trap.
+const maxTrappedText = 1024
+
+// <DONE> and <PROMPT> end a string like the terminator, but also end the text
+// PrintText is running.
+const (
+	textDone = 0x57
+	textPrompt = 0x58
+)
+
+// Routines that wait for frames, input or sound, which can't finish without
+// interrupts, and are returned from right away by default.
+var defaultSkippedRoutines = []string{
+	"DelayFrame", "DelayFrames", "Delay3", "WaitForTextScrollButtonPress",
+	"ManualTextScroll", "PlaySound", "WaitForSoundToFinish", "UpdateSprites",
+}
+
+// textRunner captures the text the code prints through the traps.
+type textRunner struct {
+	output strings.Builder
+}
+
+// readString reads text up to its terminator, <DONE> or <PROMPT>, reporting
+// whether it was one of the latter.
+func (t *textRunner) readString(addr uint16, read func(uint16) uint8) (string, uint16, bool) {
+	data := make([]byte, 0, 32)
+	done := false
+	for len(data) < maxTrappedText {
+		b := read(addr)
+		addr++
+		if b == gen1sav.TextTerminator {
+			break
+		}
+		if b == textDone || b == textPrompt {
+			done = true
+			break
+		}
+		data = append(data, b)
+	}
+	text, _, _ := gen1sav.DecodeText(data)
+	return text, addr, done
+}
+
+func (t *textRunner) print(text string) {
+	t.output.WriteString(text)
+	log.Printf("Printed %q\n", text)
+}
+
+// placeString stands in for PlaceString, which prints the string at hl.
+func (t *textRunner) placeString(c *sm83.CPU) (bool, error) {
+	text, _, _ := t.readString(c.HL(), c.Memory.Read)
+	t.print(text + "\n")
+	c.Ret()
+	return false, nil
+}
+
+// printText stands in for PrintText, running the text commands at hl. Text
+// commands that run code make the code run instead of returning.
+func (t *textRunner) printText(c *sm83.CPU) (bool, error) {
+	addr := c.HL()
+	read := c.Memory.Read
+	for {
+		command := read(addr)
+		addr++
+		switch command {
+		case 0x00:
+			var text string
+			var done bool
+			text, addr, done = t.readString(addr, read)
+			t.print(text + "\n")
+			if done {
+				c.Ret()
+				return false, nil
+			}
+		case 0x01:
+			text, _, done := t.readString(uint16(read(addr)) | uint16(read(addr + 1)) << 8, read)
+			t.print(text)
+			if done {
+				c.Ret()
+				return false, nil
+			}
+			addr += 2
+		case 0x02, 0x09:
+			log.Printf("Skipping number printing text command 0x%02x\n", command)
+			addr += 3
+		case 0x03:
+			addr += 2
+		case 0x04:
+			addr += 4
+		case 0x08:
+			c.PC = addr
+			return false, nil
+		case 0x0c:
+			addr++
+		case 0x17:
+			far := uint16(read(addr)) | uint16(read(addr + 1)) << 8
+			bank := read(addr + 2)
+			addr += 3
+			text, _, done := t.readString(far, func(a uint16) uint8 {
+				return romdata.ReadBanked(pokéRom, bank, a)
+			})
+			t.print(text + "\n")
+			if done {
+				c.Ret()
+				return false, nil
+			}
+		case gen1sav.TextTerminator:
+			c.Ret()
+			return false, nil
+		default:
+			if command > 0x17 {
+				return true, fmt.Errorf("unknown text command 0x%02x at 0x%04x", command, addr - 1)
+			}
+		}
+	}
+}
+
+func runPayload() {
+	flags := flag.NewFlagSet("run", flag.ExitOnError)
+	entryArg := flags.String("entry", "a7d0", "address (hex) to start running at")
+	bankArg := flags.String("bank", "1", "ROM bank (hex) mapped in when the code starts")
+	savPath := flags.String("sav", "", "save file holding SRAM banks 1-3 (default: result.bin, if it's a save file)")
+	symPath := flags.String("sym", "", "pokered .sym file to find the text routines and the overworld loop in (required)")
+	maxCycles := flags.Uint64("cycles", 10000000, "maximum number of machine cycles to run for")
+	skipArg := flags.String("skip", strings.Join(defaultSkippedRoutines, ","), "comma separated routines to return from right away")
+	trace := flags.Bool("trace", false, "log every instruction run")
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), `usage: 
+	%v run [options] result.bin
+
+result.bin: memory image holding the code, such as the one recovered by undo
+
+Runs the code at the entry address in an SM83 interpreter, over the memory
+image and the ROM, with SRAM enabled. Calls to PlaceString and PrintText are
+trapped to print their text, and TextBoxBorder is skipped. Running stops when
+the code returns to the overworld loop or to where it was called from, or after
+the cycle limit.
+
+options:
+`, os.Args[0])
+		flags.PrintDefaults()
+	}
+	handle(flags.Parse(os.Args[2:]))
+	
+	if flags.NArg() != 1 || *symPath == "" {
+		flags.Usage()
+		os.Exit(1)
+	}
+	
+	entry, err := strconv.ParseUint(*entryArg, 16, 16)
+	handle(err)
+	bank, err := strconv.ParseUint(*bankArg, 16, 8)
+	handle(err)
+	memSpace, err := loadMemImage(flags.Arg(0))
+	handle(err)
+	savData, err := loadSRAMBanks(*savPath, flags.Arg(0))
+	handle(err)
+	symFile, err := os.Open(*symPath)
+	handle(err)
+	symbols, err := sm83.ReadSymbols(symFile)
+	symFile.Close()
+	handle(err)
+	
+	cpu := &sm83.CPU{
+		Memory: newBankedSpace(memSpace, savData, uint8(bank)),
+		// wStack
+		SP: 0xdfff,
+		PC: uint16(entry),
+	}
+	runner := &textRunner{}
+	stop := func(c *sm83.CPU) (bool, error) {
+		return true, nil
+	}
+	skip := func(c *sm83.CPU) (bool, error) {
+		c.Ret()
+		return false, nil
+	}
+	traps := make(map[uint16]sm83.Trap)
+	addTrap := func(name string, trap sm83.Trap) {
+		trapBank, addr, ok := symbols.Lookup(name)
+		if !ok {
+			log.Printf("Warning: %s isn't in %s, not trapping it\n", name, *symPath)
+			return
+		}
+		if addr >= 0x4000 {
+			log.Printf("Warning: %s is in bank 0x%02x, not trapping it\n", name, trapBank)
+			return
+		}
+		traps[addr] = trap
+	}
+	for _, name := range strings.Split(*skipArg, ",") {
+		if name != "" {
+			addTrap(name, skip)
+		}
+	}
+	addTrap("PlaceString", runner.placeString)
+	addTrap("PrintText", runner.printText)
+	addTrap("TextBoxBorder", skip)
+	addTrap("OverworldLoop", stop)
+	
+	// return to the overworld loop, or to a sentinel if it's unknown
+	_, returnAddr, ok := symbols.Lookup("OverworldLoop")
+	if !ok {
+		returnAddr = 0xfffe
+		traps[returnAddr] = stop
+	}
+	cpu.Push(returnAddr)
+	
+	if *trace {
+		cpu.Trace = func(c *sm83.CPU) {
+			code := []byte{c.Memory.Read(c.PC), c.Memory.Read(c.PC + 1), c.Memory.Read(c.PC + 2)}
+			log.Printf("%04x: %s (a=%02x f=%02x bc=%04x de=%04x hl=%04x sp=%04x)\n",
+			           c.PC, sm83.Decode(code, c.PC, nil).Text, c.A, c.F, c.BC(), c.DE(), c.HL(), c.SP)
+		}
+	}
+	
+	err = cpu.Run(traps, *maxCycles)
+	if errors.Is(err, sm83.ErrCycleLimit) {
+		log.Printf("Warning: %v\n", err)
+	} else if err != nil {
+		log.Printf("Error: %v\n", err)
+	}
+	log.Printf("Stopped at 0x%04x after %d cycles\n", cpu.PC, cpu.Cycles)
+	fmt.Print(runner.output.String())
+}
@@ -0,0 +1,127 @@
+/*
+ * fools2024-solutions: source code for Kagamiin's solutions for TheZZAZZGlitch April Fools Event 2024's Security Testing Program.
+ * Copyright (C) 2024 Kagamiin~
+ * 
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License,
+ * or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program. If not, see <https://www.gnu.org/licenses/>.
+ */
+
+package savestate
+
+import (
+	"encoding/binary"
+	"fmt"
+
+	"github.com/Kagamiin/fools2024-solutions/challenge-1/cmd/gbmem"
+)
+
+// SameBoy stores its states in its own format followed by BESS blocks, which
+// any emulator can read. The file ends with the offset of the first block and
+// the BESS magic. Each block is a 4 character ID and a 32-bit size, followed
+// by its contents; memory contents are pointed to by the CORE block.
+var bessMagic = []byte("BESS")
+
+// Offsets within the CORE block
+const (
+	bessCoreIE = 0x15
+	bessCoreIO = 0x18
+	bessCoreRAM = 0x98
+	bessCoreVRAM = 0xa0
+	bessCoreMBCRAM = 0xa8
+	bessCoreOAM = 0xb0
+	bessCoreHRAM = 0xb8
+	bessCoreSize = 0xd0
+)
+
+func readBESS(data []byte) (*State, error) {
+	if len(data) < 8 {
+		return nil, ErrUnknownFormat
+	}
+	footer := len(data) - 8
+	offset := int(binary.LittleEndian.Uint32(data[footer:]))
+	state := State{
+		Format: "BESS",
+	}
+
+	var core []byte
+	for offset < footer {
+		if offset + 8 > footer {
+			return nil, fmt.Errorf("BESS block header at 0x%x runs past the end of the file", offset)
+		}
+		id := string(data[offset:offset + 4])
+		size := int(binary.LittleEndian.Uint32(data[offset + 4:]))
+		offset += 8
+		if offset + size > footer {
+			return nil, fmt.Errorf("BESS block %q at 0x%x runs past the end of the file", id, offset - 8)
+		}
+		block := data[offset:offset + size]
+		offset += size
+
+		switch id {
+		case "CORE":
+			core = block
+		case "INFO":
+			if len(block) >= 0x10 {
+				state.Title = trimTitle(block[:0x10])
+			}
+		case "MBC ":
+			// a list of register writes
+			state.MBC = &gbmem.MBC1{}
+			for i := 0; i + 3 <= len(block); i += 3 {
+				state.MBC.Write(binary.LittleEndian.Uint16(block[i:]), block[i + 2])
+			}
+		case "END ":
+			offset = footer
+		}
+	}
+	if len(core) < bessCoreSize {
+		return nil, fmt.Errorf("BESS state without a valid CORE block")
+	}
+
+	region := func(at int) ([]byte, error) {
+		size := int(binary.LittleEndian.Uint32(core[at:]))
+		start := int(binary.LittleEndian.Uint32(core[at + 4:]))
+		if start + size > len(data) {
+			return nil, fmt.Errorf("BESS memory region at 0x%x runs past the end of the file", start)
+		}
+		return data[start:start + size], nil
+	}
+	var err error
+	if state.WRAM, err = region(bessCoreRAM); err != nil {
+		return nil, err
+	}
+	if state.VRAM, err = region(bessCoreVRAM); err != nil {
+		return nil, err
+	}
+	if state.SRAM, err = region(bessCoreMBCRAM); err != nil {
+		return nil, err
+	}
+	if state.OAM, err = region(bessCoreOAM); err != nil {
+		return nil, err
+	}
+	if state.HRAM, err = region(bessCoreHRAM); err != nil {
+		return nil, err
+	}
+	state.IO = core[bessCoreIO:bessCoreIO + IOSize]
+	state.IE = core[bessCoreIE]
+	// SVBK
+	state.WRAMBank = int(state.IO[0x70] & 0x07)
+	if len(state.WRAM) <= WRAMBankSize * 2 {
+		state.WRAMBank = 1
+	}
+	if len(state.SRAM) == 0 {
+		state.SRAM = nil
+	}
+
+	return &state, state.check()
+}
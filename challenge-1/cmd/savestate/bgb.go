@@ -0,0 +1,108 @@
+/*
+ * fools2024-solutions: source code for Kagamiin's solutions for TheZZAZZGlitch April Fools Event 2024's Security Testing Program.
+ * Copyright (C) 2024 Kagamiin~
+ * 
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License,
+ * or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program. If not, see <https://www.gnu.org/licenses/>.
+ */
+
+package savestate
+
+import (
+	"encoding/binary"
+	"fmt"
+
+	"github.com/Kagamiin/fools2024-solutions/challenge-1/cmd/gbmem"
+)
+
+// BGB states are a list of named blocks, each made of a NUL-terminated name,
+// a 32-bit size and the block's contents, starting with the emulator name.
+var bgbFirstBlock = []byte("NAME\x00")
+
+func isBGBState(data []byte) bool {
+	return len(data) >= len(bgbFirstBlock) && string(data[:len(bgbFirstBlock)]) == string(bgbFirstBlock)
+}
+
+func readBGBBlocks(data []byte) (map[string][]byte, error) {
+	blocks := make(map[string][]byte)
+	for offset := 0; offset < len(data); {
+		end := offset
+		for end < len(data) && data[end] != 0 {
+			end++
+		}
+		if end + 5 > len(data) {
+			return nil, fmt.Errorf("BGB block header at 0x%x runs past the end of the file", offset)
+		}
+		name := string(data[offset:end])
+		size := int(binary.LittleEndian.Uint32(data[end + 1:]))
+		offset = end + 5
+		if offset + size > len(data) {
+			return nil, fmt.Errorf("BGB block %q runs past the end of the file", name)
+		}
+		blocks[name] = data[offset:offset + size]
+		offset += size
+	}
+	return blocks, nil
+}
+
+func readBGB(data []byte) (*State, error) {
+	blocks, err := readBGBBlocks(data)
+	if err != nil {
+		return nil, err
+	}
+	state := State{
+		Format: "BGB",
+		Title: trimTitle(blocks["ROMNAME"]),
+		WRAM: blocks["WRAM"],
+		VRAM: blocks["VRAM"],
+		OAM: blocks["OAM"],
+		HRAM: blocks["HRAM"],
+		IO: blocks["IOREGS"],
+		SRAM: blocks["SRAM"],
+	}
+	if len(state.IO) < IOSize {
+		return nil, fmt.Errorf("BGB state: expected %d bytes of I/O registers, got %d", IOSize, len(state.IO))
+	}
+	if ie := blocks["IE"]; len(ie) > 0 {
+		state.IE = ie[0]
+	}
+	if bank := blocks["WRAMBANK"]; len(bank) > 0 {
+		state.WRAMBank = int(bank[0] & 0x07)
+	}
+	if len(state.SRAM) == 0 {
+		state.SRAM = nil
+	}
+
+	if romBank, ok := blocks["ROMBANK"]; ok && len(romBank) > 0 {
+		// the MBC1 registers, as BGB exposes them
+		bank := int(romBank[0])
+		if len(romBank) > 1 {
+			bank |= int(romBank[1]) << 8
+		}
+		state.MBC = &gbmem.MBC1{
+			Bank1: uint8(bank & 0x1f),
+			Bank2: uint8(bank >> 5) & 0x03,
+		}
+		if enable := blocks["SRAMENABLE"]; len(enable) > 0 {
+			state.MBC.RAMEnable = enable[0] != 0
+		}
+		if mode := blocks["MBC1MODE"]; len(mode) > 0 {
+			state.MBC.Mode = mode[0] & 0x01
+		}
+		if sramBank := blocks["SRAMBANK"]; len(sramBank) > 0 && state.MBC.Mode == 1 {
+			state.MBC.Bank2 = sramBank[0] & 0x03
+		}
+	}
+
+	return &state, state.check()
+}
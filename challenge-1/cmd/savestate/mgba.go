@@ -0,0 +1,128 @@
+/*
+ * fools2024-solutions: source code for Kagamiin's solutions for TheZZAZZGlitch April Fools Event 2024's Security Testing Program.
+ * Copyright (C) 2024 Kagamiin~
+ * 
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License,
+ * or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program. If not, see <https://www.gnu.org/licenses/>.
+ */
+
+package savestate
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
+	"fmt"
+	"io"
+
+	"github.com/Kagamiin/fools2024-solutions/challenge-1/cmd/gbmem"
+)
+
+// mGBA writes its states either raw, or embedded in a screenshot as a
+// zlib-compressed PNG chunk.
+var pngSignature = []byte("\x89PNG\r\n\x1a\n")
+
+const mgbaStateChunk = "gbAs"
+
+// Layout of a Game Boy state from mGBA, as documented in its
+// include/mgba/internal/gb/serialize.h. It holds no SRAM, which mGBA keeps in
+// the save file instead.
+const (
+	mgbaMagicMask = 0xffff0000
+	mgbaMagic = 0x00400000
+	mgbaTitle = 0x10
+	// memory state: the mapped ROM, WRAM and SRAM banks, the MBC1 mode and the
+	// flags, whose bit 0 is set while SRAM is enabled
+	mgbaCurrentBank = 0x150
+	mgbaWRAMCurrentBank = 0x152
+	mgbaSRAMCurrentBank = 0x153
+	mgbaMBC1Mode = 0x16c
+	mgbaMemoryFlags = 0x194
+	mgbaIO = 0x400
+	mgbaHRAM = 0x480
+	mgbaIE = 0x4ff
+	mgbaOAM = 0x500
+	mgbaVRAM = 0x1000
+	mgbaVRAMSize = 0x4000
+	mgbaWRAM = 0x5000
+	mgbaWRAMSize = 0x8000
+	mgbaStateSize = mgbaWRAM + mgbaWRAMSize
+)
+
+func isMGBAState(data []byte) bool {
+	return len(data) >= mgbaStateSize && binary.LittleEndian.Uint32(data) & mgbaMagicMask == mgbaMagic
+}
+
+func extractPNGChunk(data []byte, chunkType string) ([]byte, error) {
+	for offset := len(pngSignature); offset + 12 <= len(data); {
+		size := int(binary.BigEndian.Uint32(data[offset:]))
+		name := string(data[offset + 4:offset + 8])
+		if offset + 12 + size > len(data) {
+			break
+		}
+		if name == chunkType {
+			return data[offset + 8:offset + 8 + size], nil
+		}
+		offset += 12 + size
+	}
+	return nil, fmt.Errorf("no %s chunk in PNG savestate", chunkType)
+}
+
+func readMGBA(data []byte) (*State, error) {
+	if bytes.HasPrefix(data, pngSignature) {
+		chunk, err := extractPNGChunk(data, mgbaStateChunk)
+		if err != nil {
+			return nil, err
+		}
+		reader, err := zlib.NewReader(bytes.NewReader(chunk))
+		if err != nil {
+			return nil, err
+		}
+		data, err = io.ReadAll(reader)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if !isMGBAState(data) {
+		return nil, fmt.Errorf("not an mGBA Game Boy state")
+	}
+
+	state := State{
+		Format: "mGBA",
+		Title: trimTitle(data[mgbaTitle:mgbaTitle + 0x10]),
+		IO: data[mgbaIO:mgbaIO + IOSize],
+		IE: data[mgbaIE],
+		HRAM: data[mgbaHRAM:mgbaHRAM + HRAMSize],
+		OAM: data[mgbaOAM:mgbaOAM + OAMSize],
+		VRAM: data[mgbaVRAM:mgbaVRAM + mgbaVRAMSize],
+		WRAM: data[mgbaWRAM:mgbaWRAM + mgbaWRAMSize],
+	}
+	state.WRAMBank = int(data[mgbaWRAMCurrentBank] & 0x07)
+
+	// mGBA keeps the banks it mapped rather than the registers, so rebuild
+	// them: the upper ROM bank bits are Bank2 in mode 0, the SRAM bank is in
+	// mode 1.
+	currentBank := binary.LittleEndian.Uint16(data[mgbaCurrentBank:])
+	mbc := gbmem.MBC1{
+		RAMEnable: data[mgbaMemoryFlags] & 0x01 != 0,
+		Bank1: uint8(currentBank & 0x1f),
+		Bank2: uint8(currentBank >> 5 & 0x03),
+		Mode: data[mgbaMBC1Mode] & 0x01,
+	}
+	if mbc.Mode == 1 {
+		mbc.Bank2 = data[mgbaSRAMCurrentBank] & 0x03
+	}
+	state.MBC = &mbc
+
+	return &state, state.check()
+}
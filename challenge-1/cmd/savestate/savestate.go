@@ -0,0 +1,141 @@
+/*
+ * fools2024-solutions: source code for Kagamiin's solutions for TheZZAZZGlitch April Fools Event 2024's Security Testing Program.
+ * Copyright (C) 2024 Kagamiin~
+ * 
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License,
+ * or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program. If not, see <https://www.gnu.org/licenses/>.
+ */
+
+package savestate
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/Kagamiin/fools2024-solutions/challenge-1/cmd/gbmem"
+)
+
+const (
+	VRAMSize = 0x2000
+	WRAMBankSize = 0x1000
+	HRAMSize = 0x7f
+	OAMSize = 0xa0
+	IOSize = 0x80
+)
+
+var ErrUnknownFormat = errors.New("unknown savestate format")
+
+type State struct {
+	Format string
+	// Title is the ROM title the state was made with, if the format stores it.
+	Title string
+	// VRAM and WRAM hold all banks, although only the first VRAM bank and the
+	// first two WRAM banks are used outside of CGB mode.
+	VRAM []byte
+	WRAM []byte
+	// WRAMBank is the bank mapped at 0xd000-0xdfff.
+	WRAMBank int
+	OAM []byte
+	IO []byte
+	HRAM []byte
+	IE uint8
+	// SRAM holds all banks of cartridge RAM, or is nil if the format doesn't
+	// store it along with the state.
+	SRAM []byte
+	// MBC is nil if the format doesn't store the MBC registers.
+	MBC *gbmem.MBC1
+}
+
+// ReadFile reads a savestate in any of the supported formats.
+func ReadFile(path string) (*State, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	state, err := Read(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return state, nil
+}
+
+// Read detects the format of a savestate and reads it.
+func Read(data []byte) (*State, error) {
+	switch {
+	case bytes.HasSuffix(data, bessMagic):
+		return readBESS(data)
+	case bytes.HasPrefix(data, pngSignature) || isMGBAState(data):
+		return readMGBA(data)
+	case isBGBState(data):
+		return readBGB(data)
+	}
+	return nil, ErrUnknownFormat
+}
+
+func trimTitle(title []byte) string {
+	return strings.TrimRight(string(title), "\x00")
+}
+
+func (s *State) check() error {
+	if len(s.VRAM) < VRAMSize {
+		return fmt.Errorf("%s state: expected at least %d bytes of VRAM, got %d", s.Format, VRAMSize, len(s.VRAM))
+	}
+	if len(s.WRAM) < WRAMBankSize * 2 {
+		return fmt.Errorf("%s state: expected at least %d bytes of WRAM, got %d", s.Format, WRAMBankSize * 2, len(s.WRAM))
+	}
+	if len(s.HRAM) < HRAMSize {
+		return fmt.Errorf("%s state: expected %d bytes of HRAM, got %d", s.Format, HRAMSize, len(s.HRAM))
+	}
+	if s.WRAMBank == 0 {
+		s.WRAMBank = 1
+	}
+	if (s.WRAMBank + 1) * WRAMBankSize > len(s.WRAM) {
+		return fmt.Errorf("%s state: WRAM bank %d out of range", s.Format, s.WRAMBank)
+	}
+	return nil
+}
+
+// MemImage lays out the state as a 64 KiB memory image, the same way as the
+// RAM dumps used as a baseline for decompression. ROM space is left zeroed.
+// SRAM reads as 0xff unless the state has it and it's enabled.
+func (s *State) MemImage() []byte {
+	memSpace := make([]byte, 65536)
+	copy(memSpace[0x8000:0xa000], s.VRAM[:VRAMSize])
+
+	sram := memSpace[0xa000:0xc000]
+	for i := range sram {
+		sram[i] = 0xff
+	}
+	if s.SRAM != nil && (s.MBC == nil || s.MBC.RAMEnable) {
+		bank := 0
+		if s.MBC != nil {
+			bank = s.MBC.SRAMBank()
+		}
+		if (bank + 1) * gbmem.SRAMBankSize <= len(s.SRAM) {
+			copy(sram, s.SRAM[bank * gbmem.SRAMBankSize:])
+		}
+	}
+
+	copy(memSpace[0xc000:0xd000], s.WRAM[:WRAMBankSize])
+	copy(memSpace[0xd000:0xe000], s.WRAM[s.WRAMBank * WRAMBankSize:])
+	// echo RAM
+	copy(memSpace[0xe000:0xfe00], memSpace[0xc000:0xde00])
+	copy(memSpace[0xfe00:0xfea0], s.OAM)
+	copy(memSpace[0xff00:0xff80], s.IO)
+	copy(memSpace[0xff80:0xffff], s.HRAM)
+	memSpace[0xffff] = s.IE
+	return memSpace
+}
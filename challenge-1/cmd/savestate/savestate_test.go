@@ -0,0 +1,218 @@
+/*
+ * fools2024-solutions: source code for Kagamiin's solutions for TheZZAZZGlitch April Fools Event 2024's Security Testing Program.
+ * Copyright (C) 2024 Kagamiin~
+ * 
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License,
+ * or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program. If not, see <https://www.gnu.org/licenses/>.
+ */
+
+package savestate_test
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
+	"errors"
+	"hash/crc32"
+	"testing"
+
+	"github.com/Kagamiin/fools2024-solutions/challenge-1/cmd/savestate"
+)
+
+func filled(size int, value uint8) []byte {
+	return bytes.Repeat([]byte{value}, size)
+}
+
+func appendU32(data []byte, value int) []byte {
+	return binary.LittleEndian.AppendUint32(data, uint32(value))
+}
+
+// checkMemImage checks the memory image of a state whose regions were filled
+// with 0x11 (VRAM), 0x22 (WRAM bank 0), 0x33 (WRAM bank 1), 0x44 (OAM),
+// 0x55 (HRAM) and 0x66 (I/O).
+func checkMemImage(t *testing.T, state *savestate.State, sramValue uint8) {
+	memImage := state.MemImage()
+	expected := map[uint16]uint8{
+		0x8000: 0x11, 0x9fff: 0x11, 0xa000: sramValue, 0xbfff: sramValue,
+		0xc000: 0x22, 0xcfff: 0x22, 0xd000: 0x33, 0xdfff: 0x33,
+		0xe000: 0x22, 0xf000: 0x33, 0xfdff: 0x33,
+		0xfe00: 0x44, 0xfe9f: 0x44, 0xff00: 0x66, 0xff7f: 0x66, 0xff80: 0x55, 0xfffe: 0x55,
+	}
+	for addr, value := range expected {
+		if memImage[addr] != value {
+			t.Errorf("%s: expected 0x%02x at 0x%04x, got 0x%02x", state.Format, value, addr, memImage[addr])
+		}
+	}
+}
+
+func wram() []byte {
+	return append(filled(0x1000, 0x22), filled(0x1000, 0x33)...)
+}
+
+func io() []byte {
+	io := filled(0x80, 0x66)
+	// SVBK
+	io[0x70] = 0x00
+	return io
+}
+
+func Test_ReadBESS(t *testing.T) {
+	// memory regions first, as SameBoy does, then the blocks
+	data := []byte{}
+	regions := [][]byte{wram(), filled(0x2000, 0x11), append(filled(0x2000, 0x77), filled(0x2000, 0x88)...),
+	                    filled(0xa0, 0x44), filled(0x7f, 0x55)}
+	offsets := []int{}
+	for _, region := range regions {
+		offsets = append(offsets, len(data))
+		data = append(data, region...)
+	}
+
+	firstBlock := len(data)
+	data = append(data, "INFO"...)
+	data = appendU32(data, 0x12)
+	data = append(data, "POKEMON BLUE\x00\x00\x00\x00\x9d\x0a"...)
+
+	core := make([]byte, 0xd0)
+	core[0x15] = 0x1f
+	copy(core[0x18:], io())
+	for i, region := range regions {
+		binary.LittleEndian.PutUint32(core[0x98 + i * 8:], uint32(len(region)))
+		binary.LittleEndian.PutUint32(core[0x9c + i * 8:], uint32(offsets[i]))
+	}
+	data = append(data, "CORE"...)
+	data = appendU32(data, len(core))
+	data = append(data, core...)
+
+	// enable SRAM, select SRAM bank 1
+	mbc := []byte{0x00, 0x00, 0x0a, 0x00, 0x60, 0x01, 0x00, 0x40, 0x01, 0x00, 0x20, 0x05}
+	data = append(data, "MBC "...)
+	data = appendU32(data, len(mbc))
+	data = append(data, mbc...)
+	data = append(data, "END "...)
+	data = appendU32(data, 0)
+	data = appendU32(data, firstBlock)
+	data = append(data, "BESS"...)
+
+	state, err := savestate.Read(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if state.Format != "BESS" || state.Title != "POKEMON BLUE" || state.IE != 0x1f {
+		t.Errorf("unexpected state %s %q IE=0x%02x", state.Format, state.Title, state.IE)
+	}
+	if state.MBC == nil || !state.MBC.RAMEnable || state.MBC.SRAMBank() != 1 || state.MBC.ROMBank() != 0x25 {
+		t.Errorf("unexpected MBC state %+v", state.MBC)
+	}
+	checkMemImage(t, state, 0x88)
+}
+
+func bgbBlock(data []byte, name string, contents []byte) []byte {
+	data = append(data, name...)
+	data = append(data, 0)
+	data = appendU32(data, len(contents))
+	return append(data, contents...)
+}
+
+func Test_ReadBGB(t *testing.T) {
+	data := bgbBlock(nil, "NAME", []byte("bgb"))
+	data = bgbBlock(data, "ROMNAME", []byte("POKEMON BLUE"))
+	data = bgbBlock(data, "WRAM", wram())
+	data = bgbBlock(data, "VRAM", filled(0x2000, 0x11))
+	data = bgbBlock(data, "OAM", filled(0xa0, 0x44))
+	data = bgbBlock(data, "HRAM", filled(0x7f, 0x55))
+	data = bgbBlock(data, "IOREGS", io())
+	data = bgbBlock(data, "SRAM", filled(0x8000, 0x77))
+	data = bgbBlock(data, "ROMBANK", []byte{0x0e, 0x00})
+	data = bgbBlock(data, "SRAMENABLE", []byte{0x00})
+
+	state, err := savestate.Read(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if state.Format != "BGB" || state.Title != "POKEMON BLUE" {
+		t.Errorf("unexpected state %s %q", state.Format, state.Title)
+	}
+	if state.MBC == nil || state.MBC.ROMBank() != 0x0e {
+		t.Errorf("unexpected MBC state %+v", state.MBC)
+	}
+	// SRAM is disabled
+	checkMemImage(t, state, 0xff)
+}
+
+// mgbaState builds a state following the offsets listed in mGBA's
+// serialize.h: I/O at 0x400, HRAM at 0x480-0x4fe, the cached IE at 0x4ff,
+// OAM at 0x500, VRAM at 0x1000 and WRAM at 0x5000. The memory state maps
+// ROM bank 0x2e, WRAM bank 1 and SRAM bank 1, with SRAM enabled in mode 1.
+func mgbaState() []byte {
+	data := make([]byte, 0xd000)
+	binary.LittleEndian.PutUint32(data, 0x00400002)
+	copy(data[0x10:], "POKEMON BLUE")
+	binary.LittleEndian.PutUint16(data[0x150:], 0x2e)
+	data[0x152] = 0x01
+	data[0x153] = 0x01
+	data[0x16c] = 0x01
+	data[0x194] = 0x01
+	copy(data[0x400:], io())
+	copy(data[0x480:], filled(0x7f, 0x55))
+	data[0x4ff] = 0x1f
+	copy(data[0x500:], filled(0xa0, 0x44))
+	copy(data[0x1000:], filled(0x4000, 0x11))
+	copy(data[0x5000:], wram())
+	return data
+}
+
+func pngChunk(data []byte, chunkType string, contents []byte) []byte {
+	data = binary.BigEndian.AppendUint32(data, uint32(len(contents)))
+	data = append(data, chunkType...)
+	data = append(data, contents...)
+	return binary.BigEndian.AppendUint32(data, crc32.ChecksumIEEE(append([]byte(chunkType), contents...)))
+}
+
+func Test_ReadMGBA(t *testing.T) {
+	raw := mgbaState()
+
+	var compressed bytes.Buffer
+	writer := zlib.NewWriter(&compressed)
+	writer.Write(raw)
+	writer.Close()
+	png := []byte("\x89PNG\r\n\x1a\n")
+	png = pngChunk(png, "IHDR", make([]byte, 13))
+	png = pngChunk(png, "gbAs", compressed.Bytes())
+	png = pngChunk(png, "IEND", nil)
+
+	for _, data := range [][]byte{raw, png} {
+		state, err := savestate.Read(data)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if state.Format != "mGBA" || state.Title != "POKEMON BLUE" || state.IE != 0x1f || state.SRAM != nil {
+			t.Errorf("unexpected state %s %q", state.Format, state.Title)
+		}
+		if state.MBC == nil || !state.MBC.RAMEnable || state.MBC.ROMBank() != 0x2e || state.MBC.SRAMBank() != 1 {
+			t.Errorf("unexpected MBC state %+v", state.MBC)
+		}
+		if state.WRAMBank != 1 {
+			t.Errorf("expected WRAM bank 1, got %d", state.WRAMBank)
+		}
+		checkMemImage(t, state, 0xff)
+	}
+}
+
+func Test_ReadUnknown(t *testing.T) {
+	if _, err := savestate.Read(make([]byte, 0x100)); err == nil {
+		t.Error("expected an error for an unknown format")
+	}
+	if _, err := savestate.Read([]byte("0BESS")); !errors.Is(err, savestate.ErrUnknownFormat) {
+		t.Errorf("expected ErrUnknownFormat for a truncated BESS footer, got %v", err)
+	}
+}
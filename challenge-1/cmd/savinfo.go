@@ -0,0 +1,144 @@
+/*
+ * fools2024-solutions: source code for Kagamiin's solutions for TheZZAZZGlitch April Fools Event 2024's Security Testing Program.
+ * Copyright (C) 2024 Kagamiin~
+ * 
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License,
+ * or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program. If not, see <https://www.gnu.org/licenses/>.
+ */
+
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/Kagamiin/fools2024-solutions/challenge-1/cmd/gen1sav"
+)
+
+func showSaveInfo() {
+	flags := flag.NewFlagSet("savinfo", flag.ExitOnError)
+	asJSON := flags.Bool("json", false, "print the decoded save file as JSON")
+	recoveredPath := flags.String("recovered", "", "memory image written by undo, whose SRAM replaces bank 0 of the save file")
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), `usage: 
+	%v savinfo [options] pokeblue.sav
+
+pokeblue.sav: save file to decode
+
+Prints the trainer data, items, party, boxes, Hall of Fame and Pokédex of the
+save file, along with the status of each checksum. Species, items, moves and
+maps are shown as their index in hex.
+
+options:
+`, os.Args[0])
+		flags.PrintDefaults()
+	}
+	handle(flags.Parse(os.Args[2:]))
+	
+	if flags.NArg() != 1 {
+		flags.Usage()
+		os.Exit(1)
+	}
+	
+	savData, err := readSavFile(flags.Arg(0))
+	handle(err)
+	if *recoveredPath != "" {
+		memSpace, err := loadMemImage(*recoveredPath)
+		handle(err)
+		copy(savData, memSpace[0xa000:0xc000])
+	}
+	
+	save, err := gen1sav.Parse(savData)
+	handle(err)
+	
+	if *asJSON {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "\t")
+		handle(encoder.Encode(save))
+		return
+	}
+	printSave(save)
+}
+
+func printSave(s *gen1sav.Save) {
+	fmt.Printf("player: %q (ID %05d), rival: %q\n", s.PlayerName, s.PlayerID, s.RivalName)
+	fmt.Printf("money: ¥%d, coins: %d, badges: %08b, options: 0x%02x\n", s.Money, s.Coins, s.Badges, s.Options)
+	fmt.Printf("map: 0x%02x at (%d, %d)\n", s.Map, s.X, s.Y)
+	fmt.Printf("play time: %d:%02d:%02d", s.PlayTime.Hours, s.PlayTime.Minutes, s.PlayTime.Seconds)
+	if s.PlayTime.Maxed {
+		fmt.Print(" (maxed)")
+	}
+	fmt.Printf("\npokédex: %d owned, %d seen\n", len(s.Owned), len(s.Seen))
+	fmt.Printf("  owned: %v\n  seen: %v\n", s.Owned, s.Seen)
+	
+	printItems("bag", s.BagItems)
+	printItems("PC items", s.PCItems)
+	
+	fmt.Printf("\nparty (%d):\n", len(s.Party))
+	printMons(s.Party)
+	for _, box := range s.Boxes {
+		current := ""
+		if box.Current {
+			current = ", current"
+		}
+		fmt.Printf("\nbox %d (%d%s):\n", box.Number, len(box.Mons), current)
+		printMons(box.Mons)
+	}
+	
+	fmt.Printf("\nHall of Fame (%d teams):\n", len(s.HallOfFame))
+	for i, team := range s.HallOfFame {
+		names := make([]string, len(team))
+		for j, mon := range team {
+			names[j] = fmt.Sprintf("0x%02x L%d %q", mon.Species, mon.Level, mon.Nickname)
+		}
+		fmt.Printf("  #%d: %s\n", i + 1, strings.Join(names, ", "))
+	}
+	
+	fmt.Println("\nchecksums:")
+	for _, checksum := range s.Checksums {
+		status := "ok"
+		if !checksum.Valid {
+			status = fmt.Sprintf("BAD, computed 0x%02x", checksum.Computed)
+		}
+		fmt.Printf("  %s: 0x%02x %s\n", checksum.Region, checksum.Stored, status)
+	}
+	
+	if len(s.Warnings) > 0 {
+		fmt.Println("\nwarnings:")
+		for _, warning := range s.Warnings {
+			fmt.Printf("  %s\n", warning)
+		}
+	}
+}
+
+func printItems(list string, items []gen1sav.Item) {
+	fmt.Printf("%s (%d):", list, len(items))
+	for _, item := range items {
+		fmt.Printf(" 0x%02x×%d", item.ID, item.Quantity)
+	}
+	fmt.Println()
+}
+
+func printMons(mons []gen1sav.Mon) {
+	for _, mon := range mons {
+		level := mon.BoxLevel
+		if mon.Party != nil {
+			level = mon.Party.Level
+		}
+		fmt.Printf("  0x%02x L%d %q (OT %q %05d), HP %d, moves % x\n",
+		           mon.Species, level, mon.Nickname, mon.OTName, mon.OTID, mon.HP, mon.Moves[:])
+	}
+}
@@ -0,0 +1,557 @@
+/*
+ * fools2024-solutions: source code for Kagamiin's solutions for TheZZAZZGlitch April Fools Event 2024's Security Testing Program.
+ * Copyright (C) 2024 Kagamiin~
+ * 
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License,
+ * or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program. If not, see <https://www.gnu.org/licenses/>.
+ */
+
+package sm83
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/Kagamiin/fools2024-solutions/challenge-1/cmd/gbmem"
+)
+
+// Flags in the F register.
+const (
+	FlagZ = 0x80
+	FlagN = 0x40
+	FlagH = 0x20
+	FlagC = 0x10
+)
+
+var ErrIllegalOpcode = errors.New("illegal opcode")
+
+// CPU interprets SM83 code over a memory model. Interrupts aren't emulated:
+// halt and stop do nothing, and ei and di only set IME.
+type CPU struct {
+	A, F, B, C, D, E, H, L uint8
+	SP, PC uint16
+	IME bool
+	// Cycles counts the machine cycles taken, 4 clocks each.
+	Cycles uint64
+	Memory gbmem.Memory
+	// Trace is called by Run before every instruction, if not nil.
+	Trace func(c *CPU)
+}
+
+// cycles holds the machine cycles each unprefixed opcode takes, for
+// conditional ones when the condition doesn't hold.
+var cycles = [256]uint8{
+	1, 3, 2, 2, 1, 1, 2, 1, 5, 2, 2, 2, 1, 1, 2, 1,
+	1, 3, 2, 2, 1, 1, 2, 1, 3, 2, 2, 2, 1, 1, 2, 1,
+	2, 3, 2, 2, 1, 1, 2, 1, 2, 2, 2, 2, 1, 1, 2, 1,
+	2, 3, 2, 2, 3, 3, 3, 1, 2, 2, 2, 2, 1, 1, 2, 1,
+	1, 1, 1, 1, 1, 1, 2, 1, 1, 1, 1, 1, 1, 1, 2, 1,
+	1, 1, 1, 1, 1, 1, 2, 1, 1, 1, 1, 1, 1, 1, 2, 1,
+	1, 1, 1, 1, 1, 1, 2, 1, 1, 1, 1, 1, 1, 1, 2, 1,
+	2, 2, 2, 2, 2, 2, 1, 2, 1, 1, 1, 1, 1, 1, 2, 1,
+	1, 1, 1, 1, 1, 1, 2, 1, 1, 1, 1, 1, 1, 1, 2, 1,
+	1, 1, 1, 1, 1, 1, 2, 1, 1, 1, 1, 1, 1, 1, 2, 1,
+	1, 1, 1, 1, 1, 1, 2, 1, 1, 1, 1, 1, 1, 1, 2, 1,
+	1, 1, 1, 1, 1, 1, 2, 1, 1, 1, 1, 1, 1, 1, 2, 1,
+	2, 3, 3, 4, 3, 4, 2, 4, 2, 4, 3, 1, 3, 6, 2, 4,
+	2, 3, 3, 1, 3, 4, 2, 4, 2, 4, 3, 1, 3, 1, 2, 4,
+	3, 3, 2, 1, 1, 4, 2, 4, 4, 1, 4, 1, 1, 1, 2, 4,
+	3, 3, 2, 1, 1, 4, 2, 4, 3, 2, 4, 1, 1, 1, 2, 4,
+}
+
+func (c *CPU) fetch() uint8 {
+	b := c.Memory.Read(c.PC)
+	c.PC++
+	return b
+}
+
+func (c *CPU) fetch16() uint16 {
+	low := c.fetch()
+	return uint16(low) | uint16(c.fetch()) << 8
+}
+
+func (c *CPU) HL() uint16 {
+	return uint16(c.H) << 8 | uint16(c.L)
+}
+
+func (c *CPU) BC() uint16 {
+	return uint16(c.B) << 8 | uint16(c.C)
+}
+
+func (c *CPU) DE() uint16 {
+	return uint16(c.D) << 8 | uint16(c.E)
+}
+
+func (c *CPU) setHL(v uint16) {
+	c.H, c.L = uint8(v >> 8), uint8(v)
+}
+
+// reg reads an 8-bit operand by its index in b, c, d, e, h, l, [hl], a.
+func (c *CPU) reg(i uint8) uint8 {
+	switch i {
+	case 0:
+		return c.B
+	case 1:
+		return c.C
+	case 2:
+		return c.D
+	case 3:
+		return c.E
+	case 4:
+		return c.H
+	case 5:
+		return c.L
+	case 6:
+		return c.Memory.Read(c.HL())
+	}
+	return c.A
+}
+
+func (c *CPU) setReg(i, v uint8) {
+	switch i {
+	case 0:
+		c.B = v
+	case 1:
+		c.C = v
+	case 2:
+		c.D = v
+	case 3:
+		c.E = v
+	case 4:
+		c.H = v
+	case 5:
+		c.L = v
+	case 6:
+		c.Memory.Write(c.HL(), v)
+	default:
+		c.A = v
+	}
+}
+
+// pair reads a register pair by its index in bc, de, hl, then sp or af.
+func (c *CPU) pair(i uint8, af bool) uint16 {
+	switch i {
+	case 0:
+		return c.BC()
+	case 1:
+		return c.DE()
+	case 2:
+		return c.HL()
+	}
+	if af {
+		return uint16(c.A) << 8 | uint16(c.F)
+	}
+	return c.SP
+}
+
+func (c *CPU) setPair(i uint8, af bool, v uint16) {
+	switch i {
+	case 0:
+		c.B, c.C = uint8(v >> 8), uint8(v)
+	case 1:
+		c.D, c.E = uint8(v >> 8), uint8(v)
+	case 2:
+		c.setHL(v)
+	default:
+		if af {
+			// the low nybble of F always reads as 0
+			c.A, c.F = uint8(v >> 8), uint8(v) & 0xf0
+		} else {
+			c.SP = v
+		}
+	}
+}
+
+func (c *CPU) Push(v uint16) {
+	c.SP--
+	c.Memory.Write(c.SP, uint8(v >> 8))
+	c.SP--
+	c.Memory.Write(c.SP, uint8(v))
+}
+
+func (c *CPU) Pop() uint16 {
+	low := c.Memory.Read(c.SP)
+	c.SP++
+	high := c.Memory.Read(c.SP)
+	c.SP++
+	return uint16(low) | uint16(high) << 8
+}
+
+// Ret returns from the current routine, as if it had run ret.
+func (c *CPU) Ret() {
+	c.PC = c.Pop()
+}
+
+func (c *CPU) condition(i uint8) bool {
+	switch i {
+	case 0:
+		return c.F & FlagZ == 0
+	case 1:
+		return c.F & FlagZ != 0
+	case 2:
+		return c.F & FlagC == 0
+	}
+	return c.F & FlagC != 0
+}
+
+func flag(set bool, f uint8) uint8 {
+	if set {
+		return f
+	}
+	return 0
+}
+
+func (c *CPU) alu(op, v uint8) {
+	a := c.A
+	carry := uint8(0)
+	if c.F & FlagC != 0 && (op == 1 || op == 3) {
+		carry = 1
+	}
+	switch op {
+	case 0, 1: // add, adc
+		result := uint16(a) + uint16(v) + uint16(carry)
+		c.A = uint8(result)
+		c.F = flag(c.A == 0, FlagZ) | flag((a & 0xf) + (v & 0xf) + carry > 0xf, FlagH) | flag(result > 0xff, FlagC)
+	case 2, 3, 7: // sub, sbc, cp
+		result := int(a) - int(v) - int(carry)
+		c.F = FlagN | flag(uint8(result) == 0, FlagZ) | flag(int(a & 0xf) - int(v & 0xf) - int(carry) < 0, FlagH) | flag(result < 0, FlagC)
+		if op != 7 {
+			c.A = uint8(result)
+		}
+	case 4: // and
+		c.A &= v
+		c.F = flag(c.A == 0, FlagZ) | FlagH
+	case 5: // xor
+		c.A ^= v
+		c.F = flag(c.A == 0, FlagZ)
+	case 6: // or
+		c.A |= v
+		c.F = flag(c.A == 0, FlagZ)
+	}
+}
+
+// addSP computes sp + e for add sp, e and ld hl, sp + e, which take their
+// flags from the unsigned addition of the low byte.
+func (c *CPU) addSP(e uint8) uint16 {
+	result := c.SP + uint16(int8(e))
+	c.F = flag((c.SP & 0xf) + uint16(e & 0xf) > 0xf, FlagH) | flag((c.SP & 0xff) + uint16(e) > 0xff, FlagC)
+	return result
+}
+
+// Step runs one instruction.
+func (c *CPU) Step() error {
+	pc := c.PC
+	op := c.fetch()
+	if Illegal(op) {
+		c.PC = pc
+		return fmt.Errorf("%w 0x%02x at 0x%04x", ErrIllegalOpcode, op, pc)
+	}
+	c.Cycles += uint64(cycles[op])
+	x, y, z := op >> 6, (op >> 3) & 7, op & 7
+	p, q := y >> 1, y & 1
+
+	switch x {
+	case 1:
+		// halt does nothing without interrupts
+		if op != 0x76 {
+			c.setReg(y, c.reg(z))
+		}
+		return nil
+	case 2:
+		c.alu(y, c.reg(z))
+		return nil
+	case 0:
+		c.step0(op, y, z, p, q)
+		return nil
+	}
+
+	switch z {
+	case 0:
+		switch y {
+		case 4:
+			c.Memory.Write(0xff00 | uint16(c.fetch()), c.A)
+		case 5:
+			e := c.fetch()
+			c.SP = c.addSP(e)
+		case 6:
+			c.A = c.Memory.Read(0xff00 | uint16(c.fetch()))
+		case 7:
+			e := c.fetch()
+			c.setHL(c.addSP(e))
+		default:
+			if c.condition(y) {
+				c.Cycles += 3
+				c.Ret()
+			}
+		}
+	case 1:
+		switch {
+		case q == 0:
+			c.setPair(p, true, c.Pop())
+		case p == 0:
+			c.Ret()
+		case p == 1:
+			c.Ret()
+			c.IME = true
+		case p == 2:
+			c.PC = c.HL()
+		default:
+			c.SP = c.HL()
+		}
+	case 2:
+		switch y {
+		case 4:
+			c.Memory.Write(0xff00 | uint16(c.C), c.A)
+		case 5:
+			c.Memory.Write(c.fetch16(), c.A)
+		case 6:
+			c.A = c.Memory.Read(0xff00 | uint16(c.C))
+		case 7:
+			c.A = c.Memory.Read(c.fetch16())
+		default:
+			target := c.fetch16()
+			if c.condition(y) {
+				c.Cycles++
+				c.PC = target
+			}
+		}
+	case 3:
+		switch y {
+		case 0:
+			c.PC = c.fetch16()
+		case 1:
+			c.stepPrefixed()
+		case 6:
+			c.IME = false
+		case 7:
+			c.IME = true
+		}
+	case 4:
+		target := c.fetch16()
+		if c.condition(y) {
+			c.Cycles += 3
+			c.Push(c.PC)
+			c.PC = target
+		}
+	case 5:
+		if q == 0 {
+			c.Push(c.pair(p, true))
+		} else {
+			target := c.fetch16()
+			c.Push(c.PC)
+			c.PC = target
+		}
+	case 6:
+		c.alu(y, c.fetch())
+	case 7:
+		c.Push(c.PC)
+		c.PC = uint16(y) * 8
+	}
+	return nil
+}
+
+func (c *CPU) step0(op, y, z, p, q uint8) {
+	switch z {
+	case 0:
+		switch {
+		case y == 0:
+		case y == 1:
+			addr := c.fetch16()
+			c.Memory.Write(addr, uint8(c.SP))
+			c.Memory.Write(addr + 1, uint8(c.SP >> 8))
+		case y == 2:
+			// stop is followed by a padding byte
+			c.fetch()
+		case y == 3 || c.condition(y - 4):
+			e := c.fetch()
+			if y != 3 {
+				c.Cycles++
+			}
+			c.PC += uint16(int8(e))
+		default:
+			c.fetch()
+		}
+	case 1:
+		if q == 0 {
+			c.setPair(p, false, c.fetch16())
+			return
+		}
+		hl, v := c.HL(), c.pair(p, false)
+		result := uint32(hl) + uint32(v)
+		c.F = c.F & FlagZ | flag((hl & 0xfff) + (v & 0xfff) > 0xfff, FlagH) | flag(result > 0xffff, FlagC)
+		c.setHL(uint16(result))
+	case 2:
+		var addr uint16
+		switch p {
+		case 0:
+			addr = c.BC()
+		case 1:
+			addr = c.DE()
+		default:
+			addr = c.HL()
+			if p == 2 {
+				c.setHL(addr + 1)
+			} else {
+				c.setHL(addr - 1)
+			}
+		}
+		if q == 0 {
+			c.Memory.Write(addr, c.A)
+		} else {
+			c.A = c.Memory.Read(addr)
+		}
+	case 3:
+		if q == 0 {
+			c.setPair(p, false, c.pair(p, false) + 1)
+		} else {
+			c.setPair(p, false, c.pair(p, false) - 1)
+		}
+	case 4:
+		v := c.reg(y) + 1
+		c.setReg(y, v)
+		c.F = c.F & FlagC | flag(v == 0, FlagZ) | flag(v & 0xf == 0, FlagH)
+	case 5:
+		v := c.reg(y) - 1
+		c.setReg(y, v)
+		c.F = c.F & FlagC | FlagN | flag(v == 0, FlagZ) | flag(v & 0xf == 0xf, FlagH)
+	case 6:
+		c.setReg(y, c.fetch())
+	case 7:
+		c.stepAccumulator(y)
+	}
+}
+
+func (c *CPU) stepAccumulator(y uint8) {
+	a := c.A
+	switch y {
+	case 0: // rlca
+		c.A = a << 1 | a >> 7
+		c.F = flag(a & 0x80 != 0, FlagC)
+	case 1: // rrca
+		c.A = a >> 1 | a << 7
+		c.F = flag(a & 1 != 0, FlagC)
+	case 2: // rla
+		c.A = a << 1 | (c.F & FlagC) >> 4
+		c.F = flag(a & 0x80 != 0, FlagC)
+	case 3: // rra
+		c.A = a >> 1 | (c.F & FlagC) << 3
+		c.F = flag(a & 1 != 0, FlagC)
+	case 4: // daa
+		carry := c.F & FlagC != 0
+		if c.F & FlagN == 0 {
+			if carry || a > 0x99 {
+				a += 0x60
+				carry = true
+			}
+			if c.F & FlagH != 0 || a & 0xf > 9 {
+				a += 0x06
+			}
+		} else {
+			if carry {
+				a -= 0x60
+			}
+			if c.F & FlagH != 0 {
+				a -= 0x06
+			}
+		}
+		c.A = a
+		c.F = c.F & FlagN | flag(a == 0, FlagZ) | flag(carry, FlagC)
+	case 5: // cpl
+		c.A = ^a
+		c.F |= FlagN | FlagH
+	case 6: // scf
+		c.F = c.F & FlagZ | FlagC
+	case 7: // ccf
+		c.F = c.F & FlagZ | (c.F & FlagC) ^ FlagC
+	}
+}
+
+func (c *CPU) stepPrefixed() {
+	op := c.fetch()
+	x, y, z := op >> 6, (op >> 3) & 7, op & 7
+	// on top of the cycle the prefix took
+	c.Cycles++
+	if z == 6 {
+		if x == 1 {
+			c.Cycles++
+		} else {
+			c.Cycles += 2
+		}
+	}
+
+	v := c.reg(z)
+	switch x {
+	case 1: // bit
+		c.F = c.F & FlagC | FlagH | flag(v & (1 << y) == 0, FlagZ)
+		return
+	case 2:
+		c.setReg(z, v & ^(1 << y))
+		return
+	case 3:
+		c.setReg(z, v | 1 << y)
+		return
+	}
+
+	var result uint8
+	carry := false
+	switch y {
+	case 0: // rlc
+		result, carry = v << 1 | v >> 7, v & 0x80 != 0
+	case 1: // rrc
+		result, carry = v >> 1 | v << 7, v & 1 != 0
+	case 2: // rl
+		result, carry = v << 1 | (c.F & FlagC) >> 4, v & 0x80 != 0
+	case 3: // rr
+		result, carry = v >> 1 | (c.F & FlagC) << 3, v & 1 != 0
+	case 4: // sla
+		result, carry = v << 1, v & 0x80 != 0
+	case 5: // sra
+		result, carry = v >> 1 | v & 0x80, v & 1 != 0
+	case 6: // swap
+		result = v << 4 | v >> 4
+	case 7: // srl
+		result, carry = v >> 1, v & 1 != 0
+	}
+	c.setReg(z, result)
+	c.F = flag(result == 0, FlagZ) | flag(carry, FlagC)
+}
+
+var ErrCycleLimit = errors.New("cycle limit reached")
+
+// A Trap runs instead of the code at its address, such as to stand in for a
+// routine. It returns whether to stop running.
+type Trap func(c *CPU) (stop bool, err error)
+
+// Run runs instructions until a trap stops it, an error happens, or more than
+// maxCycles machine cycles have been taken. Traps are checked before every
+// instruction, and each one counts as a cycle, so that a trap that doesn't
+// move on still runs into the limit.
+func (c *CPU) Run(traps map[uint16]Trap, maxCycles uint64) error {
+	for c.Cycles <= maxCycles {
+		if trap, ok := traps[c.PC]; ok {
+			c.Cycles++
+			stop, err := trap(c)
+			if stop || err != nil {
+				return err
+			}
+			continue
+		}
+		if c.Trace != nil {
+			c.Trace(c)
+		}
+		if err := c.Step(); err != nil {
+			return err
+		}
+	}
+	return fmt.Errorf("%w after %d cycles, at 0x%04x", ErrCycleLimit, c.Cycles, c.PC)
+}
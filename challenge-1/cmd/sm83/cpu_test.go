@@ -0,0 +1,130 @@
+/*
+ * fools2024-solutions: source code for Kagamiin's solutions for TheZZAZZGlitch April Fools Event 2024's Security Testing Program.
+ * Copyright (C) 2024 Kagamiin~
+ * 
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License,
+ * or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program. If not, see <https://www.gnu.org/licenses/>.
+ */
+
+package sm83_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/Kagamiin/fools2024-solutions/challenge-1/cmd/gbmem"
+	"github.com/Kagamiin/fools2024-solutions/challenge-1/cmd/sm83"
+)
+
+func newCPU(code []byte) *sm83.CPU {
+	memory := make(gbmem.Flat, 0x10000)
+	copy(memory[0xc000:], code)
+	return &sm83.CPU{Memory: memory, PC: 0xc000, SP: 0xdfff}
+}
+
+func runUntil(t *testing.T, c *sm83.CPU, end uint16) {
+	t.Helper()
+	stop := func(c *sm83.CPU) (bool, error) {
+		return true, nil
+	}
+	if err := c.Run(map[uint16]sm83.Trap{end: stop}, 10000); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func Test_CPULoop(t *testing.T) {
+	// sum 1 to 10 into a through a called routine, then store it
+	c := newCPU([]byte{
+		0xaf,             // xor a
+		0x06, 0x0a,       // ld b, 10
+		0xcd, 0x0d, 0xc0, // call .add
+		0x05,             // dec b
+		0x20, 0xfa,       // jr nz, -6
+		0xea, 0x00, 0xd0, // ld [$d000], a
+		0x76,             // halt
+		0x80,             // .add: add b
+		0xc9,             // ret
+	})
+	runUntil(t, c, 0xc00c)
+	if c.A != 55 || c.Memory.Read(0xd000) != 55 || c.SP != 0xdfff {
+		t.Errorf("expected 55 with the stack balanced, got a=%d, [$d000]=%d, sp=%04x", c.A, c.Memory.Read(0xd000), c.SP)
+	}
+	// 1 + 2 + 10 * (6 + 1 + 4 + 1 + 3) - 1 + 4, and 1 for the trap
+	if c.Cycles != 157 {
+		t.Errorf("expected 157 cycles, got %d", c.Cycles)
+	}
+}
+
+func Test_CPUArithmetic(t *testing.T) {
+	c := newCPU([]byte{
+		0x3e, 0x45,       // ld a, $45
+		0xc6, 0x38,       // add $38
+		0x27,             // daa
+		0x47,             // ld b, a
+		0x3e, 0x0f,       // ld a, $0f
+		0xcb, 0x37,       // swap a
+		0xcb, 0x3f,       // srl a
+		0x4f,             // ld c, a
+		0x21, 0xff, 0x0f, // ld hl, $0fff
+		0x23,             // inc hl
+		0x2b,             // dec hl
+		0x29,             // add hl, hl
+		0xf5,             // push af
+		0xd1,             // pop de
+		0x37,             // scf
+		0x3f,             // ccf
+		0x3e, 0x10,       // ld a, $10
+		0xd6, 0x20,       // sub $20
+	})
+	runUntil(t, c, 0xc01c)
+	if c.B != 0x83 {
+		t.Errorf("expected daa to give $83, got $%02x", c.B)
+	}
+	if c.C != 0x78 {
+		t.Errorf("expected $78 after swap and srl, got $%02x", c.C)
+	}
+	if c.HL() != 0x1ffe || c.DE() != 0x7820 {
+		t.Errorf("expected hl=$1ffe and af pushed as $7820, got $%04x and $%04x", c.HL(), c.DE())
+	}
+	if c.A != 0xf0 || c.F != sm83.FlagN | sm83.FlagC {
+		t.Errorf("expected $f0 with N and C set, got $%02x with flags %08b", c.A, c.F)
+	}
+}
+
+func Test_CPUTrapsAndErrors(t *testing.T) {
+	c := newCPU([]byte{
+		0x21, 0x34, 0x12, // ld hl, $1234
+		0xcd, 0x00, 0x40, // call $4000
+		0xd3,             // illegal
+	})
+	var trapped uint16
+	traps := map[uint16]sm83.Trap{
+		0x4000: func(c *sm83.CPU) (bool, error) {
+			trapped = c.HL()
+			c.Ret()
+			return false, nil
+		},
+	}
+	if err := c.Run(traps, 1000); !errors.Is(err, sm83.ErrIllegalOpcode) || c.PC != 0xc006 {
+		t.Errorf("expected an illegal opcode at $c006, got %v at $%04x", err, c.PC)
+	}
+	if trapped != 0x1234 {
+		t.Errorf("expected the trap to see hl=$1234, got $%04x", trapped)
+	}
+
+	// jr -2
+	c = newCPU([]byte{0x18, 0xfe})
+	if err := c.Run(nil, 100); !errors.Is(err, sm83.ErrCycleLimit) {
+		t.Errorf("expected the cycle limit to stop an endless loop, got %v", err)
+	}
+}
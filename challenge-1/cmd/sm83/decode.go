@@ -0,0 +1,261 @@
+/*
+ * fools2024-solutions: source code for Kagamiin's solutions for TheZZAZZGlitch April Fools Event 2024's Security Testing Program.
+ * Copyright (C) 2024 Kagamiin~
+ * 
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License,
+ * or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program. If not, see <https://www.gnu.org/licenses/>.
+ */
+
+package sm83
+
+import (
+	"fmt"
+	"strings"
+)
+
+// A Labeler names addresses, such as with the symbols of a .sym file.
+type Labeler interface {
+	Label(addr uint16) (string, bool)
+}
+
+// LabelFunc adapts a function to a Labeler.
+type LabelFunc func(addr uint16) (string, bool)
+
+func (f LabelFunc) Label(addr uint16) (string, bool) {
+	return f(addr)
+}
+
+type Instruction struct {
+	Addr uint16
+	// Bytes holds the opcode and operands. It's short of the instruction's
+	// length if the data ended before its operands.
+	Bytes []byte
+	Text string
+}
+
+// Complete tells whether all the bytes of the instruction were available.
+func (i Instruction) Complete() bool {
+	return len(i.Bytes) > 0 && len(i.Bytes) == Length(i.Bytes[0])
+}
+
+var (
+	r8 = []string{"b", "c", "d", "e", "h", "l", "[hl]", "a"}
+	rp = []string{"bc", "de", "hl", "sp"}
+	rp2 = []string{"bc", "de", "hl", "af"}
+	conditions = []string{"nz", "z", "nc", "c"}
+	alu = []string{"add a, ", "adc a, ", "sub ", "sbc a, ", "and ", "xor ", "or ", "cp "}
+	accumulatorOps = []string{"rlca", "rrca", "rla", "rra", "daa", "cpl", "scf", "ccf"}
+	indirectLoads = []string{"[bc]", "[de]", "[hli]", "[hld]"}
+	rotations = []string{"rlc", "rrc", "rl", "rr", "sla", "sra", "swap", "srl"}
+	bitOps = []string{"", "bit", "res", "set"}
+)
+
+// Decode disassembles the instruction at the start of data, which is at addr,
+// the way rgbds writes it. Addresses are named with labels, if not nil.
+func Decode(data []byte, addr uint16, labels Labeler) Instruction {
+	if len(data) == 0 {
+		return Instruction{Addr: addr}
+	}
+	length := min(Length(data[0]), len(data))
+	d := decoder{data: data[:length], addr: addr, labels: labels}
+	return Instruction{
+		Addr: addr,
+		Bytes: append([]byte(nil), data[:length]...),
+		Text: d.decode(),
+	}
+}
+
+type decoder struct {
+	data []byte
+	addr uint16
+	labels Labeler
+}
+
+// n returns the immediate byte operand, or ?? if the data ended before it.
+func (d *decoder) n() string {
+	if len(d.data) < 2 {
+		return "$??"
+	}
+	return fmt.Sprintf("$%02x", d.data[1])
+}
+
+func (d *decoder) nn() (uint16, bool) {
+	if len(d.data) < 3 {
+		return 0, false
+	}
+	return uint16(d.data[1]) | uint16(d.data[2]) << 8, true
+}
+
+// address formats a 16-bit address operand, using its label if it has one.
+func (d *decoder) address(addr uint16, ok bool) string {
+	if !ok {
+		return "$????"
+	}
+	if d.labels != nil {
+		if label, found := d.labels.Label(addr); found {
+			return label
+		}
+	}
+	return fmt.Sprintf("$%04x", addr)
+}
+
+func (d *decoder) imm16() string {
+	return d.address(d.nn())
+}
+
+func (d *decoder) relative() string {
+	if len(d.data) < 2 {
+		return d.address(0, false)
+	}
+	return d.address(d.addr + 2 + uint16(int8(d.data[1])), true)
+}
+
+func (d *decoder) high() string {
+	if len(d.data) < 2 {
+		return "[$ff??]"
+	}
+	return "[" + d.address(0xff00 | uint16(d.data[1]), true) + "]"
+}
+
+func (d *decoder) signed() string {
+	if len(d.data) < 2 {
+		return "$??"
+	}
+	offset := int8(d.data[1])
+	if offset < 0 {
+		return fmt.Sprintf("-$%02x", -int(offset))
+	}
+	return fmt.Sprintf("$%02x", offset)
+}
+
+func (d *decoder) decode() string {
+	op := d.data[0]
+	x, y, z := op >> 6, (op >> 3) & 7, op & 7
+	p, q := y >> 1, y & 1
+	if Illegal(op) {
+		return fmt.Sprintf("db $%02x ; illegal", op)
+	}
+
+	switch x {
+	case 0:
+		switch z {
+		case 0:
+			switch {
+			case y == 0:
+				return "nop"
+			case y == 1:
+				return "ld [" + d.imm16() + "], sp"
+			case y == 2:
+				return "stop"
+			case y == 3:
+				return "jr " + d.relative()
+			default:
+				return "jr " + conditions[y - 4] + ", " + d.relative()
+			}
+		case 1:
+			if q == 0 {
+				return "ld " + rp[p] + ", " + d.imm16()
+			}
+			return "add hl, " + rp[p]
+		case 2:
+			if q == 0 {
+				return "ld " + indirectLoads[p] + ", a"
+			}
+			return "ld a, " + indirectLoads[p]
+		case 3:
+			if q == 0 {
+				return "inc " + rp[p]
+			}
+			return "dec " + rp[p]
+		case 4:
+			return "inc " + r8[y]
+		case 5:
+			return "dec " + r8[y]
+		case 6:
+			return "ld " + r8[y] + ", " + d.n()
+		default:
+			return accumulatorOps[y]
+		}
+	case 1:
+		if y == 6 && z == 6 {
+			return "halt"
+		}
+		return "ld " + r8[y] + ", " + r8[z]
+	case 2:
+		return alu[y] + r8[z]
+	}
+
+	switch z {
+	case 0:
+		switch y {
+		case 4:
+			return "ldh " + d.high() + ", a"
+		case 5:
+			return "add sp, " + d.signed()
+		case 6:
+			return "ldh a, " + d.high()
+		case 7:
+			return "ld hl, sp + " + d.signed()
+		}
+		return "ret " + conditions[y]
+	case 1:
+		if q == 0 {
+			return "pop " + rp2[p]
+		}
+		return []string{"ret", "reti", "jp hl", "ld sp, hl"}[p]
+	case 2:
+		switch y {
+		case 4:
+			return "ld [$ff00+c], a"
+		case 5:
+			return "ld [" + d.imm16() + "], a"
+		case 6:
+			return "ld a, [$ff00+c]"
+		case 7:
+			return "ld a, [" + d.imm16() + "]"
+		}
+		return "jp " + conditions[y] + ", " + d.imm16()
+	case 3:
+		switch y {
+		case 0:
+			return "jp " + d.imm16()
+		case 1:
+			return d.prefixed()
+		case 6:
+			return "di"
+		}
+		return "ei"
+	case 4:
+		return "call " + conditions[y] + ", " + d.imm16()
+	case 5:
+		if q == 0 {
+			return "push " + rp2[p]
+		}
+		return "call " + d.imm16()
+	case 6:
+		return strings.TrimSuffix(alu[y], " ") + " " + d.n()
+	}
+	return fmt.Sprintf("rst $%02x", y * 8)
+}
+
+func (d *decoder) prefixed() string {
+	if len(d.data) < 2 {
+		return "prefix ??"
+	}
+	op := d.data[1]
+	x, y, z := op >> 6, (op >> 3) & 7, op & 7
+	if x == 0 {
+		return rotations[y] + " " + r8[z]
+	}
+	return fmt.Sprintf("%s %d, %s", bitOps[x], y, r8[z])
+}
@@ -0,0 +1,102 @@
+/*
+ * fools2024-solutions: source code for Kagamiin's solutions for TheZZAZZGlitch April Fools Event 2024's Security Testing Program.
+ * Copyright (C) 2024 Kagamiin~
+ * 
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License,
+ * or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program. If not, see <https://www.gnu.org/licenses/>.
+ */
+
+package sm83
+
+// A Line is an instruction disassembled from data with unknown bits.
+type Line struct {
+	Instruction
+	// Unknown holds the unknown bits of each byte of the instruction.
+	Unknown []uint8
+	// Alternatives lists the other decodings the unknown bits of the opcode
+	// allow. Their lengths may differ from the instruction's, in which case
+	// the instructions that follow are only one of the possible readings.
+	Alternatives []Instruction
+}
+
+// HasUnknown tells whether any byte of the instruction has unknown bits.
+func (l Line) HasUnknown() bool {
+	for _, mask := range l.Unknown {
+		if mask != 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// Disassemble disassembles data, which is at addr, one instruction after the
+// other. unknown holds the unknown bits of data, and can be nil if all of them
+// are known; the best-effort values in data are used to decode them.
+func Disassemble(data, unknown []byte, addr uint16, labels Labeler) []Line {
+	lines := make([]Line, 0)
+	for pc := 0; pc < len(data); {
+		instruction := Decode(data[pc:], addr + uint16(pc), labels)
+		line := Line{Instruction: instruction, Unknown: make([]uint8, len(instruction.Bytes))}
+		if unknown != nil {
+			copy(line.Unknown, unknown[pc:])
+			line.Alternatives = alternatives(data[pc:], line.Unknown, instruction, labels)
+		}
+		lines = append(lines, line)
+		pc += len(instruction.Bytes)
+	}
+	return lines
+}
+
+// alternatives decodes every value the unknown bits of the opcode can take,
+// along with those of the second byte for CB-prefixed instructions.
+func alternatives(data []byte, unknown []uint8, decoded Instruction, labels Labeler) []Instruction {
+	if unknown[0] == 0 && (data[0] != 0xcb || len(unknown) < 2 || unknown[1] == 0) {
+		return nil
+	}
+	seen := map[string]bool{decoded.Text: true}
+	result := make([]Instruction, 0)
+	variant := make([]byte, min(len(data), 3))
+	add := func() {
+		instruction := Decode(variant, decoded.Addr, labels)
+		if !seen[instruction.Text] {
+			seen[instruction.Text] = true
+			result = append(result, instruction)
+		}
+	}
+	forEachValue(data[0], unknown[0], func(opcode uint8) {
+		copy(variant, data)
+		variant[0] = opcode
+		if opcode != 0xcb || len(variant) < 2 || len(unknown) < 2 {
+			add()
+			return
+		}
+		forEachValue(data[1], unknown[1], func(b uint8) {
+			variant[1] = b
+			add()
+		})
+	})
+	return result
+}
+
+// forEachValue calls f with every value the unknown bits of b can take.
+func forEachValue(b, unknown uint8, f func(uint8)) {
+	// walk the subsets of the unknown bits
+	subset := uint8(0)
+	for {
+		f(b & ^unknown | subset)
+		if subset == unknown {
+			return
+		}
+		subset = (subset - unknown) & unknown
+	}
+}
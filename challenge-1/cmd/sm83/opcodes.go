@@ -0,0 +1,75 @@
+/*
+ * fools2024-solutions: source code for Kagamiin's solutions for TheZZAZZGlitch April Fools Event 2024's Security Testing Program.
+ * Copyright (C) 2024 Kagamiin~
+ * 
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License,
+ * or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program. If not, see <https://www.gnu.org/licenses/>.
+ */
+
+package sm83
+
+// Opcodes that lock up the CPU when executed.
+var illegalOpcodes = map[uint8]bool{
+	0xd3: true, 0xdb: true, 0xdd: true, 0xe3: true, 0xe4: true, 0xeb: true,
+	0xec: true, 0xed: true, 0xf4: true, 0xfc: true, 0xfd: true,
+}
+
+func Illegal(opcode uint8) bool {
+	return illegalOpcodes[opcode]
+}
+
+var lengths [256]int
+
+func init() {
+	for i := range lengths {
+		lengths[i] = 1
+	}
+	twoBytes := []uint8{
+		// ld r, n
+		0x06, 0x0e, 0x16, 0x1e, 0x26, 0x2e, 0x36, 0x3e,
+		// stop / jr
+		0x10, 0x18, 0x20, 0x28, 0x30, 0x38,
+		// alu a, n
+		0xc6, 0xce, 0xd6, 0xde, 0xe6, 0xee, 0xf6, 0xfe,
+		0xcb, 0xe0, 0xe8, 0xf0, 0xf8,
+	}
+	threeBytes := []uint8{
+		// ld rr, nn / ld [nn], sp
+		0x01, 0x11, 0x21, 0x31, 0x08,
+		// jp / call
+		0xc2, 0xc3, 0xca, 0xd2, 0xda, 0xc4, 0xcc, 0xcd, 0xd4, 0xdc,
+		0xea, 0xfa,
+	}
+	for _, opcode := range twoBytes {
+		lengths[opcode] = 2
+	}
+	for _, opcode := range threeBytes {
+		lengths[opcode] = 3
+	}
+}
+
+// Length returns the length in bytes of the instruction starting with opcode,
+// including its operands. CB-prefixed instructions are 2 bytes long.
+func Length(opcode uint8) int {
+	return lengths[opcode]
+}
+
+// EndsFlow tells whether execution never falls through to the next
+// instruction: unconditional jumps and returns.
+func EndsFlow(opcode uint8) bool {
+	switch opcode {
+	case 0x18, 0xc3, 0xc9, 0xd9, 0xe9:
+		return true
+	}
+	return false
+}
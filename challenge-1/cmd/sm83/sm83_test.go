@@ -0,0 +1,130 @@
+/*
+ * fools2024-solutions: source code for Kagamiin's solutions for TheZZAZZGlitch April Fools Event 2024's Security Testing Program.
+ * Copyright (C) 2024 Kagamiin~
+ * 
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License,
+ * or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program. If not, see <https://www.gnu.org/licenses/>.
+ */
+
+package sm83_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/Kagamiin/fools2024-solutions/challenge-1/cmd/sm83"
+)
+
+func Test_Decode(t *testing.T) {
+	cases := []struct {
+		data []byte
+		text string
+	}{
+		{[]byte{0x00}, "nop"},
+		{[]byte{0x3e, 0x50}, "ld a, $50"},
+		{[]byte{0x21, 0xd0, 0xa7}, "ld hl, $a7d0"},
+		{[]byte{0x2a}, "ld a, [hli]"},
+		{[]byte{0x18, 0xfe}, "jr $c000"},
+		{[]byte{0x20, 0x03}, "jr nz, $c005"},
+		{[]byte{0x76}, "halt"},
+		{[]byte{0x46}, "ld b, [hl]"},
+		{[]byte{0xaf}, "xor a"},
+		{[]byte{0xfe, 0x10}, "cp $10"},
+		{[]byte{0xce, 0x01}, "adc a, $01"},
+		{[]byte{0xe0, 0x40}, "ldh [$ff40], a"},
+		{[]byte{0xf8, 0xfe}, "ld hl, sp + -$02"},
+		{[]byte{0xea, 0x00, 0xd0}, "ld [$d000], a"},
+		{[]byte{0xcd, 0x3e, 0x3e}, "call $3e3e"},
+		{[]byte{0xc9}, "ret"},
+		{[]byte{0xd8}, "ret c"},
+		{[]byte{0xe9}, "jp hl"},
+		{[]byte{0xf5}, "push af"},
+		{[]byte{0xff}, "rst $38"},
+		{[]byte{0xcb, 0x37}, "swap a"},
+		{[]byte{0xcb, 0x7e}, "bit 7, [hl]"},
+		{[]byte{0xcb, 0xc7}, "set 0, a"},
+		{[]byte{0xd3}, "db $d3 ; illegal"},
+		{[]byte{0xc3, 0x50}, "jp $????"},
+	}
+	for _, c := range cases {
+		instruction := sm83.Decode(c.data, 0xc000, nil)
+		if instruction.Text != c.text {
+			t.Errorf("% x: expected %q, got %q", c.data, c.text, instruction.Text)
+		}
+	}
+
+	for opcode := 0; opcode < 256; opcode++ {
+		instruction := sm83.Decode([]byte{uint8(opcode), 0x12, 0x34}, 0, nil)
+		if len(instruction.Bytes) != sm83.Length(uint8(opcode)) || !instruction.Complete() || instruction.Text == "" {
+			t.Errorf("opcode 0x%02x: unexpected decoding %+v", opcode, instruction)
+		}
+	}
+}
+
+func Test_Symbols(t *testing.T) {
+	symbols, err := sm83.ReadSymbols(strings.NewReader(`; comment
+00:3e3e DelayFrames
+01:4000 InBank1
+02:4000 InBank2
+00:a7d0 sPayload
+00:d11e wd11e
+00:ff40 rLCDC ; trailing comment
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	labels := symbols.At(0x02, 0x00)
+	for _, c := range []struct {
+		addr uint16
+		label string
+	}{{0x3e3e, "DelayFrames"}, {0x4000, "InBank2"}, {0xa7d0, "sPayload"}, {0xd11e, "wd11e"}, {0xff40, "rLCDC"}} {
+		if label, ok := labels.Label(c.addr); !ok || label != c.label {
+			t.Errorf("0x%04x: expected %s, got %q", c.addr, c.label, label)
+		}
+	}
+	if text := sm83.Decode([]byte{0xcd, 0x3e, 0x3e}, 0, labels).Text; text != "call DelayFrames" {
+		t.Errorf("expected the call target to be labeled, got %q", text)
+	}
+	if text := sm83.Decode([]byte{0xe0, 0x40}, 0, labels).Text; text != "ldh [rLCDC], a" {
+		t.Errorf("expected the HRAM address to be labeled, got %q", text)
+	}
+
+	if _, err := sm83.ReadSymbols(strings.NewReader("garbage\n")); err == nil {
+		t.Error("expected an error for a malformed line")
+	}
+}
+
+func Test_DisassembleUnknown(t *testing.T) {
+	// ld a, $?5 / inc ? / ret
+	data := []byte{0x3e, 0x05, 0x3c, 0xc9}
+	unknown := []byte{0x00, 0xf0, 0x08, 0x00}
+	lines := sm83.Disassemble(data, unknown, 0xa7d0, nil)
+	if len(lines) != 3 {
+		t.Fatalf("expected 3 instructions, got %d", len(lines))
+	}
+	if !lines[0].HasUnknown() || len(lines[0].Alternatives) != 0 {
+		t.Errorf("expected an unknown operand without alternatives, got %+v", lines[0])
+	}
+	if len(lines[1].Alternatives) != 1 || lines[1].Alternatives[0].Text != "inc [hl]" {
+		t.Errorf("expected inc [hl] as the alternative to inc a, got %+v", lines[1].Alternatives)
+	}
+	if lines[2].HasUnknown() || lines[2].Addr != 0xa7d3 {
+		t.Errorf("unexpected last instruction %+v", lines[2])
+	}
+
+	// an unknown bit turning adc a, e into a CB prefix
+	lines = sm83.Disassemble([]byte{0x8b, 0x37}, []byte{0x40, 0x00}, 0, nil)
+	if len(lines[0].Alternatives) != 1 || lines[0].Alternatives[0].Text != "swap a" {
+		t.Errorf("expected swap a as the alternative, got %+v", lines[0].Alternatives)
+	}
+}
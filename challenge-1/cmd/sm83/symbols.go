@@ -0,0 +1,94 @@
+/*
+ * fools2024-solutions: source code for Kagamiin's solutions for TheZZAZZGlitch April Fools Event 2024's Security Testing Program.
+ * Copyright (C) 2024 Kagamiin~
+ * 
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License,
+ * or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program. If not, see <https://www.gnu.org/licenses/>.
+ */
+
+package sm83
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+type bankAddr struct {
+	bank uint8
+	addr uint16
+}
+
+// Symbols holds the labels of a .sym file, as written by rgblink for pokered.
+type Symbols struct {
+	labels map[bankAddr]string
+	addrs map[string]bankAddr
+}
+
+// ReadSymbols reads a .sym file, made of bank:addr label lines and ; comments.
+func ReadSymbols(r io.Reader) (*Symbols, error) {
+	s := &Symbols{labels: make(map[bankAddr]string), addrs: make(map[string]bankAddr)}
+	scanner := bufio.NewScanner(r)
+	for lineNumber := 1; scanner.Scan(); lineNumber++ {
+		line, _, _ := strings.Cut(scanner.Text(), ";")
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+		bankArg, addrArg, ok := strings.Cut(fields[0], ":")
+		if len(fields) != 2 || !ok {
+			return nil, fmt.Errorf("line %d: expected bank:addr label, got %q", lineNumber, scanner.Text())
+		}
+		bank, err := strconv.ParseUint(bankArg, 16, 8)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", lineNumber, err)
+		}
+		addr, err := strconv.ParseUint(addrArg, 16, 16)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", lineNumber, err)
+		}
+		key := bankAddr{uint8(bank), uint16(addr)}
+		s.addrs[fields[1]] = key
+		// keep the first label, which is the global one for local labels
+		// at the same address
+		if _, exists := s.labels[key]; !exists {
+			s.labels[key] = fields[1]
+		}
+	}
+	return s, scanner.Err()
+}
+
+// At returns a Labeler for the given ROM and SRAM banks. Addresses in the
+// other regions are looked up in bank 0, where rgblink puts all of WRAM when
+// linking in DMG mode like pokered does.
+func (s *Symbols) At(romBank, sramBank uint8) Labeler {
+	return LabelFunc(func(addr uint16) (string, bool) {
+		var bank uint8
+		switch {
+		case addr >= 0x4000 && addr < 0x8000:
+			bank = romBank
+		case addr >= 0xa000 && addr < 0xc000:
+			bank = sramBank
+		}
+		label, ok := s.labels[bankAddr{bank, addr}]
+		return label, ok
+	})
+}
+
+// Lookup returns the bank and address of a label.
+func (s *Symbols) Lookup(name string) (bank uint8, addr uint16, ok bool) {
+	key, ok := s.addrs[name]
+	return key.bank, key.addr, ok
+}
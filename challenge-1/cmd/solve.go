@@ -0,0 +1,153 @@
+/*
+ * fools2024-solutions: source code for Kagamiin's solutions for TheZZAZZGlitch April Fools Event 2024's Security Testing Program.
+ * Copyright (C) 2024 Kagamiin~
+ * 
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License,
+ * or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program. If not, see <https://www.gnu.org/licenses/>.
+ */
+
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/Kagamiin/fools2024-solutions/challenge-1/cmd/gen1sav"
+)
+
+// Longer lists of ambiguous bits are only written to the bit map.
+const maxListedAmbiguousBits = 256
+
+// knownBytes collects the repeatable -known offset=value option.
+type knownBytes map[int]uint8
+
+func (k knownBytes) String() string {
+	return fmt.Sprint(map[int]uint8(k))
+}
+
+func (k knownBytes) Set(value string) error {
+	offsetArg, valueArg, ok := strings.Cut(value, "=")
+	if !ok {
+		return fmt.Errorf("expected offset=value, got %q", value)
+	}
+	offset, err := strconv.ParseUint(offsetArg, 16, 16)
+	if err != nil {
+		return err
+	}
+	b, err := strconv.ParseUint(valueArg, 16, 8)
+	if err != nil {
+		return err
+	}
+	k[int(offset)] = uint8(b)
+	return nil
+}
+
+// loadSaveBitMap reads an unknown bit map over a save file, or over a memory
+// image as written by undo, whose SRAM maps onto bank 0 of the save.
+func loadSaveBitMap(path string) ([]byte, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	switch len(data) {
+	case gen1sav.SaveSize:
+		return data, nil
+	case 65536:
+		bitMap := make([]byte, gen1sav.SaveSize)
+		copy(bitMap, data[0xa000:0xc000])
+		return bitMap, nil
+	}
+	return nil, fmt.Errorf("%s: expected a %d byte bit map over a save file or a 65536 byte one over memory, got %d bytes", path, gen1sav.SaveSize, len(data))
+}
+
+func solveChecksums() {
+	flags := flag.NewFlagSet("solve", flag.ExitOnError)
+	outPath := flags.String("o", "solved.sav", "path of the save file to write, with every determined bit filled in")
+	ambiguousPath := flags.String("ambiguous", "ambiguousbits.bin", "path of the bit map of bits that stay ambiguous to write")
+	recoveredPath := flags.String("recovered", "", "memory image written by undo, whose SRAM replaces bank 0 of the save file")
+	known := make(knownBytes)
+	flags.Var(known, "known", "byte of the save file known to hold a value, as offset=value in hex (can be repeated)")
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), `usage: 
+	%v solve [options] pokeblue.sav unknownbits.bin
+
+pokeblue.sav: save file to solve the unknown bits of
+unknownbits.bin: bit map of the unknown bits, either over the save file, or
+                 over memory as written by undo, where SRAM is bank 0 of the save
+
+Treats the unknown bits as variables, and finds the values the main data, box
+bank and box checksums allow for them. Bank 0, which holds the Hall of Fame,
+isn't covered by any checksum, so damage there can't be solved: a bit map
+written by undo only covers bank 0, and solving with one is refused unless
+--force is given.
+Generates the following files in the current directory:
+- solved.sav: contains the save file with every determined bit filled in (unless changed with -o)
+- ambiguousbits.bin: contains a bitmap of the bits that stay ambiguous (unless changed with -ambiguous)
+
+options:
+`, os.Args[0])
+		flags.PrintDefaults()
+	}
+	handle(flags.Parse(os.Args[2:]))
+	
+	if flags.NArg() != 2 {
+		flags.Usage()
+		os.Exit(1)
+	}
+	
+	savData, err := readSavFile(flags.Arg(0))
+	handle(err)
+	if *recoveredPath != "" {
+		memSpace, err := loadMemImage(*recoveredPath)
+		handle(err)
+		copy(savData, memSpace[0xa000:0xc000])
+	}
+	
+	unknownBitMap, err := loadSaveBitMap(flags.Arg(1))
+	handle(err)
+	
+	solution, err := gen1sav.Solve(savData, unknownBitMap, known)
+	handle(err)
+	
+	if solution.Uncovered > 0 && len(solution.Determined) == 0 && len(solution.Ambiguous) == 0 {
+		refuse(fmt.Errorf("none of the %d unknown bits falls in a checksummed region, so there's nothing to solve", solution.Uncovered))
+	}
+	for _, name := range solution.Unsatisfiable {
+		log.Printf("Warning: the %s checksum can't hold for any value of the unknown bits\n", name)
+	}
+	log.Printf("Determined %d bits, %d stay ambiguous, %d aren't covered by any checksum\n",
+	           len(solution.Determined), len(solution.Ambiguous), solution.Uncovered)
+	if solution.Unique() {
+		log.Println("The solution is unique.")
+	}
+	for _, bit := range solution.Determined {
+		fmt.Printf("determined %v = %d\n", bit, (solution.Data[bit.Offset] >> bit.Bit) & 1)
+	}
+	if len(solution.Ambiguous) <= maxListedAmbiguousBits {
+		for _, bit := range solution.Ambiguous {
+			fmt.Printf("ambiguous %v\n", bit)
+		}
+	}
+	
+	err = dumpBin(*outPath, &solution.Data)
+	handle(err)
+	
+	err = dumpBin(*ambiguousPath, &solution.Unknown)
+	handle(err)
+	
+	log.Println("Done.")
+}
@@ -0,0 +1,116 @@
+/*
+ * fools2024-solutions: source code for Kagamiin's solutions for TheZZAZZGlitch April Fools Event 2024's Security Testing Program.
+ * Copyright (C) 2024 Kagamiin~
+ * 
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License,
+ * or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program. If not, see <https://www.gnu.org/licenses/>.
+ */
+
+package spriteimg
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"sort"
+)
+
+// A Palette maps the four 2bpp color indices to colors, lightest first.
+type Palette [4]color.RGBA
+
+func rgb(r, g, b uint8) color.RGBA {
+	return color.RGBA{R: r, G: g, B: b, A: 0xff}
+}
+
+// sgbColor converts an SGB/CGB 5-bit per channel color to 8 bits per channel.
+func sgbColor(r, g, b uint8) color.RGBA {
+	return rgb(r * 255 / 31, g * 255 / 31, b * 255 / 31)
+}
+
+var Palettes = map[string]Palette{
+	// Plain grayscale, as on a DMG with a perfectly neutral screen
+	"dmg": {rgb(0xff, 0xff, 0xff), rgb(0xaa, 0xaa, 0xaa), rgb(0x55, 0x55, 0x55), rgb(0x00, 0x00, 0x00)},
+	// The classic DMG green LCD shades
+	"dmg-green": {rgb(0x9b, 0xbc, 0x0f), rgb(0x8b, 0xac, 0x0f), rgb(0x30, 0x62, 0x30), rgb(0x0f, 0x38, 0x0f)},
+	// PAL_MEWMON from pokered's SGB palettes, used for Missingno. and Mew
+	"sgb": {sgbColor(31, 29, 31), sgbColor(30, 22, 17), sgbColor(16, 14, 19), sgbColor(3, 2, 2)},
+	// PAL_GREYMON from pokered's SGB palettes
+	"sgb-grey": {sgbColor(31, 29, 31), sgbColor(26, 21, 22), sgbColor(15, 15, 18), sgbColor(3, 2, 2)},
+	// The GBC boot ROM's BG compatibility palette for Pokémon Blue
+	"gbc-blue": {rgb(0xff, 0xff, 0xff), rgb(0x63, 0xa5, 0xff), rgb(0x00, 0x00, 0xff), rgb(0x00, 0x00, 0x00)},
+	// The GBC boot ROM's BG compatibility palette for Pokémon Red
+	"gbc-red": {rgb(0xff, 0xff, 0xff), rgb(0xff, 0x84, 0x84), rgb(0x94, 0x3a, 0x3a), rgb(0x00, 0x00, 0x00)},
+}
+
+func PaletteNames() []string {
+	names := make([]string, 0, len(Palettes))
+	for name := range Palettes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Render turns interlaced 2bpp tile data, with tiles ordered column by column
+// as the game leaves them in the sprite buffers, into an image.
+// The image is widthTiles x heightTiles tiles large, scaled up by scale.
+func Render(tiles []byte, widthTiles, heightTiles, scale int, palette Palette) (*image.Paletted, error) {
+	if len(tiles) < widthTiles * heightTiles * 16 {
+		return nil, fmt.Errorf("expected at least %d bytes of tile data, got %d", widthTiles * heightTiles * 16, len(tiles))
+	}
+	if scale < 1 {
+		return nil, fmt.Errorf("invalid scale %d", scale)
+	}
+
+	colors := make(color.Palette, len(palette))
+	for i := range palette {
+		colors[i] = palette[i]
+	}
+	img := image.NewPaletted(image.Rect(0, 0, widthTiles * 8 * scale, heightTiles * 8 * scale), colors)
+
+	for x := 0; x < widthTiles * 8; x++ {
+		for y := 0; y < heightTiles * 8; y++ {
+			tile := (x / 8) * heightTiles + y / 8
+			offset := tile * 16 + (y % 8) * 2
+			bit := uint(7 - x % 8)
+			colorIdx := (tiles[offset] >> bit) & 1 | ((tiles[offset + 1] >> bit) & 1) << 1
+			for sx := 0; sx < scale; sx++ {
+				for sy := 0; sy < scale; sy++ {
+					img.SetColorIndex(x * scale + sx, y * scale + sy, colorIdx)
+				}
+			}
+		}
+	}
+
+	return img, nil
+}
+
+// CropAligned extracts the tiles of a sprite of the given dimensions from the
+// 7x7 tile area it was copied/aligned into.
+func CropAligned(aligned []byte, widthTiles, heightTiles int) ([]byte, error) {
+	if widthTiles < 1 || widthTiles > 7 || heightTiles < 1 || heightTiles > 7 {
+		return nil, fmt.Errorf("cannot crop a %dx%d sprite out of a 7x7 area", widthTiles, heightTiles)
+	}
+	if len(aligned) < 7 * 7 * 16 {
+		return nil, fmt.Errorf("expected %d bytes of tile data, got %d", 7 * 7 * 16, len(aligned))
+	}
+
+	firstColumn := (8 - widthTiles) / 2
+	firstRow := 7 - heightTiles
+	result := make([]byte, 0, widthTiles * heightTiles * 16)
+	for column := firstColumn; column < firstColumn + widthTiles; column++ {
+		start := (column * 7 + firstRow) * 16
+		result = append(result, aligned[start:start + heightTiles * 16]...)
+	}
+	return result, nil
+}
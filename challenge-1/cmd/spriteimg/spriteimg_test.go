@@ -0,0 +1,76 @@
+/*
+ * fools2024-solutions: source code for Kagamiin's solutions for TheZZAZZGlitch April Fools Event 2024's Security Testing Program.
+ * Copyright (C) 2024 Kagamiin~
+ * 
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License,
+ * or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program. If not, see <https://www.gnu.org/licenses/>.
+ */
+
+package spriteimg_test
+
+import (
+	"testing"
+
+	"github.com/Kagamiin/fools2024-solutions/challenge-1/cmd/spriteimg"
+)
+
+func Test_RenderColorIndices(t *testing.T) {
+	// One tile whose first row holds colors 0, 1, 2 and 3, twice
+	tiles := make([]byte, 16)
+	tiles[0] = 0x55
+	tiles[1] = 0x33
+
+	img, err := spriteimg.Render(tiles, 1, 1, 2, spriteimg.Palettes["dmg"])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if img.Bounds().Dx() != 16 || img.Bounds().Dy() != 16 {
+		t.Fatalf("expected a 16x16 image, got %v", img.Bounds())
+	}
+	expected := []uint8{0, 1, 2, 3, 0, 1, 2, 3}
+	for x, colorIdx := range expected {
+		for sx := 0; sx < 2; sx++ {
+			if got := img.ColorIndexAt(x * 2 + sx, 1); got != colorIdx {
+				t.Errorf("pixel %d: expected color %d, got %d", x * 2 + sx, colorIdx, got)
+			}
+		}
+	}
+	if got := img.ColorIndexAt(0, 2); got != 0 {
+		t.Errorf("expected color 0 on the second row, got %d", got)
+	}
+}
+
+func Test_CropAligned(t *testing.T) {
+	aligned := make([]byte, 7 * 7 * 16)
+	for tile := 0; tile < 49; tile++ {
+		aligned[tile * 16] = uint8(tile)
+	}
+
+	// A 5x5 sprite is placed from tile column 1, tile row 2
+	cropped, err := spriteimg.CropAligned(aligned, 5, 5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for column := 0; column < 5; column++ {
+		for row := 0; row < 5; row++ {
+			expected := uint8((column + 1) * 7 + row + 2)
+			if got := cropped[(column * 5 + row) * 16]; got != expected {
+				t.Errorf("tile %d,%d: expected aligned tile %d, got %d", column, row, expected, got)
+			}
+		}
+	}
+
+	if _, err := spriteimg.CropAligned(aligned, 8, 8); err == nil {
+		t.Error("expected an error for an 8x8 sprite")
+	}
+}
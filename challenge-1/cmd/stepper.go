@@ -0,0 +1,216 @@
+/*
+ * fools2024-solutions: source code for Kagamiin's solutions for TheZZAZZGlitch April Fools Event 2024's Security Testing Program.
+ * Copyright (C) 2024 Kagamiin~
+ * 
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License,
+ * or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program. If not, see <https://www.gnu.org/licenses/>.
+ */
+
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/Kagamiin/fools2024-solutions/challenge-1/cmd/decomp"
+)
+
+const (
+	ansiReset = "\033[0m"
+	ansiDest = "\033[97;41m"
+	ansiSource = "\033[97;44m"
+	ansiMaskBit = "\033[91m"
+	ansiClear = "\033[H\033[2J"
+)
+
+func stepJournal() {
+	flags := flag.NewFlagSet("step", flag.ExitOnError)
+	startAt := flags.Int("start", 0, "index of the operation to start at")
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), `usage: 
+	%v step [options] journal memory
+
+journal: decompression journal, as written by the record subcommand
+memory: 64 KiB memory image, or 32 KiB save file, to step the journal over
+
+Commands, entered at the prompt:
+	(empty), n [count]  apply the next operation(s)
+	b [count]           revert the last operation(s)
+	g index             go to the given operation index
+	f addr              go to the first operation touching addr (hex)
+	f                   go to the next operation touching the last searched addr
+	q                   quit
+
+options:
+`, os.Args[0])
+		flags.PrintDefaults()
+	}
+	handle(flags.Parse(os.Args[2:]))
+	
+	if flags.NArg() != 2 {
+		flags.Usage()
+		os.Exit(1)
+	}
+	
+	recording, err := readJournalFile(flags.Arg(0))
+	handle(err)
+	
+	memSpace, err := loadMemImage(flags.Arg(1))
+	handle(err)
+	
+	stepper := recording.NewStepper(memSpace)
+	stepper.Seek(*startAt)
+	
+	input := bufio.NewScanner(os.Stdin)
+	searchAddr := -1
+	status := ""
+	for {
+		fmt.Print(ansiClear)
+		printStepperState(stepper)
+		if status != "" {
+			fmt.Println(status)
+			status = ""
+		}
+		fmt.Print("> ")
+		if !input.Scan() {
+			break
+		}
+		
+		fields := strings.Fields(input.Text())
+		command := "n"
+		if len(fields) > 0 {
+			command = fields[0]
+		}
+		count := 1
+		if len(fields) > 1 && (command == "n" || command == "b") {
+			count, err = strconv.Atoi(fields[1])
+			if err != nil {
+				status = fmt.Sprintf("invalid count: %v", err)
+				continue
+			}
+		}
+		
+		switch command {
+		case "n":
+			stepper.Seek(stepper.Position() + count)
+		case "b":
+			stepper.Seek(stepper.Position() - count)
+		case "g":
+			if len(fields) < 2 {
+				status = "usage: g index"
+				continue
+			}
+			index, err := strconv.Atoi(fields[1])
+			if err != nil {
+				status = fmt.Sprintf("invalid index: %v", err)
+				continue
+			}
+			stepper.Seek(index)
+		case "f":
+			from := stepper.Position() + 1
+			if len(fields) > 1 {
+				addr, err := strconv.ParseUint(strings.TrimPrefix(fields[1], "0x"), 16, 16)
+				if err != nil {
+					status = fmt.Sprintf("invalid address: %v", err)
+					continue
+				}
+				searchAddr = int(addr)
+				from = 0
+			}
+			if searchAddr < 0 {
+				status = "usage: f addr"
+				continue
+			}
+			index := recording.FindTouching(uint16(searchAddr), from)
+			if index < 0 {
+				status = fmt.Sprintf("no operation touches 0x%04x", searchAddr)
+				continue
+			}
+			stepper.Seek(index)
+		case "q":
+			return
+		default:
+			status = fmt.Sprintf("unknown command %q", command)
+		}
+	}
+	log.Println("Done.")
+}
+
+func printStepperState(stepper *decomp.Stepper) {
+	fmt.Printf("Operation %d of %d\n\n", stepper.Position(), stepper.Len())
+	
+	operation, ok := stepper.Current()
+	if !ok {
+		fmt.Println("End of journal reached.")
+		return
+	}
+	
+	memory := stepper.Memory()
+	fmt.Printf("Next: %-8v dest 0x%04x  source 0x%04x  mask %08b  value %08b  phase %v\n\n",
+		operation.T, operation.DestAddr, operation.SourceAddr, operation.Mask, operation.Value, operation.Phase)
+	fmt.Printf("dest bits:   %s\n", formatMaskedBits(memory[operation.DestAddr], operation.Mask))
+	usesSource := operation.T == decomp.DCopy || operation.T == decomp.DXor || operation.T == decomp.DeltaDec || operation.T == decomp.Scale
+	if usesSource {
+		fmt.Printf("source bits: %s\n", formatMaskedBits(memory[operation.SourceAddr], operation.Mask))
+	}
+	fmt.Println()
+	
+	fmt.Printf("%sdest%s around 0x%04x:\n", ansiDest, ansiReset, operation.DestAddr)
+	printHexView(memory, operation, operation.DestAddr, usesSource)
+	if usesSource {
+		fmt.Printf("\n%ssource%s around 0x%04x:\n", ansiSource, ansiReset, operation.SourceAddr)
+		printHexView(memory, operation, operation.SourceAddr, usesSource)
+	}
+	fmt.Println()
+}
+
+func formatMaskedBits(value, mask uint8) string {
+	var sb strings.Builder
+	for bit := 7; bit >= 0; bit-- {
+		digit := (value >> bit) & 1
+		if mask & (1 << bit) != 0 {
+			fmt.Fprintf(&sb, "%s%d%s", ansiMaskBit, digit, ansiReset)
+		} else {
+			fmt.Fprintf(&sb, "%d", digit)
+		}
+	}
+	return sb.String()
+}
+
+func printHexView(memory []byte, operation decomp.Operation, center uint16, usesSource bool) {
+	rowStart := int(center &^ 0xf) - 0x10
+	for row := 0; row < 3; row++ {
+		base := rowStart + row * 0x10
+		if base < 0 || base >= len(memory) {
+			continue
+		}
+		fmt.Printf("%04x: ", base)
+		for offset := 0; offset < 0x10; offset++ {
+			addr := uint16(base + offset)
+			switch {
+			case addr == operation.DestAddr:
+				fmt.Printf("%s%02x%s ", ansiDest, memory[addr], ansiReset)
+			case usesSource && addr == operation.SourceAddr:
+				fmt.Printf("%s%02x%s ", ansiSource, memory[addr], ansiReset)
+			default:
+				fmt.Printf("%02x ", memory[addr])
+			}
+		}
+		fmt.Println()
+	}
+}